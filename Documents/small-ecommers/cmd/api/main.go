@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"small-ecommers/internal/domain/entity"
 	"small-ecommers/internal/handler"
 	"small-ecommers/internal/infrastructure/database"
+	"small-ecommers/internal/infrastructure/email"
 	"small-ecommers/internal/infrastructure/kafka"
+	"small-ecommers/internal/infrastructure/payment"
 	"small-ecommers/internal/infrastructure/repository"
+	"small-ecommers/internal/infrastructure/search"
+	"small-ecommers/internal/infrastructure/storage"
 	"small-ecommers/internal/middleware"
 	"small-ecommers/internal/usecase"
 	"small-ecommers/pkg/config"
@@ -36,7 +43,7 @@ func main() {
 	defer db.Close()
 
 	// Run migrations
-	if err := database.Migrate(db); err != nil {
+	if err := database.Migrate(db, cfg.Pricing.Currency); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
@@ -50,27 +57,143 @@ func main() {
 		defer kafkaProducer.Close()
 	}
 
+	// Initialize mailer
+	var mailer usecase.Mailer = email.NewCaptureMailer()
+	if cfg.Email.SMTPHost != "" {
+		mailer = email.NewSMTPMailer(cfg.Email.SMTPHost, cfg.Email.SMTPPort, cfg.Email.SMTPUsername, cfg.Email.SMTPPassword, cfg.Email.From)
+	} else {
+		log.Printf("Warning: SMTP_HOST is not set, emails are logged instead of sent")
+	}
+
+	// Initialize blob storage
+	blobStore, err := storage.NewLocalBlobStore(cfg.Invoice.StorageDir)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	mediaStore, err := storage.NewLocalBlobStore(cfg.Media.StorageDir)
+	if err != nil {
+		log.Fatalf("Failed to initialize media storage: %v", err)
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewPostgresUserRepository(db)
 	productRepo := repository.NewPostgresProductRepository(db)
+	categoryRepo := repository.NewPostgresCategoryRepository(db)
+	priceRepo := repository.NewPostgresPriceRepository(db)
 	cartRepo := repository.NewPostgresCartRepository(db)
 	orderRepo := repository.NewPostgresOrderRepository(db)
+	exchangeRateRepo := repository.NewPostgresExchangeRateRepository(db)
+	invoiceRepo := repository.NewPostgresInvoiceRepository(db)
+	subscriptionRepo := repository.NewPostgresSubscriptionRepository(db)
+	giftCardRepo := repository.NewPostgresGiftCardRepository(db)
+	ledgerRepo := repository.NewPostgresLedgerRepository(db)
+	loyaltyRepo := repository.NewPostgresLoyaltyRepository(db)
+	fraudRepo := repository.NewPostgresFraudRepository(db)
+
+	// Initialize event bus
+	events := usecase.NewEventBus()
+	if kafkaProducer != nil {
+		events.Subscribe(usecase.KafkaOrderCreatedHandler(kafkaProducer), entity.OrderEventCreated)
+	}
 
 	// Initialize use cases
 	userUseCase := usecase.NewUserUseCase(userRepo)
-	productUseCase := usecase.NewProductUseCase(productRepo)
-	cartUseCase := usecase.NewCartUseCase(cartRepo, productRepo)
-	orderUseCase := usecase.NewOrderUseCase(orderRepo, cartRepo, productRepo, kafkaProducer)
+	productUseCase := usecase.NewProductUseCase(productRepo, categoryRepo, priceRepo, mediaStore, usecase.ImageSettings{
+		BaseURL:       cfg.Media.BaseURL,
+		ThumbnailSize: cfg.Media.ThumbnailSize,
+		MaxBytes:      int64(cfg.Media.MaxUploadBytes),
+		MaxPixels:     cfg.Media.MaxPixels,
+	}, cfg.Pricing.Currency)
+	productCSVUseCase := usecase.NewProductCSVUseCase(productRepo, categoryRepo, cfg.Pricing.Currency)
+	categoryUseCase := usecase.NewCategoryUseCase(categoryRepo)
+	searchUseCase := usecase.NewSearchUseCase(search.NewPostgresProductSearch(db), productRepo)
+	prices := usecase.NewPriceResolver(exchangeRateRepo, cfg.Pricing.Currency)
+	exchangeRateUseCase := usecase.NewExchangeRateUseCase(exchangeRateRepo, cfg.Pricing.Currency)
+	cartUseCase := usecase.NewCartUseCase(cartRepo, productRepo, prices)
+	pricing, err := newPricingPipeline(&cfg.Pricing)
+	if err != nil {
+		log.Fatalf("Invalid pricing configuration: %v", err)
+	}
+	invoiceUseCase := usecase.NewInvoiceUseCase(invoiceRepo, orderRepo, productRepo, userRepo, blobStore, usecase.InvoiceSeller{
+		Name:    cfg.Invoice.SellerName,
+		Address: cfg.Invoice.SellerAddress,
+	})
+	creditUseCase := usecase.NewCreditUseCase(giftCardRepo, ledgerRepo)
+	clock := usecase.SystemClock{}
+	pointValue, err := entity.ParseMoney(cfg.Loyalty.PointValue, cfg.Pricing.Currency)
+	if err != nil {
+		log.Fatalf("Invalid loyalty point value: %v", err)
+	}
+	loyaltyUseCase := usecase.NewLoyaltyUseCase(loyaltyRepo, usecase.LoyaltyRules{
+		EarnRate:   cfg.Loyalty.EarnRate,
+		PointValue: pointValue,
+		Validity:   cfg.Loyalty.PointsValidity,
+	}, clock)
+	fraudScreener, err := newFraudScreener(&cfg.Fraud, cfg.Pricing.Currency, fraudRepo, userRepo, orderRepo, clock)
+	if err != nil {
+		log.Fatalf("Invalid fraud configuration: %v", err)
+	}
+	orderUseCase := usecase.NewOrderUseCase(
+		orderRepo,
+		cartRepo,
+		productRepo,
+		cartUseCase,
+		prices,
+		pricing,
+		invoiceUseCase,
+		creditUseCase,
+		loyaltyUseCase,
+		fraudScreener,
+		payment.NewManualGateway(),
+		events,
+	)
+	orderExportUseCase := usecase.NewOrderExportUseCase(orderRepo)
+	subscriptionUseCase := usecase.NewSubscriptionUseCase(subscriptionRepo, productRepo, prices, clock)
+	notificationUseCase, err := usecase.NewNotificationUseCase(userRepo, mailer, cfg.Invoice.SellerName)
+	if err != nil {
+		log.Fatalf("Failed to load email templates: %v", err)
+	}
+	events.Subscribe(notificationUseCase.HandleOrderEvent, notificationUseCase.Events()...)
+
+	// Initialize background jobs
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	subscriptionScheduler := usecase.NewSubscriptionScheduler(
+		subscriptionRepo,
+		orderUseCase,
+		clock,
+		cfg.Scheduler.SubscriptionBatchSize,
+	)
+	go subscriptionScheduler.Run(jobs, cfg.Scheduler.SubscriptionInterval)
+
+	priceScheduler := usecase.NewPriceScheduler(priceRepo, productRepo, clock, cfg.Scheduler.PriceBatchSize)
+	go priceScheduler.Run(jobs, cfg.Scheduler.PriceInterval)
+	go events.Run(jobs)
 
 	// Initialize handlers
 	userHandler := handler.NewUserHandler(userUseCase)
 	productHandler := handler.NewProductHandler(productUseCase)
+	productCSVHandler := handler.NewProductCSVHandler(productCSVUseCase)
+	mediaHandler := handler.NewMediaHandler(productUseCase, cfg.Media.CacheMaxAge)
+	searchHandler := handler.NewSearchHandler(searchUseCase)
+	categoryHandler := handler.NewCategoryHandler(categoryUseCase)
 	cartHandler := handler.NewCartHandler(cartUseCase)
 	orderHandler := handler.NewOrderHandler(orderUseCase)
+	orderExportHandler := handler.NewOrderExportHandler(orderExportUseCase)
+	exchangeRateHandler := handler.NewExchangeRateHandler(exchangeRateUseCase)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceUseCase)
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionUseCase)
+	creditHandler := handler.NewCreditHandler(creditUseCase)
+	loyaltyHandler := handler.NewLoyaltyHandler(loyaltyUseCase)
+	fraudHandler := handler.NewFraudHandler(fraudScreener)
 
 	// Create Fiber app
+	// Leave room above the largest image for the rest of the multipart request
 	app := fiber.New(fiber.Config{
 		ErrorHandler: middleware.ErrorHandler,
+		BodyLimit:    max(fiber.DefaultBodyLimit, cfg.Media.MaxUploadBytes+1<<20),
 	})
 
 	// Middleware
@@ -84,6 +207,9 @@ func main() {
 		})
 	})
 
+	// Product images
+	app.Get("/media/*", mediaHandler.ServeMedia)
+
 	// API Routes
 	api := app.Group("/api/v1")
 
@@ -95,12 +221,18 @@ func main() {
 	auth := api.Group("")
 	auth.Use(middleware.AuthRequired())
 
+	adminOnly := middleware.AdminRequired(cfg.Auth.AdminUserIDs)
+
 	// Products
 	auth.Get("/products", productHandler.ListProducts)
+	auth.Get("/products/search", searchHandler.SearchProducts)
 	auth.Get("/products/:id", productHandler.GetProduct)
 	auth.Post("/products", productHandler.CreateProduct)
 	auth.Put("/products/:id", productHandler.UpdateProduct)
-	auth.Delete("/products/:id", productHandler.DeleteProduct)
+	auth.Get("/products/:id/price-history", adminOnly, productHandler.GetPriceHistory)
+
+	// Categories
+	auth.Get("/categories", categoryHandler.GetCategoryTree)
 
 	// Cart
 	auth.Get("/cart", cartHandler.GetCart)
@@ -108,6 +240,7 @@ func main() {
 	auth.Delete("/cart/items/:id", cartHandler.RemoveItem)
 	auth.Put("/cart/items/:id", cartHandler.UpdateItemQuantity)
 	auth.Post("/cart/clear", cartHandler.ClearCart)
+	auth.Put("/cart/currency", cartHandler.SetCurrency)
 
 	// Orders
 	auth.Post("/orders", orderHandler.CreateOrder)
@@ -115,12 +248,68 @@ func main() {
 	auth.Get("/orders/:id", orderHandler.GetOrder)
 	auth.Post("/orders/:id/pay", orderHandler.PayOrder)
 	auth.Post("/orders/:id/cancel", orderHandler.CancelOrder)
-	auth.Put("/orders/:id/status", orderHandler.UpdateOrderStatus)
+	auth.Get("/orders/:id/invoice", invoiceHandler.GetInvoice)
+	auth.Post("/orders/:id/reorder", orderHandler.Reorder)
+
+	// Subscriptions
+	auth.Post("/subscriptions", subscriptionHandler.CreateSubscription)
+	auth.Get("/subscriptions", subscriptionHandler.GetUserSubscriptions)
+	auth.Get("/subscriptions/:id", subscriptionHandler.GetSubscription)
+	auth.Post("/subscriptions/:id/pause", subscriptionHandler.PauseSubscription)
+	auth.Post("/subscriptions/:id/resume", subscriptionHandler.ResumeSubscription)
+	auth.Post("/subscriptions/:id/cancel", subscriptionHandler.CancelSubscription)
 
 	// Users
 	auth.Get("/users", userHandler.ListUsers)
 	auth.Get("/users/:id", userHandler.GetUser)
 
+	// Exchange rates
+	auth.Get("/exchange-rates", exchangeRateHandler.ListRates)
+
+	// Gift cards and store credit
+	auth.Get("/gift-cards/:code", creditHandler.GetGiftCard)
+	auth.Get("/store-credit", creditHandler.GetStoreCredit)
+
+	// Loyalty points
+	auth.Get("/me/loyalty", loyaltyHandler.GetBalance)
+	auth.Get("/me/loyalty/history", loyaltyHandler.GetHistory)
+
+	// Admin routes
+	admin := auth.Group("/admin")
+	admin.Use(adminOnly)
+
+	admin.Put("/exchange-rates/:currency", exchangeRateHandler.SetRate)
+	admin.Post("/exchange-rates/import", exchangeRateHandler.ImportRates)
+	admin.Post("/gift-cards", creditHandler.IssueGiftCard)
+	admin.Get("/gift-cards/:code", creditHandler.GetGiftCardStatement)
+	admin.Post("/users/:id/store-credit", creditHandler.AdjustStoreCredit)
+	admin.Get("/orders", orderHandler.SearchOrders)
+	admin.Get("/orders/count", orderHandler.CountOrders)
+	admin.Get("/orders/export", orderExportHandler.ExportOrders)
+	admin.Put("/orders/:id/status", orderHandler.UpdateOrderStatus)
+	admin.Post("/orders/:id/refund", orderHandler.RefundOrder)
+	admin.Post("/orders/:id/approve", orderHandler.ApproveOrder)
+	admin.Post("/orders/:id/reject", orderHandler.RejectOrder)
+	admin.Get("/orders/:id/screening", fraudHandler.GetScreening)
+	admin.Get("/fraud/reviews", fraudHandler.ListPendingReviews)
+	admin.Post("/products/import", productCSVHandler.ImportProducts)
+	admin.Get("/products/export", productCSVHandler.ExportProducts)
+	admin.Get("/products", productHandler.ListAllProducts)
+	admin.Delete("/products/:id", productHandler.ArchiveProduct)
+	admin.Post("/products/:id/restore", productHandler.RestoreProduct)
+	admin.Post("/products/:id/variants", productHandler.CreateVariant)
+	admin.Put("/products/:id/variants/:variantId", productHandler.UpdateVariant)
+	admin.Delete("/products/:id/variants/:variantId", productHandler.DeleteVariant)
+	admin.Post("/products/:id/images", productHandler.UploadImage)
+	admin.Put("/products/:id/images/:imageId", productHandler.UpdateImage)
+	admin.Delete("/products/:id/images/:imageId", productHandler.DeleteImage)
+	admin.Get("/products/:id/scheduled-prices", productHandler.ListScheduledPrices)
+	admin.Post("/products/:id/scheduled-prices", productHandler.SchedulePrice)
+	admin.Delete("/products/:id/scheduled-prices/:scheduleId", productHandler.CancelScheduledPrice)
+	admin.Post("/categories", categoryHandler.CreateCategory)
+	admin.Put("/categories/:id", categoryHandler.UpdateCategory)
+	admin.Delete("/categories/:id", categoryHandler.DeleteCategory)
+
 	// Start server
 	go func() {
 		log.Printf("Server starting on port %s", cfg.Server.Port)
@@ -135,7 +324,101 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopJobs()
 	if err := app.Shutdown(); err != nil {
 		log.Printf("Error during shutdown: %v", err)
 	}
 }
+
+// newPricingPipeline builds the order pricing pipeline from configuration
+// Discounts are applied before tax, and tax before shipping
+func newPricingPipeline(cfg *config.PricingConfig) (*usecase.PricingPipeline, error) {
+	shippingFee, err := entity.ParseMoney(cfg.ShippingFee, cfg.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("shipping fee: %w", err)
+	}
+	freeShippingThreshold, err := entity.ParseMoney(cfg.FreeShippingThreshold, cfg.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("free shipping threshold: %w", err)
+	}
+	orderDiscountThreshold, err := entity.ParseMoney(cfg.OrderDiscountThreshold, cfg.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("order discount threshold: %w", err)
+	}
+
+	var stages []usecase.PricingStage
+
+	if cfg.BulkDiscountMinQuantity > 0 && cfg.BulkDiscountPercent.Sign() > 0 {
+		stages = append(stages, &usecase.BulkLineDiscount{
+			MinQuantity: cfg.BulkDiscountMinQuantity,
+			Percent:     cfg.BulkDiscountPercent,
+		})
+	}
+
+	if cfg.OrderDiscountPercent.Sign() > 0 {
+		stages = append(stages, &usecase.OrderThresholdDiscount{
+			MinSubtotal: orderDiscountThreshold,
+			Percent:     cfg.OrderDiscountPercent,
+		})
+	}
+
+	stages = append(stages,
+		&usecase.PointsDiscount{},
+		&usecase.FlatTax{Rate: cfg.TaxRate, Rounding: entity.RoundHalfUp},
+		&usecase.FlatShipping{Fee: shippingFee, FreeThreshold: freeShippingThreshold},
+	)
+
+	return usecase.NewPricingPipeline(stages...), nil
+}
+
+// newFraudScreener builds the fraud screening rules from configuration
+func newFraudScreener(
+	cfg *config.FraudConfig,
+	currency string,
+	fraudRepo *repository.PostgresFraudRepository,
+	userRepo *repository.PostgresUserRepository,
+	orderRepo *repository.PostgresOrderRepository,
+	clock usecase.Clock,
+) (*usecase.FraudScreener, error) {
+	newAccountMinTotal, err := entity.ParseMoney(cfg.NewAccountMinTotal, currency)
+	if err != nil {
+		return nil, fmt.Errorf("new account minimum total: %w", err)
+	}
+
+	thresholds := usecase.FraudThresholds{
+		ReviewScore: cfg.ReviewScore,
+		RejectScore: cfg.RejectScore,
+	}
+
+	return usecase.NewFraudScreener(fraudRepo, userRepo, clock, thresholds,
+		&usecase.UserVelocityRule{
+			Orders:    orderRepo,
+			Window:    cfg.VelocityWindow,
+			MaxOrders: cfg.MaxOrdersPerUser,
+			Score:     40,
+			Clock:     clock,
+		},
+		&usecase.IPVelocityRule{
+			Orders:    orderRepo,
+			Window:    cfg.VelocityWindow,
+			MaxOrders: cfg.MaxOrdersPerIP,
+			Score:     40,
+			Clock:     clock,
+		},
+		&usecase.OrderValueOutlierRule{
+			Orders:     orderRepo,
+			MinOrders:  cfg.OutlierMinOrders,
+			Multiplier: cfg.OutlierMultiplier,
+			Score:      30,
+		},
+		&usecase.AddressMismatchRule{
+			Score: 20,
+		},
+		&usecase.NewAccountHighValueRule{
+			MaxAccountAge: cfg.NewAccountAge,
+			MinTotal:      newAccountMinTotal,
+			Score:         40,
+			Clock:         clock,
+		},
+	), nil
+}
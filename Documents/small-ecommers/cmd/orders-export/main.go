@@ -0,0 +1,130 @@
+// Command orders-export writes the orders placed in a date range, with their items,
+// as CSV or JSON Lines, for example:
+//
+//	orders-export -from 2024-01-01 -to 2024-02-01 -status paid,shipped -o january.csv
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/infrastructure/database"
+	"small-ecommers/internal/infrastructure/repository"
+	"small-ecommers/internal/usecase"
+	"small-ecommers/pkg/config"
+)
+
+func main() {
+	from := flag.String("from", "", "export orders placed at or after, RFC 3339 or YYYY-MM-DD (required)")
+	to := flag.String("to", "", "export orders placed before, RFC 3339 or YYYY-MM-DD (required)")
+	format := flag.String("format", string(usecase.ExportFormatCSV), "output format, csv or ndjson")
+	status := flag.String("status", "", "comma separated order statuses")
+	userID := flag.String("user", "", "only orders of this user ID")
+	productID := flag.String("product", "", "only orders containing this product ID")
+	currency := flag.String("currency", "", "only orders in this currency, required with a total range")
+	minTotal := flag.String("min-total", "", "minimum order total")
+	maxTotal := flag.String("max-total", "", "maximum order total")
+	output := flag.String("o", "", "output file, standard output if empty")
+	flag.Parse()
+
+	req := &usecase.OrderSearchRequest{
+		UserID:    *userID,
+		ProductID: *productID,
+		Currency:  strings.ToUpper(*currency),
+		MinTotal:  *minTotal,
+		MaxTotal:  *maxTotal,
+	}
+
+	for _, s := range strings.Split(*status, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			req.Statuses = append(req.Statuses, entity.OrderStatus(s))
+		}
+	}
+
+	var err error
+	if req.From, err = parseTime(*from); err != nil {
+		log.Fatalf("Invalid -from: %v", err)
+	}
+	if req.To, err = parseTime(*to); err != nil {
+		log.Fatalf("Invalid -to: %v", err)
+	}
+
+	cfg := config.LoadConfig()
+
+	db, err := database.NewPostgresConnection(&database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.DBName,
+		SSLMode:  cfg.Database.SSLMode,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	exports := usecase.NewOrderExportUseCase(repository.NewPostgresOrderRepository(db))
+
+	write, err := exports.ExportOrders(ctx, req, usecase.ExportFormat(*format))
+	if err != nil {
+		log.Fatalf("Invalid export: %v", err)
+	}
+
+	if err := run(write, *output); err != nil {
+		log.Fatalf("Failed to export orders: %v", err)
+	}
+}
+
+// run writes the export to the output file, or to standard output
+func run(write func(w io.Writer) error, output string) error {
+	if output == "" {
+		out := bufio.NewWriter(os.Stdout)
+		if err := write(out); err != nil {
+			// Keep what was written, including the trailer that marks the export incomplete
+			out.Flush()
+			return err
+		}
+		return out.Flush()
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// parseTime reads an RFC 3339 time or a date, nil if the value is empty
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("expected RFC 3339 or YYYY-MM-DD, got %q", value)
+}
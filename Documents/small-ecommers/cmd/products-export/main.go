@@ -0,0 +1,85 @@
+// Command products-export writes the products as CSV, one row per variant,
+// in the format read by products-import, for example:
+//
+//	products-export -status active -o catalog.csv
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/infrastructure/database"
+	"small-ecommers/internal/infrastructure/repository"
+	"small-ecommers/internal/usecase"
+	"small-ecommers/pkg/config"
+)
+
+func main() {
+	status := flag.String("status", "", "only products in this status, active or archived")
+	output := flag.String("o", "", "output file, standard output if empty")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+
+	db, err := database.NewPostgresConnection(&database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.DBName,
+		SSLMode:  cfg.Database.SSLMode,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	exports := usecase.NewProductCSVUseCase(
+		repository.NewPostgresProductRepository(db),
+		repository.NewPostgresCategoryRepository(db),
+		cfg.Pricing.Currency,
+	)
+
+	write, err := exports.ExportProducts(ctx, entity.ProductStatus(*status))
+	if err != nil {
+		log.Fatalf("Invalid export: %v", err)
+	}
+
+	if err := run(write, *output); err != nil {
+		log.Fatalf("Failed to export products: %v", err)
+	}
+}
+
+// run writes the export to the output file, or to standard output
+func run(write func(w io.Writer) error, output string) error {
+	if output == "" {
+		out := bufio.NewWriter(os.Stdout)
+		if err := write(out); err != nil {
+			return err
+		}
+		return out.Flush()
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,88 @@
+// Command products-import creates and updates products from a CSV file, matching them by SKU,
+// and prints the report as JSON, for example:
+//
+//	products-import -atomic catalog.csv
+//
+// It exits with status 1 when any row was rejected.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"small-ecommers/internal/infrastructure/database"
+	"small-ecommers/internal/infrastructure/repository"
+	"small-ecommers/internal/usecase"
+	"small-ecommers/pkg/config"
+)
+
+func main() {
+	atomic := flag.Bool("atomic", false, "import nothing unless every row is valid")
+	dryRun := flag.Bool("dry-run", false, "validate the file and report without writing")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file]\nReads standard input without a file.\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var input io.Reader = os.Stdin
+	if path := flag.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Failed to open input file: %v", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	cfg := config.LoadConfig()
+
+	db, err := database.NewPostgresConnection(&database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.DBName,
+		SSLMode:  cfg.Database.SSLMode,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	imports := usecase.NewProductCSVUseCase(
+		repository.NewPostgresProductRepository(db),
+		repository.NewPostgresCategoryRepository(db),
+		cfg.Pricing.Currency,
+	)
+
+	report, err := imports.ImportProducts(ctx, &usecase.ProductImportRequest{
+		Data:   input,
+		Atomic: *atomic,
+		DryRun: *dryRun,
+	})
+	if err != nil {
+		log.Fatalf("Failed to import products: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	if len(report.Errors) > 0 {
+		db.Close()
+		os.Exit(1)
+	}
+}
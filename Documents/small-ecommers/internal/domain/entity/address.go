@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Address represents a postal address
+type Address struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	PostalCode string `json:"postal_code"`
+	// Country is an ISO 3166-1 alpha-2 code
+	Country string `json:"country"`
+}
+
+// Validate checks that the address has the fields needed to deliver to it
+func (a *Address) Validate() error {
+	if strings.TrimSpace(a.Line1) == "" || strings.TrimSpace(a.City) == "" || len(strings.TrimSpace(a.Country)) != 2 {
+		return ErrInvalidAddress
+	}
+	return nil
+}
+
+// SameCountry checks if two addresses are in the same country
+func (a *Address) SameCountry(other *Address) bool {
+	return strings.EqualFold(strings.TrimSpace(a.Country), strings.TrimSpace(other.Country))
+}
+
+// SamePostalCode checks if two addresses share a postal code, ignoring case and spacing
+func (a *Address) SamePostalCode(other *Address) bool {
+	normalize := func(code string) string {
+		return strings.ToUpper(strings.Join(strings.Fields(code), ""))
+	}
+	return normalize(a.PostalCode) == normalize(other.PostalCode)
+}
+
+// Value implements driver.Valuer, storing the address as JSON
+func (a Address) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Scan implements sql.Scanner, reading an address stored as JSON
+func (a *Address) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into Address", src)
+	}
+}
@@ -0,0 +1,248 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// attributeKeyPattern matches lower case attribute keys such as "material" or "weight_kg"
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// AttributeType is the type of the values of a product attribute
+type AttributeType string
+
+const (
+	// AttributeTypeText is free text
+	AttributeTypeText AttributeType = "text"
+	// AttributeTypeNumber is a decimal number, in the unit of the attribute
+	AttributeTypeNumber AttributeType = "number"
+	// AttributeTypeBoolean is true or false
+	AttributeTypeBoolean AttributeType = "boolean"
+	// AttributeTypeEnum is one of the values listed by the attribute
+	AttributeTypeEnum AttributeType = "enum"
+)
+
+// IsValid checks if the attribute type is known
+func (t AttributeType) IsValid() bool {
+	switch t {
+	case AttributeTypeText, AttributeTypeNumber, AttributeTypeBoolean, AttributeTypeEnum:
+		return true
+	}
+	return false
+}
+
+// AttributeDefinition describes an attribute the products of a category have
+type AttributeDefinition struct {
+	Key  string        `json:"key"`
+	Name string        `json:"name"`
+	Type AttributeType `json:"type"`
+	// Values are the allowed values of an enum attribute
+	Values []string `json:"values,omitempty"`
+	// Unit of a number attribute, such as kg or cm
+	Unit string `json:"unit,omitempty"`
+	// Required attributes must be set on every product of the category
+	Required bool `json:"required,omitempty"`
+}
+
+// AttributeSchema is the list of attributes of a category, stored as JSON
+type AttributeSchema []AttributeDefinition
+
+// Value implements driver.Valuer, storing the schema as JSON
+func (s AttributeSchema) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]AttributeDefinition(s))
+}
+
+// Scan implements sql.Scanner, reading a schema stored as JSON
+func (s *AttributeSchema) Scan(src interface{}) error {
+	return scanJSON(src, s)
+}
+
+// Definition returns the definition of the attribute with the given key
+func (s AttributeSchema) Definition(key string) (AttributeDefinition, bool) {
+	for _, definition := range s {
+		if definition.Key == key {
+			return definition, true
+		}
+	}
+	return AttributeDefinition{}, false
+}
+
+// ValidateAttributeSchema checks the attribute definitions of a category
+func ValidateAttributeSchema(schema AttributeSchema) error {
+	keys := make(map[string]bool, len(schema))
+	for _, definition := range schema {
+		if !attributeKeyPattern.MatchString(definition.Key) || keys[definition.Key] {
+			return fmt.Errorf("%w: attribute keys must be unique lower case letters, digits and underscores", ErrInvalidCategory)
+		}
+		keys[definition.Key] = true
+
+		if strings.TrimSpace(definition.Name) == "" {
+			return fmt.Errorf("%w: attribute %q has no name", ErrInvalidCategory, definition.Key)
+		}
+
+		if !definition.Type.IsValid() {
+			return fmt.Errorf("%w: attribute %q has unknown type %q", ErrInvalidCategory, definition.Key, definition.Type)
+		}
+
+		if definition.Type != AttributeTypeEnum {
+			if len(definition.Values) > 0 {
+				return fmt.Errorf("%w: only enum attributes list values, %q is %s", ErrInvalidCategory, definition.Key, definition.Type)
+			}
+			continue
+		}
+
+		if len(definition.Values) == 0 {
+			return fmt.Errorf("%w: enum attribute %q has no values", ErrInvalidCategory, definition.Key)
+		}
+		values := make(map[string]bool, len(definition.Values))
+		for _, value := range definition.Values {
+			if value == "" || values[value] {
+				return fmt.Errorf("%w: values of attribute %q must be unique and non-empty", ErrInvalidCategory, definition.Key)
+			}
+			values[value] = true
+		}
+	}
+	return nil
+}
+
+// MergeAttributeSchemas combines the schemas of the categories of a product
+// An attribute defined by several categories is required if any of them requires it,
+// and an enum takes the values of all of them. Its type must be the same everywhere.
+func MergeAttributeSchemas(schemas ...AttributeSchema) (AttributeSchema, error) {
+	var merged AttributeSchema
+	index := make(map[string]int)
+
+	for _, schema := range schemas {
+		for _, definition := range schema {
+			i, ok := index[definition.Key]
+			if !ok {
+				definition.Values = slices.Clone(definition.Values)
+				index[definition.Key] = len(merged)
+				merged = append(merged, definition)
+				continue
+			}
+
+			existing := &merged[i]
+			if existing.Type != definition.Type {
+				return nil, fmt.Errorf("%w: attribute %q is both %s and %s", ErrInvalidAttribute, definition.Key, existing.Type, definition.Type)
+			}
+			existing.Required = existing.Required || definition.Required
+			for _, value := range definition.Values {
+				if !slices.Contains(existing.Values, value) {
+					existing.Values = append(existing.Values, value)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Key < merged[j].Key })
+	return merged, nil
+}
+
+// Validate checks the attributes of a product against the schema of its categories
+// Every attribute must be defined with a value of its type, and required attributes must be set
+func (s AttributeSchema) Validate(attributes ProductAttributes) error {
+	for key, value := range attributes {
+		definition, ok := s.Definition(key)
+		if !ok {
+			return fmt.Errorf("%w: %q is not an attribute of the product's categories", ErrInvalidAttribute, key)
+		}
+		if err := definition.validateValue(value); err != nil {
+			return err
+		}
+	}
+
+	for _, definition := range s {
+		if _, ok := attributes[definition.Key]; definition.Required && !ok {
+			return fmt.Errorf("%w: %q is required", ErrInvalidAttribute, definition.Key)
+		}
+	}
+
+	return nil
+}
+
+// validateValue checks that a value decoded from JSON has the type of the attribute
+func (d AttributeDefinition) validateValue(value interface{}) error {
+	valid := false
+	switch v := value.(type) {
+	case string:
+		valid = (d.Type == AttributeTypeText && strings.TrimSpace(v) != "") ||
+			(d.Type == AttributeTypeEnum && slices.Contains(d.Values, v))
+	case float64:
+		valid = d.Type == AttributeTypeNumber && !math.IsNaN(v) && !math.IsInf(v, 0)
+	case bool:
+		valid = d.Type == AttributeTypeBoolean
+	}
+
+	if !valid {
+		if d.Type == AttributeTypeEnum {
+			return fmt.Errorf("%w: %q must be one of %s", ErrInvalidAttribute, d.Key, strings.Join(d.Values, ", "))
+		}
+		return fmt.Errorf("%w: %q must be a %s", ErrInvalidAttribute, d.Key, d.Type)
+	}
+	return nil
+}
+
+// ParseValue reads a value of the attribute from text, such as a query parameter or a CSV cell
+func (d AttributeDefinition) ParseValue(text string) (interface{}, error) {
+	var value interface{} = text
+
+	switch d.Type {
+	case AttributeTypeNumber:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q must be a number", ErrInvalidAttribute, d.Key)
+		}
+		value = number
+	case AttributeTypeBoolean:
+		boolean, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q must be true or false", ErrInvalidAttribute, d.Key)
+		}
+		value = boolean
+	}
+
+	if err := d.validateValue(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// FormatAttributeValue returns the text form of an attribute value, as read by ParseValue
+func FormatAttributeValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ProductAttributes maps attribute keys to the values of a product, stored as JSON
+// Values are strings, numbers (float64) or booleans, as decoded from JSON
+type ProductAttributes map[string]interface{}
+
+// Value implements driver.Valuer, storing the attributes as JSON
+func (a ProductAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]interface{}(a))
+}
+
+// Scan implements sql.Scanner, reading attributes stored as JSON
+func (a *ProductAttributes) Scan(src interface{}) error {
+	return scanJSON(src, a)
+}
@@ -0,0 +1,25 @@
+package entity
+
+// Backorder is the outstanding quantity of an order item waiting for stock
+type Backorder struct {
+	OrderID     string `json:"order_id"`
+	OrderItemID string `json:"order_item_id"`
+	Quantity    int    `json:"quantity"`
+	// Allocated is the quantity filled by the latest allocation
+	Allocated int `json:"allocated"`
+}
+
+// AllocateBackorders fills backorders first come, first served from the given stock
+// The queue must be ordered oldest first. Returns the stock left over.
+func AllocateBackorders(stock int, queue []*Backorder) int {
+	for _, backorder := range queue {
+		if stock <= 0 {
+			break
+		}
+
+		backorder.Allocated = min(stock, backorder.Quantity)
+		backorder.Quantity -= backorder.Allocated
+		stock -= backorder.Allocated
+	}
+	return stock
+}
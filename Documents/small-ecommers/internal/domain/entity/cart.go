@@ -4,49 +4,56 @@ import "time"
 
 // Cart represents a shopping cart entity in the domain
 type Cart struct {
-	ID        string      `json:"id"`
-	UserID    string      `json:"user_id"`
-	Items     []*CartItem `json:"items"`
-	CreatedAt time.Time   `json:"created_at"`
-	UpdatedAt time.Time   `json:"updated_at"`
+	ID       string      `json:"id"`
+	UserID   string      `json:"user_id"`
+	Currency string      `json:"currency"`
+	Items    []*CartItem `json:"items"`
+	// Version is incremented by every update of the cart, it guards against lost updates
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // CartItem represents an item in the shopping cart
 type CartItem struct {
-	ID        string  `json:"id"`
-	ProductID string  `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"`
+	Quantity  int    `json:"quantity"`
+	Price     Money  `json:"price"`
 }
 
 // NewCart creates a new Cart entity
-func NewCart(id, userID string) *Cart {
+func NewCart(id, userID, currency string) *Cart {
 	now := time.Now()
 	return &Cart{
 		ID:        id,
 		UserID:    userID,
+		Currency:  currency,
 		Items:     make([]*CartItem, 0),
+		Version:   1,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 }
 
 // NewCartItem creates a new CartItem
-func NewCartItem(id, productID string, quantity int, price float64) *CartItem {
+func NewCartItem(id, productID, variantID string, quantity int, price Money) *CartItem {
 	return &CartItem{
 		ID:        id,
 		ProductID: productID,
+		VariantID: variantID,
 		Quantity:  quantity,
 		Price:     price,
 	}
 }
 
 // AddItem adds an item to the cart
-// If the item already exists, it updates the quantity
+// If the variant is already in the cart, it updates the quantity
 func (c *Cart) AddItem(item *CartItem) {
 	// Check if item already exists in cart
 	for _, existingItem := range c.Items {
-		if existingItem.ProductID == item.ProductID {
+		if existingItem.VariantID == item.VariantID {
 			existingItem.Quantity += item.Quantity
 			c.UpdatedAt = time.Now()
 			return
@@ -58,10 +65,10 @@ func (c *Cart) AddItem(item *CartItem) {
 	c.UpdatedAt = time.Now()
 }
 
-// RemoveItem removes an item from the cart by product ID
-func (c *Cart) RemoveItem(productID string) error {
+// RemoveItem removes an item from the cart by variant ID
+func (c *Cart) RemoveItem(variantID string) error {
 	for i, item := range c.Items {
-		if item.ProductID == productID {
+		if item.VariantID == variantID {
 			c.Items = append(c.Items[:i], c.Items[i+1:]...)
 			c.UpdatedAt = time.Now()
 			return nil
@@ -70,10 +77,10 @@ func (c *Cart) RemoveItem(productID string) error {
 	return ErrCartItemNotFound
 }
 
-// UpdateItemQuantity updates the quantity of an item in the cart
-func (c *Cart) UpdateItemQuantity(productID string, quantity int) error {
+// UpdateItemQuantity updates the quantity of an item in the cart by variant ID
+func (c *Cart) UpdateItemQuantity(variantID string, quantity int) error {
 	for _, item := range c.Items {
-		if item.ProductID == productID {
+		if item.VariantID == variantID {
 			item.Quantity = quantity
 			c.UpdatedAt = time.Now()
 			return nil
@@ -82,6 +89,13 @@ func (c *Cart) UpdateItemQuantity(productID string, quantity int) error {
 	return ErrCartItemNotFound
 }
 
+// SetCurrency switches the cart to another currency
+// Item prices must be re-resolved by the caller afterwards
+func (c *Cart) SetCurrency(currency string) {
+	c.Currency = currency
+	c.UpdatedAt = time.Now()
+}
+
 // Clear removes all items from the cart
 func (c *Cart) Clear() {
 	c.Items = make([]*CartItem, 0)
@@ -89,12 +103,16 @@ func (c *Cart) Clear() {
 }
 
 // GetTotal calculates the total price of all items in the cart
-func (c *Cart) GetTotal() float64 {
-	total := 0.0
+func (c *Cart) GetTotal() (Money, error) {
+	total := Zero(c.Currency)
 	for _, item := range c.Items {
-		total += item.Price * float64(item.Quantity)
+		subtotal, err := item.Price.Mul(item.Quantity)
+		if err != nil {
+			return Money{}, err
+		}
+		total = total.Add(subtotal)
 	}
-	return total
+	return total, nil
 }
 
 // GetItemCount returns the total number of items in the cart
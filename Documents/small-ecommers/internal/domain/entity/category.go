@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"regexp"
+	"sort"
+	"time"
+)
+
+// slugPattern matches lower case words of letters and digits separated by single hyphens
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Category represents a node of the product category tree
+type Category struct {
+	ID string `json:"id"`
+	// ParentID is nil for top level categories
+	ParentID *string `json:"parent_id,omitempty"`
+	Name     string  `json:"name"`
+	Slug     string  `json:"slug"`
+	// Position orders the category among its siblings, lowest first
+	Position int `json:"position"`
+	// Attributes are the attributes of the products in the category and the categories below it
+	Attributes AttributeSchema `json:"attributes,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	// Children is only filled in when the tree is built
+	Children []*Category `json:"children,omitempty"`
+}
+
+// NewCategory creates a new Category entity
+func NewCategory(id, name, slug string, parentID *string, position int) *Category {
+	now := time.Now()
+	return &Category{
+		ID:        id,
+		ParentID:  parentID,
+		Name:      name,
+		Slug:      slug,
+		Position:  position,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsValidSlug checks if a slug is usable in URLs
+func IsValidSlug(slug string) bool {
+	return len(slug) <= 100 && slugPattern.MatchString(slug)
+}
+
+// BuildCategoryTree links categories to their parents and returns the top level ones
+// Siblings are ordered by position, then name. Categories whose parent is missing are
+// treated as top level so that none are lost.
+func BuildCategoryTree(categories []*Category) []*Category {
+	byID := make(map[string]*Category, len(categories))
+	for _, category := range categories {
+		category.Children = nil
+		byID[category.ID] = category
+	}
+
+	var roots []*Category
+	for _, category := range categories {
+		if category.ParentID != nil {
+			if parent, ok := byID[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, category)
+				continue
+			}
+		}
+		roots = append(roots, category)
+	}
+
+	sortCategories(roots)
+	return roots
+}
+
+// sortCategories orders siblings by position and name, recursively
+func sortCategories(categories []*Category) {
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].Position != categories[j].Position {
+			return categories[i].Position < categories[j].Position
+		}
+		return categories[i].Name < categories[j].Name
+	})
+	for _, category := range categories {
+		sortCategories(category.Children)
+	}
+}
@@ -8,12 +8,64 @@ var (
 	ErrUserAlreadyExists  = errors.New("user already exists")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 
-	ErrProductNotFound   = errors.New("product not found")
-	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrProductNotFound        = errors.New("product not found")
+	ErrProductArchived        = errors.New("product is no longer available")
+	ErrInvalidProductQuery    = errors.New("invalid product query")
+	ErrInsufficientStock      = errors.New("insufficient stock")
+	ErrInvalidStockPolicy     = errors.New("invalid stock policy")
+	ErrVariantNotFound        = errors.New("variant not found")
+	ErrInvalidVariant         = errors.New("invalid variant")
+	ErrVariantInUse           = errors.New("variant is in orders, carts or subscriptions, set its stock to 0 instead")
+	ErrSKUExists              = errors.New("SKU already exists")
+	ErrImageNotFound          = errors.New("image not found")
+	ErrInvalidImage           = errors.New("invalid image")
+	ErrImageTooLarge          = errors.New("image too large")
+	ErrBlobNotFound           = errors.New("blob not found")
+	ErrInvalidImport          = errors.New("invalid import file")
+	ErrScheduledPriceNotFound = errors.New("scheduled price not found")
+	ErrInvalidScheduledPrice  = errors.New("invalid scheduled price")
+	ErrScheduledPriceChanged  = errors.New("scheduled price or product price changed meanwhile")
+	ErrInvalidAttribute       = errors.New("invalid product attribute")
+
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryExists      = errors.New("category slug already exists")
+	ErrInvalidCategory     = errors.New("invalid category")
+	ErrCategoryHasChildren = errors.New("category has children")
 
 	ErrCartNotFound     = errors.New("cart not found")
 	ErrCartItemNotFound = errors.New("cart item not found")
 
-	ErrOrderNotFound = errors.New("order not found")
-	ErrInvalidOrder  = errors.New("invalid order")
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrInvalidOrder      = errors.New("invalid order")
+	ErrOrderBackordered  = errors.New("order has items waiting for stock")
+	ErrInvalidOrderQuery = errors.New("invalid order query")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+
+	ErrInvalidCurrency      = errors.New("invalid currency")
+	ErrInvalidExchangeRate  = errors.New("invalid exchange rate")
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrPriceNotAvailable    = errors.New("price not available in currency")
+
+	ErrInvoiceNotFound = errors.New("invoice not found")
+
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrInvalidSubscription  = errors.New("invalid subscription")
+
+	ErrPaymentDeclined = errors.New("payment declined")
+
+	ErrGiftCardNotFound    = errors.New("gift card not found")
+	ErrGiftCardExpired     = errors.New("gift card expired")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrInvalidRefund       = errors.New("invalid refund")
+
+	ErrInsufficientPoints = errors.New("insufficient loyalty points")
+
+	ErrInvalidAddress         = errors.New("invalid address")
+	ErrOrderRejected          = errors.New("order rejected by fraud screening")
+	ErrFraudScreeningNotFound = errors.New("fraud screening not found")
+
+	ErrVersionConflict = errors.New("modified by another request, reload and retry")
 )
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// OrderEventType identifies something that happened to an order
+type OrderEventType string
+
+const (
+	OrderEventCreated   OrderEventType = "order.created"
+	OrderEventPaid      OrderEventType = "order.paid"
+	OrderEventShipped   OrderEventType = "order.shipped"
+	OrderEventCancelled OrderEventType = "order.cancelled"
+	OrderEventRefunded  OrderEventType = "order.refunded"
+)
+
+// OrderEvent is a domain event raised when an order changes
+type OrderEvent struct {
+	Type OrderEventType
+	// Order is a copy of the order as it was when the event was raised
+	Order *Order
+	// Amount is the amount refunded, set on refund events only
+	Amount     *Money
+	OccurredAt time.Time
+}
+
+// NewOrderEvent creates a new OrderEvent holding a copy of the order
+func NewOrderEvent(eventType OrderEventType, order *Order, now time.Time) *OrderEvent {
+	snapshot := *order
+	return &OrderEvent{
+		Type:       eventType,
+		Order:      &snapshot,
+		OccurredAt: now,
+	}
+}
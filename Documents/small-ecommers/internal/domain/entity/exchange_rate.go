@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ExchangeRate represents the number of quote currency units bought by one base currency unit
+type ExchangeRate struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          string    `json:"rate"` // Exact decimal, e.g. "0.92"
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// NewExchangeRate creates a new ExchangeRate after validating the currencies and rate
+func NewExchangeRate(base, quote, rate string) (*ExchangeRate, error) {
+	base = strings.ToUpper(strings.TrimSpace(base))
+	quote = strings.ToUpper(strings.TrimSpace(quote))
+	if !IsCurrencyCode(base) || !IsCurrencyCode(quote) || base == quote {
+		return nil, ErrInvalidCurrency
+	}
+
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || r.Sign() <= 0 {
+		return nil, ErrInvalidExchangeRate
+	}
+
+	return &ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          strings.TrimSpace(rate),
+		UpdatedAt:     time.Now(),
+	}, nil
+}
+
+// Rat returns the rate as an exact rational number
+func (r *ExchangeRate) Rat() *big.Rat {
+	rat, ok := new(big.Rat).SetString(r.Rate)
+	if !ok {
+		return new(big.Rat)
+	}
+	return rat
+}
+
+// IsCurrencyCode reports whether code looks like an ISO 4217 alphabetic code
+func IsCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// ConvertMoney converts an amount into another currency at the given rate
+// The rate is the number of target units per source unit
+func ConvertMoney(m Money, currency string, rate *big.Rat, mode RoundingMode) Money {
+	currency = strings.ToUpper(currency)
+
+	// Scale for the difference in minor units between the two currencies
+	scale := new(big.Rat).Set(rate)
+	diff := CurrencyExponent(currency) - CurrencyExponent(m.Currency)
+	for ; diff > 0; diff-- {
+		scale.Mul(scale, big.NewRat(10, 1))
+	}
+	for ; diff < 0; diff++ {
+		scale.Quo(scale, big.NewRat(10, 1))
+	}
+
+	converted := m.MulRat(scale, mode)
+	converted.Currency = currency
+	return converted
+}
@@ -0,0 +1,78 @@
+package entity
+
+import "time"
+
+// FraudOutcome is the decision of a fraud screening
+type FraudOutcome string
+
+const (
+	FraudOutcomeApprove FraudOutcome = "approve"
+	FraudOutcomeReview  FraudOutcome = "review"
+	FraudOutcomeReject  FraudOutcome = "reject"
+)
+
+// FraudReviewDecision is the decision of an admin reviewing a held order
+type FraudReviewDecision string
+
+const (
+	FraudReviewApproved FraudReviewDecision = "approved"
+	FraudReviewRejected FraudReviewDecision = "rejected"
+)
+
+// FraudRuleHit records a fraud rule that matched an order
+type FraudRuleHit struct {
+	Rule   string `json:"rule"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// FraudScreening is the scored result of screening an order before payment
+type FraudScreening struct {
+	ID      string         `json:"id"`
+	OrderID string         `json:"order_id"`
+	Score   int            `json:"score"`
+	Outcome FraudOutcome   `json:"outcome"`
+	Hits    []FraudRuleHit `json:"hits"`
+	// ClientIP is the address the screened order was placed from
+	ClientIP *string `json:"client_ip,omitempty"`
+	// ReviewedBy, ReviewDecision and ReviewedAt are set once an admin has decided on a held order
+	ReviewedBy     *string              `json:"reviewed_by,omitempty"`
+	ReviewDecision *FraudReviewDecision `json:"review_decision,omitempty"`
+	ReviewNote     *string              `json:"review_note,omitempty"`
+	ReviewedAt     *time.Time           `json:"reviewed_at,omitempty"`
+	CreatedAt      time.Time            `json:"created_at"`
+}
+
+// NewFraudScreening creates a screening from the rule hits
+// The outcome is reject at or above rejectScore, review at or above reviewScore, approve otherwise
+func NewFraudScreening(id, orderID string, hits []FraudRuleHit, reviewScore, rejectScore int, now time.Time) *FraudScreening {
+	score := 0
+	for _, hit := range hits {
+		score += hit.Score
+	}
+
+	outcome := FraudOutcomeApprove
+	switch {
+	case rejectScore > 0 && score >= rejectScore:
+		outcome = FraudOutcomeReject
+	case reviewScore > 0 && score >= reviewScore:
+		outcome = FraudOutcomeReview
+	}
+
+	return &FraudScreening{
+		ID:        id,
+		OrderID:   orderID,
+		Score:     score,
+		Outcome:   outcome,
+		Hits:      hits,
+		CreatedAt: now,
+	}
+}
+
+// Review records the decision of the admin reviewing the screening
+func (s *FraudScreening) Review(reviewerID string, decision FraudReviewDecision, note *string, now time.Time) {
+	s.ReviewedBy = &reviewerID
+	s.ReviewDecision = &decision
+	s.ReviewNote = note
+	s.ReviewedAt = &now
+}
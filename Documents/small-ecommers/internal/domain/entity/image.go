@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+// MaxAltTextLength is the maximum length of the alt text of an image, in characters
+const MaxAltTextLength = 500
+
+// ProductImage is a picture of a product, stored as the uploaded original and a thumbnail
+type ProductImage struct {
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	// Position orders the images of a product, starting at zero with the main image
+	Position int `json:"position"`
+	// AltText describes the image for screen readers and when it cannot be shown
+	AltText     string `json:"alt_text"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	// Size is the size of the original in bytes
+	Size            int64  `json:"size"`
+	StorageKey      string `json:"-"`
+	ThumbnailKey    string `json:"-"`
+	URL             string `json:"url"`
+	ThumbnailURL    string `json:"thumbnail_url"`
+	ThumbnailWidth  int    `json:"thumbnail_width"`
+	ThumbnailHeight int    `json:"thumbnail_height"`
+	// Version is incremented by every update of the image, moves included, it guards against lost updates
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewProductImage creates a new ProductImage entity
+func NewProductImage(id, productID, altText, contentType string, width, height int, size int64) *ProductImage {
+	now := time.Now()
+	return &ProductImage{
+		ID:          id,
+		ProductID:   productID,
+		AltText:     altText,
+		ContentType: contentType,
+		Width:       width,
+		Height:      height,
+		Size:        size,
+		Version:     1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// IsValidAltText checks if alt text is short enough
+func IsValidAltText(altText string) bool {
+	return utf8.RuneCountInString(altText) <= MaxAltTextLength
+}
+
+// Image returns the image of the product with the given ID
+func (p *Product) Image(id string) (*ProductImage, error) {
+	for _, image := range p.Images {
+		if image.ID == id {
+			return image, nil
+		}
+	}
+	return nil, ErrImageNotFound
+}
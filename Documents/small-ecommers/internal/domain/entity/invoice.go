@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Invoice represents the invoice issued for a paid order
+// Numbers are sequential and gap-free within a calendar year
+type Invoice struct {
+	ID         string    `json:"id"`
+	OrderID    string    `json:"order_id"`
+	Number     string    `json:"number"`
+	Year       int       `json:"year"`
+	Sequence   int       `json:"sequence"`
+	StorageKey string    `json:"-"`
+	IssuedAt   time.Time `json:"issued_at"`
+}
+
+// NewInvoice creates a new Invoice entity that has not been numbered yet
+func NewInvoice(id, orderID string) *Invoice {
+	now := time.Now().UTC()
+	return &Invoice{
+		ID:       id,
+		OrderID:  orderID,
+		Year:     now.Year(),
+		IssuedAt: now,
+	}
+}
+
+// AssignNumber sets the position of the invoice in its year's sequence
+func (i *Invoice) AssignNumber(sequence int) {
+	i.Sequence = sequence
+	i.Number = fmt.Sprintf("INV-%d-%06d", i.Year, sequence)
+	i.StorageKey = fmt.Sprintf("invoices/%d/%s.pdf", i.Year, i.Number)
+}
+
+// FileName returns the name the invoice document is downloaded as
+func (i *Invoice) FileName() string {
+	return i.Number + ".pdf"
+}
@@ -0,0 +1,117 @@
+package entity
+
+import (
+	"crypto/rand"
+	"strings"
+	"time"
+)
+
+// LedgerAccountType identifies the kind of balance a ledger entry belongs to
+type LedgerAccountType string
+
+const (
+	LedgerAccountGiftCard    LedgerAccountType = "gift_card"
+	LedgerAccountStoreCredit LedgerAccountType = "store_credit"
+)
+
+// LedgerReason explains why a balance changed
+type LedgerReason string
+
+const (
+	LedgerReasonIssue      LedgerReason = "issue"
+	LedgerReasonRedeem     LedgerReason = "redeem"
+	LedgerReasonReversal   LedgerReason = "reversal"
+	LedgerReasonRefund     LedgerReason = "refund"
+	LedgerReasonAdjustment LedgerReason = "adjustment"
+)
+
+// LedgerEntry is an immutable credit (positive amount) or debit (negative amount) of a balance
+// For gift cards AccountID is the card ID, for store credit it is the user ID
+type LedgerEntry struct {
+	ID           string            `json:"id"`
+	AccountType  LedgerAccountType `json:"account_type"`
+	AccountID    string            `json:"account_id"`
+	Amount       Money             `json:"amount"`
+	BalanceAfter Money             `json:"balance_after"`
+	Reason       LedgerReason      `json:"reason"`
+	OrderID      *string           `json:"order_id,omitempty"`
+	Note         *string           `json:"note,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// NewLedgerEntry creates a new LedgerEntry, its balance is filled in when it is posted
+func NewLedgerEntry(id string, accountType LedgerAccountType, accountID string, amount Money, reason LedgerReason) *LedgerEntry {
+	return &LedgerEntry{
+		ID:          id,
+		AccountType: accountType,
+		AccountID:   accountID,
+		Amount:      amount,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// GiftCard represents a prepaid card redeemable at checkout
+type GiftCard struct {
+	ID             string     `json:"id"`
+	Code           string     `json:"code"`
+	InitialBalance Money      `json:"initial_balance"`
+	Balance        Money      `json:"balance"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// NewGiftCard creates a new GiftCard entity holding the given amount
+func NewGiftCard(id, code string, amount Money, expiresAt *time.Time) *GiftCard {
+	now := time.Now()
+	return &GiftCard{
+		ID:             id,
+		Code:           code,
+		InitialBalance: amount,
+		Balance:        amount,
+		ExpiresAt:      expiresAt,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// IsExpired checks if the gift card can no longer be redeemed
+func (g *GiftCard) IsExpired(now time.Time) bool {
+	return g.ExpiresAt != nil && !now.Before(*g.ExpiresAt)
+}
+
+// giftCardAlphabet leaves out characters that are easily confused, such as 0/O and 1/I
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GenerateGiftCardCode returns a random code formatted as XXXX-XXXX-XXXX-XXXX
+func GenerateGiftCardCode() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	var code strings.Builder
+	for i, b := range random {
+		if i > 0 && i%4 == 0 {
+			code.WriteByte('-')
+		}
+		code.WriteByte(giftCardAlphabet[int(b)%len(giftCardAlphabet)])
+	}
+	return code.String(), nil
+}
+
+// NormalizeGiftCardCode formats a code typed by a customer like a generated code
+func NormalizeGiftCardCode(code string) string {
+	var normalized strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if r == '-' || r == ' ' {
+			continue
+		}
+		if normalized.Len() > 0 && (normalized.Len()+1)%5 == 0 {
+			normalized.WriteByte('-')
+		}
+		normalized.WriteRune(r)
+	}
+	return normalized.String()
+}
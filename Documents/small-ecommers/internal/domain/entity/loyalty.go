@@ -0,0 +1,168 @@
+package entity
+
+import (
+	"sort"
+	"time"
+)
+
+// LoyaltyReason explains why a points balance changed
+type LoyaltyReason string
+
+const (
+	LoyaltyReasonEarn     LoyaltyReason = "earn"
+	LoyaltyReasonRedeem   LoyaltyReason = "redeem"
+	LoyaltyReasonReversal LoyaltyReason = "reversal"
+	LoyaltyReasonExpire   LoyaltyReason = "expire"
+)
+
+// LoyaltyEntry is an immutable change to a user's points, positive when points are added
+type LoyaltyEntry struct {
+	ID      string        `json:"id"`
+	UserID  string        `json:"user_id"`
+	Points  int64         `json:"points"`
+	Reason  LoyaltyReason `json:"reason"`
+	OrderID *string       `json:"order_id,omitempty"`
+	// ExpiresAt is when points added by the entry lapse, nil if they never do
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewLoyaltyEntry creates a new LoyaltyEntry
+func NewLoyaltyEntry(id, userID string, points int64, reason LoyaltyReason, now time.Time) *LoyaltyEntry {
+	return &LoyaltyEntry{
+		ID:        id,
+		UserID:    userID,
+		Points:    points,
+		Reason:    reason,
+		CreatedAt: now,
+	}
+}
+
+// LoyaltyBalance summarises the points a user can spend
+type LoyaltyBalance struct {
+	Points int64 `json:"points"`
+	// NextExpiry is when the next points lapse, NextExpiryPoints how many
+	NextExpiry       *time.Time `json:"next_expiry,omitempty"`
+	NextExpiryPoints int64      `json:"next_expiry_points,omitempty"`
+}
+
+// pointsLot is a batch of points added together and still unspent
+type pointsLot struct {
+	remaining int64
+	expiresAt *time.Time
+}
+
+// SummarizeLoyalty replays a user's points history, oldest first, and returns the balance at now
+// Spent points are taken from the lots expiring soonest. Points that lapsed by now but have
+// no expire entry yet are excluded from the balance and returned as lapsed.
+func SummarizeLoyalty(history []*LoyaltyEntry, now time.Time) (balance LoyaltyBalance, lapsed int64) {
+	var lots []*pointsLot
+	var debt int64
+
+	for _, entry := range history {
+		if entry.Points > 0 {
+			points := entry.Points
+
+			// Points owed after a reversal are settled by the next points added
+			settled := min(points, debt)
+			points -= settled
+			debt -= settled
+
+			if points > 0 {
+				lots = append(lots, &pointsLot{remaining: points, expiresAt: entry.ExpiresAt})
+			}
+			continue
+		}
+
+		debt += consumeLots(lots, -entry.Points)
+	}
+
+	for _, lot := range lots {
+		if lot.remaining == 0 {
+			continue
+		}
+
+		if lot.expiresAt != nil && !now.Before(*lot.expiresAt) {
+			lapsed += lot.remaining
+			continue
+		}
+
+		balance.Points += lot.remaining
+
+		if lot.expiresAt == nil {
+			continue
+		}
+
+		switch {
+		case balance.NextExpiry == nil || lot.expiresAt.Before(*balance.NextExpiry):
+			expiresAt := *lot.expiresAt
+			balance.NextExpiry = &expiresAt
+			balance.NextExpiryPoints = lot.remaining
+		case lot.expiresAt.Equal(*balance.NextExpiry):
+			balance.NextExpiryPoints += lot.remaining
+		}
+	}
+
+	balance.Points -= debt
+
+	return balance, lapsed
+}
+
+// consumeLots takes points from the lots expiring soonest and returns what they could not cover
+func consumeLots(lots []*pointsLot, points int64) int64 {
+	sorted := make([]*pointsLot, len(lots))
+	copy(sorted, lots)
+
+	// Lots that never expire are spent last
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].expiresAt, sorted[j].expiresAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.Before(*b)
+	})
+
+	for _, lot := range sorted {
+		if points == 0 {
+			break
+		}
+		taken := min(lot.remaining, points)
+		lot.remaining -= taken
+		points -= taken
+	}
+
+	return points
+}
+
+// OrderPoints totals the points an order earned and spent, net of earlier reversals
+type OrderPoints struct {
+	Earned   int64
+	Redeemed int64
+	// ReversedEarned are earned points already taken back, RestoredRedeemed spent points already given back
+	ReversedEarned   int64
+	RestoredRedeemed int64
+}
+
+// SummarizeOrderPoints totals the entries of a user's history that belong to an order
+func SummarizeOrderPoints(history []*LoyaltyEntry, orderID string) OrderPoints {
+	var points OrderPoints
+
+	for _, entry := range history {
+		if entry.OrderID == nil || *entry.OrderID != orderID {
+			continue
+		}
+
+		switch {
+		case entry.Reason == LoyaltyReasonEarn:
+			points.Earned += entry.Points
+		case entry.Reason == LoyaltyReasonRedeem:
+			points.Redeemed -= entry.Points
+		case entry.Reason == LoyaltyReasonReversal && entry.Points < 0:
+			points.ReversedEarned -= entry.Points
+		case entry.Reason == LoyaltyReasonReversal:
+			points.RestoredRedeemed += entry.Points
+		}
+	}
+
+	return points
+}
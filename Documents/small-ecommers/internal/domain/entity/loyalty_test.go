@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+var loyaltyStart = time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+// loyaltyEntry is an entry of user-1 made days after loyaltyStart, expiring validDays after it when validDays > 0
+func loyaltyEntry(points int64, reason LoyaltyReason, days, validDays int) *LoyaltyEntry {
+	createdAt := loyaltyStart.AddDate(0, 0, days)
+	entry := NewLoyaltyEntry("entry", "user-1", points, reason, createdAt)
+	if validDays > 0 {
+		expiresAt := createdAt.AddDate(0, 0, validDays)
+		entry.ExpiresAt = &expiresAt
+	}
+	return entry
+}
+
+func TestSummarizeLoyaltySpendsSoonestExpiringFirst(t *testing.T) {
+	history := []*LoyaltyEntry{
+		loyaltyEntry(100, LoyaltyReasonEarn, 0, 0),
+		loyaltyEntry(50, LoyaltyReasonEarn, 1, 30),
+		loyaltyEntry(40, LoyaltyReasonEarn, 2, 10),
+		loyaltyEntry(-60, LoyaltyReasonRedeem, 3, 0),
+	}
+
+	balance, lapsed := SummarizeLoyalty(history, loyaltyStart.AddDate(0, 0, 5))
+	if balance.Points != 130 || lapsed != 0 {
+		t.Fatalf("got %d points and %d lapsed, want 130 and 0", balance.Points, lapsed)
+	}
+
+	// The lot expiring on day 12 is spent, 20 points of the day 31 lot remain and expire next
+	want := loyaltyStart.AddDate(0, 0, 31)
+	if balance.NextExpiry == nil || !balance.NextExpiry.Equal(want) || balance.NextExpiryPoints != 30 {
+		t.Errorf("next expiry %v with %d points, want %v with 30", balance.NextExpiry, balance.NextExpiryPoints, want)
+	}
+}
+
+func TestSummarizeLoyaltyReportsLapsedPoints(t *testing.T) {
+	history := []*LoyaltyEntry{
+		loyaltyEntry(100, LoyaltyReasonEarn, 0, 30),
+		loyaltyEntry(-30, LoyaltyReasonRedeem, 1, 0),
+		loyaltyEntry(50, LoyaltyReasonEarn, 10, 30),
+	}
+
+	balance, lapsed := SummarizeLoyalty(history, loyaltyStart.AddDate(0, 0, 30))
+	if balance.Points != 50 || lapsed != 70 {
+		t.Fatalf("got %d points and %d lapsed, want 50 and 70", balance.Points, lapsed)
+	}
+
+	// Once the expire entry is recorded nothing is lapsed any more
+	history = append(history, loyaltyEntry(-70, LoyaltyReasonExpire, 30, 0))
+
+	balance, lapsed = SummarizeLoyalty(history, loyaltyStart.AddDate(0, 0, 30))
+	if balance.Points != 50 || lapsed != 0 {
+		t.Errorf("after expiry got %d points and %d lapsed, want 50 and 0", balance.Points, lapsed)
+	}
+}
+
+func TestSummarizeLoyaltySettlesDebtWithNextPoints(t *testing.T) {
+	history := []*LoyaltyEntry{
+		loyaltyEntry(50, LoyaltyReasonEarn, 0, 30),
+		loyaltyEntry(-50, LoyaltyReasonRedeem, 1, 0),
+		// Reversing an order whose points were already spent leaves the user owing them
+		loyaltyEntry(-40, LoyaltyReasonReversal, 2, 0),
+	}
+
+	balance, _ := SummarizeLoyalty(history, loyaltyStart.AddDate(0, 0, 3))
+	if balance.Points != -40 {
+		t.Fatalf("got %d points, want -40", balance.Points)
+	}
+
+	history = append(history, loyaltyEntry(100, LoyaltyReasonEarn, 4, 30))
+
+	balance, lapsed := SummarizeLoyalty(history, loyaltyStart.AddDate(0, 0, 5))
+	if balance.Points != 60 || balance.NextExpiryPoints != 60 {
+		t.Errorf("got %d points, %d expiring next, want 60 and 60", balance.Points, balance.NextExpiryPoints)
+	}
+
+	// The settled points do not lapse with their lot
+	if _, lapsed = SummarizeLoyalty(history, loyaltyStart.AddDate(0, 0, 40)); lapsed != 60 {
+		t.Errorf("got %d lapsed, want 60", lapsed)
+	}
+}
+
+func TestSummarizeOrderPoints(t *testing.T) {
+	orderID, otherID := "order-1", "order-2"
+	entries := []*LoyaltyEntry{
+		loyaltyEntry(-30, LoyaltyReasonRedeem, 0, 0),
+		loyaltyEntry(100, LoyaltyReasonEarn, 0, 30),
+		loyaltyEntry(-25, LoyaltyReasonReversal, 1, 0),
+		loyaltyEntry(30, LoyaltyReasonReversal, 2, 30),
+		loyaltyEntry(500, LoyaltyReasonEarn, 2, 30),
+		loyaltyEntry(-10, LoyaltyReasonExpire, 3, 0),
+	}
+	for i, entry := range entries {
+		if i == 4 {
+			entry.OrderID = &otherID
+		} else if entry.Reason != LoyaltyReasonExpire {
+			entry.OrderID = &orderID
+		}
+	}
+
+	got := SummarizeOrderPoints(entries, orderID)
+	want := OrderPoints{Earned: 100, Redeemed: 30, ReversedEarned: 25, RestoredRedeemed: 30}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
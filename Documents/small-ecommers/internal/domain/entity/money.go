@@ -0,0 +1,425 @@
+package entity
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money represents an exact monetary amount in the minor units of an ISO 4217 currency
+// Arithmetic between amounts of different currencies is a programming error and panics,
+// callers are expected to check SameCurrency where the inputs are not already known to match
+type Money struct {
+	Amount   int64  // Amount in minor units, e.g. cents for USD
+	Currency string // ISO 4217 currency code
+}
+
+// RoundingMode controls how fractional minor units are rounded
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds half away from zero, the usual commercial rounding
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds half to the nearest even minor unit (banker's rounding)
+	RoundHalfEven
+	// RoundDown truncates towards zero
+	RoundDown
+)
+
+// zeroDecimalCurrencies lists ISO 4217 currencies that have no minor unit
+var zeroDecimalCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true,
+	"JPY": true, "KMF": true, "KRW": true, "PYG": true, "RWF": true,
+	"UGX": true, "VND": true, "VUV": true, "XAF": true, "XOF": true,
+	"XPF": true,
+}
+
+// CurrencyExponent returns the number of decimal places of a currency's minor unit
+func CurrencyExponent(currency string) int {
+	if zeroDecimalCurrencies[strings.ToUpper(currency)] {
+		return 0
+	}
+	return 2
+}
+
+// NewMoney creates a Money from an amount in minor units
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Zero returns a zero amount in the given currency
+func Zero(currency string) Money {
+	return NewMoney(0, currency)
+}
+
+// ParseMoney parses a decimal string such as "12.34" into a Money
+// Digits beyond the currency exponent are only accepted when they are zero,
+// so "12.340" parses but "12.345" is rejected rather than silently rounded
+func ParseMoney(value, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Money{}, ErrInvalidAmount
+	}
+
+	negative := false
+	switch value[0] {
+	case '-':
+		negative = true
+		value = value[1:]
+	case '+':
+		value = value[1:]
+	}
+
+	whole, frac, _ := strings.Cut(value, ".")
+	if whole == "" && frac == "" {
+		return Money{}, ErrInvalidAmount
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	exp := CurrencyExponent(currency)
+	if len(frac) > exp {
+		if strings.Trim(frac[exp:], "0") != "" {
+			return Money{}, ErrInvalidAmount
+		}
+		frac = frac[:exp]
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	digits := strings.TrimLeft(whole+frac, "0")
+	if digits == "" {
+		return Zero(currency), nil
+	}
+
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return NewMoney(amount, currency), nil
+}
+
+// isDigits reports whether s contains only ASCII digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal returns the amount as a decimal string such as "12.34"
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+
+	digits := strconv.FormatUint(absInt64(amount), 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// absInt64 returns the absolute value of n as an unsigned integer
+func absInt64(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+// String returns the amount with its currency, e.g. "12.34 USD"
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// IsNegative reports whether the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// SameCurrency reports whether two amounts can be combined
+// An empty currency is treated as compatible with any currency
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == "" || other.Currency == "" || m.Currency == other.Currency
+}
+
+// mustMatch panics when the two amounts are in different currencies
+func (m Money) mustMatch(other Money) string {
+	if !m.SameCurrency(other) {
+		panic(fmt.Sprintf("money: currency mismatch %s and %s", m.Currency, other.Currency))
+	}
+	if m.Currency != "" {
+		return m.Currency
+	}
+	return other.Currency
+}
+
+// Add returns the sum of two amounts
+func (m Money) Add(other Money) Money {
+	return NewMoney(m.Amount+other.Amount, m.mustMatch(other))
+}
+
+// Sub returns the difference of two amounts
+func (m Money) Sub(other Money) Money {
+	return NewMoney(m.Amount-other.Amount, m.mustMatch(other))
+}
+
+// Cmp compares two amounts and returns -1, 0 or +1
+func (m Money) Cmp(other Money) int {
+	m.mustMatch(other)
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Neg returns the amount with its sign flipped
+func (m Money) Neg() Money {
+	return NewMoney(-m.Amount, m.Currency)
+}
+
+// Min returns the smaller of two amounts
+func (m Money) Min(other Money) Money {
+	if m.Cmp(other) <= 0 {
+		return m
+	}
+	return other
+}
+
+// Mul multiplies the amount by an integer quantity
+// ErrInvalidAmount is returned when the result does not fit in an amount
+func (m Money) Mul(quantity int) (Money, error) {
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(int64(quantity)))
+	if !product.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s times %d is out of range", ErrInvalidAmount, m, quantity)
+	}
+	return NewMoney(product.Int64(), m.Currency), nil
+}
+
+// MulRat multiplies the amount by an exact rational factor and rounds to minor units
+func (m Money) MulRat(factor *big.Rat, mode RoundingMode) Money {
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), factor)
+	return NewMoney(roundRat(product, mode), m.Currency)
+}
+
+// Percent returns the given percentage of the amount, e.g. Percent(7.5) of 10.00 is 0.75
+func (m Money) Percent(percent *big.Rat, mode RoundingMode) Money {
+	return m.MulRat(new(big.Rat).Quo(percent, big.NewRat(100, 1)), mode)
+}
+
+// roundRat rounds a rational number to an integer using the rounding mode
+func roundRat(r *big.Rat, mode RoundingMode) int64 {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+
+	negative := num.Sign() < 0
+	num.Abs(num)
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 {
+		twice := new(big.Int).Mul(rem, big.NewInt(2))
+		half := twice.Cmp(den)
+
+		switch mode {
+		case RoundHalfUp:
+			if half >= 0 {
+				quo.Add(quo, big.NewInt(1))
+			}
+		case RoundHalfEven:
+			if half > 0 || (half == 0 && quo.Bit(0) == 1) {
+				quo.Add(quo, big.NewInt(1))
+			}
+		case RoundDown:
+		}
+	}
+
+	if negative {
+		quo.Neg(quo)
+	}
+	return quo.Int64()
+}
+
+// Allocate splits the amount across the given weights without losing minor units
+// Remainders are handed out one minor unit at a time starting with the first share
+func (m Money) Allocate(weights []int64) []Money {
+	shares := make([]Money, len(weights))
+
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		for i := range shares {
+			shares[i] = Zero(m.Currency)
+		}
+		return shares
+	}
+
+	remaining := m.Amount
+	for i, w := range weights {
+		share := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(w))
+		share.Quo(share, big.NewInt(total))
+		shares[i] = NewMoney(share.Int64(), m.Currency)
+		remaining -= share.Int64()
+	}
+
+	step := int64(1)
+	if remaining < 0 {
+		step = -1
+	}
+	for i := 0; remaining != 0 && len(shares) > 0; i = (i + 1) % len(shares) {
+		if weights[i] == 0 {
+			continue
+		}
+		shares[i].Amount += step
+		remaining -= step
+	}
+
+	return shares
+}
+
+// WithDefaultCurrency assigns a currency to an amount that was decoded without one
+// The amount is re-parsed so that the exponent of the new currency is respected
+func (m Money) WithDefaultCurrency(currency string) (Money, error) {
+	if m.Currency != "" {
+		return m, nil
+	}
+	return ParseMoney(m.Decimal(), currency)
+}
+
+// moneyJSON is the wire representation of Money
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as {"amount": "12.34", "currency": "USD"}
+// The amount is a string so that clients never round-trip it through a float
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON decodes either the object form, a decimal string or a bare JSON number
+// The latter two leave the currency empty, see WithDefaultCurrency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	switch data[0] {
+	case '{':
+		var raw struct {
+			Amount   json.RawMessage `json:"amount"`
+			Currency string          `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		amount, err := decodeAmount(raw.Amount)
+		if err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(amount, raw.Currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	default:
+		amount, err := decodeAmount(data)
+		if err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(amount, "")
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+}
+
+// decodeAmount extracts the decimal text from a JSON string or number
+func decodeAmount(data json.RawMessage) (string, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return "", ErrInvalidAmount
+	}
+
+	// Expand exponents such as 1e2 without going through a float
+	s := n.String()
+	if strings.ContainsAny(s, "eE") {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return "", ErrInvalidAmount
+		}
+		return exactDecimal(r)
+	}
+	return s, nil
+}
+
+// maxAmountDecimals is the most decimals an exponent amount is expanded to
+const maxAmountDecimals = 18
+
+// exactDecimal writes a rational as a decimal string without rounding
+// ParseMoney then rejects the digits the currency has no minor unit for, so 1e-5 is an error rather than zero
+func exactDecimal(r *big.Rat) (string, error) {
+	scale := big.NewInt(1)
+	for decimals := 0; decimals <= maxAmountDecimals; decimals++ {
+		if new(big.Int).Rem(scale, r.Denom()).Sign() == 0 {
+			return r.FloatString(decimals), nil
+		}
+		scale.Mul(scale, big.NewInt(10))
+	}
+	return "", ErrInvalidAmount
+}
+
+// Value implements driver.Valuer so amounts are written to DECIMAL columns exactly
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
@@ -0,0 +1,262 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"testing/quick"
+)
+
+var quickConfig = &quick.Config{MaxCount: 2000}
+
+func TestAllocateSumsToAmount(t *testing.T) {
+	property := func(amount int32, weights []uint16) bool {
+		if len(weights) == 0 {
+			return true
+		}
+
+		raw := make([]int64, len(weights))
+		var total int64
+		for i, w := range weights {
+			raw[i] = int64(w)
+			total += raw[i]
+		}
+
+		money := NewMoney(int64(amount), "USD")
+		shares := money.Allocate(raw)
+		if len(shares) != len(weights) {
+			return false
+		}
+
+		var sum int64
+		for i, share := range shares {
+			if share.Currency != "USD" {
+				return false
+			}
+			if raw[i] == 0 && !share.IsZero() {
+				return false
+			}
+			sum += share.Amount
+		}
+
+		if total == 0 {
+			return sum == 0
+		}
+		return sum == money.Amount
+	}
+
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAllocateIsProportional(t *testing.T) {
+	// Each share is at most one minor unit away from its exact proportion
+	property := func(amount int32, weights []uint8) bool {
+		raw := make([]int64, len(weights))
+		var total int64
+		for i, w := range weights {
+			raw[i] = int64(w)
+			total += raw[i]
+		}
+		if total == 0 {
+			return true
+		}
+
+		for i, share := range NewMoney(int64(amount), "USD").Allocate(raw) {
+			exact := new(big.Rat).SetFrac64(int64(amount)*raw[i], total)
+			diff := new(big.Rat).Sub(new(big.Rat).SetInt64(share.Amount), exact)
+			if diff.Abs(diff).Cmp(big.NewRat(1, 1)) > 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseFormatRoundTrip(t *testing.T) {
+	for _, currency := range []string{"USD", "JPY"} {
+		property := func(amount int64) bool {
+			if amount == math.MinInt64 {
+				return true
+			}
+			money := NewMoney(amount, currency)
+			parsed, err := ParseMoney(money.Decimal(), currency)
+			return err == nil && parsed == money
+		}
+
+		if err := quick.Check(property, quickConfig); err != nil {
+			t.Errorf("%s: %v", currency, err)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	property := func(amount int64) bool {
+		if amount == math.MinInt64 {
+			return true
+		}
+		money := NewMoney(amount, "EUR")
+
+		data, err := json.Marshal(money)
+		if err != nil {
+			return false
+		}
+		var decoded Money
+		return json.Unmarshal(data, &decoded) == nil && decoded == money
+	}
+
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRoundingModes(t *testing.T) {
+	tests := []struct {
+		num, den                int64
+		halfUp, halfEven, round int64
+	}{
+		{5, 2, 3, 2, 2},
+		{-5, 2, -3, -2, -2},
+		{7, 2, 4, 4, 3},
+		{-7, 2, -4, -4, -3},
+		{7, 3, 2, 2, 2},
+		{8, 3, 3, 3, 2},
+		{-8, 3, -3, -3, -2},
+		{6, 3, 2, 2, 2},
+		{1, 1000, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		r := big.NewRat(tt.num, tt.den)
+		if got := roundRat(r, RoundHalfUp); got != tt.halfUp {
+			t.Errorf("RoundHalfUp(%d/%d) = %d, want %d", tt.num, tt.den, got, tt.halfUp)
+		}
+		if got := roundRat(r, RoundHalfEven); got != tt.halfEven {
+			t.Errorf("RoundHalfEven(%d/%d) = %d, want %d", tt.num, tt.den, got, tt.halfEven)
+		}
+		if got := roundRat(r, RoundDown); got != tt.round {
+			t.Errorf("RoundDown(%d/%d) = %d, want %d", tt.num, tt.den, got, tt.round)
+		}
+	}
+}
+
+func TestRoundingProperties(t *testing.T) {
+	property := func(num int32, den uint16) bool {
+		if den == 0 {
+			return true
+		}
+		r := big.NewRat(int64(num), int64(den))
+
+		// distance returns |r - q| compared with one half: -1 below, 0 at, +1 above
+		distance := func(q int64) int {
+			diff := new(big.Rat).Sub(r, new(big.Rat).SetInt64(q))
+			return diff.Abs(diff).Cmp(big.NewRat(1, 2))
+		}
+
+		halfUp := roundRat(r, RoundHalfUp)
+		halfEven := roundRat(r, RoundHalfEven)
+		down := roundRat(r, RoundDown)
+
+		// Rounding to nearest is never more than half a unit away
+		if distance(halfUp) > 0 || distance(halfEven) > 0 {
+			return false
+		}
+		// Ties go away from zero with RoundHalfUp and to the even neighbour with RoundHalfEven
+		if distance(halfUp) == 0 && new(big.Rat).SetInt64(halfUp).Cmp(r) != r.Sign() {
+			return false
+		}
+		if distance(halfEven) == 0 && halfEven%2 != 0 {
+			return false
+		}
+		// Truncation moves towards zero by less than a unit
+		truncated := new(big.Rat).SetInt64(down)
+		if r.Sign() >= 0 {
+			return truncated.Cmp(r) <= 0 && new(big.Rat).Sub(r, truncated).Cmp(big.NewRat(1, 1)) < 0
+		}
+		return truncated.Cmp(r) >= 0 && new(big.Rat).Sub(truncated, r).Cmp(big.NewRat(1, 1)) < 0
+	}
+
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseMoneyRejectsExtraPrecision(t *testing.T) {
+	if _, err := ParseMoney("12.345", "USD"); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("12.345 USD: got %v, want ErrInvalidAmount", err)
+	}
+	if _, err := ParseMoney("12.5", "JPY"); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("12.5 JPY: got %v, want ErrInvalidAmount", err)
+	}
+	if m, err := ParseMoney("12.340", "USD"); err != nil || m.Amount != 1234 {
+		t.Errorf("12.340 USD: got %v, %v", m, err)
+	}
+}
+
+func TestUnmarshalExponentAmounts(t *testing.T) {
+	tests := []struct {
+		input  string
+		amount int64
+		err    bool
+	}{
+		{`{"amount": 1e2, "currency": "USD"}`, 10000, false},
+		{`{"amount": 1.5e1, "currency": "USD"}`, 1500, false},
+		{`{"amount": 125e-2, "currency": "USD"}`, 125, false},
+		{`{"amount": 1e-5, "currency": "USD"}`, 0, true},
+		{`{"amount": 1e-1, "currency": "JPY"}`, 0, true},
+		{`{"amount": 1e-30, "currency": "USD"}`, 0, true},
+	}
+
+	for _, tt := range tests {
+		var m Money
+		err := json.Unmarshal([]byte(tt.input), &m)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: got %v, want an error", tt.input, m)
+			}
+			continue
+		}
+		if err != nil || m.Amount != tt.amount {
+			t.Errorf("%s: got %v, %v, want %d", tt.input, m, err, tt.amount)
+		}
+	}
+}
+
+func TestMulRejectsOverflow(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		quantity int
+		want     int64
+		err      bool
+	}{
+		{1250, 3, 3750, false},
+		{-1250, 3, -3750, false},
+		{math.MaxInt64, 1, math.MaxInt64, false},
+		{math.MinInt64, 1, math.MinInt64, false},
+		{math.MaxInt64 / 2, 2, math.MaxInt64 - 1, false},
+		{math.MaxInt64/2 + 1, 2, 0, true},
+		{math.MinInt64, -1, 0, true},
+		{1 << 40, 1 << 30, 0, true},
+		{-(1 << 40), 1 << 30, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := NewMoney(tt.amount, "USD").Mul(tt.quantity)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("%d * %d: got %v, %v, want ErrInvalidAmount", tt.amount, tt.quantity, got, err)
+			}
+			continue
+		}
+		if err != nil || got.Amount != tt.want || got.Currency != "USD" {
+			t.Errorf("%d * %d: got %v, %v, want %d", tt.amount, tt.quantity, got, err, tt.want)
+		}
+	}
+}
@@ -1,65 +1,244 @@
 package entity
 
-import "time"
+import (
+	"math/big"
+	"time"
+)
 
 // OrderStatus represents the status of an order
 type OrderStatus string
 
 const (
 	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusOnHold    OrderStatus = "on_hold"
 	OrderStatusPaid      OrderStatus = "paid"
 	OrderStatusShipped   OrderStatus = "shipped"
 	OrderStatusCompleted OrderStatus = "completed"
 	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
+)
+
+// IsValid checks if the order status is known
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusOnHold, OrderStatusPaid, OrderStatusShipped,
+		OrderStatusCompleted, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// PaymentMethod represents how part of an order was paid
+type PaymentMethod string
+
+const (
+	PaymentMethodGiftCard    PaymentMethod = "gift_card"
+	PaymentMethodStoreCredit PaymentMethod = "store_credit"
 )
 
 // Order represents an order entity in the domain
+// All amounts on an order, including its items, share the currency of Total
 type Order struct {
-	ID        string       `json:"id"`
-	UserID    string       `json:"user_id"`
-	Items     []*OrderItem `json:"items"`
-	Total     float64      `json:"total"`
-	Status    OrderStatus  `json:"status"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
+	ID        string         `json:"id"`
+	UserID    string         `json:"user_id"`
+	Items     []*OrderItem   `json:"items"`
+	Total     Money          `json:"total"`
+	Breakdown OrderBreakdown `json:"breakdown"`
+	// ExchangeRate is the rate locked at checkout when the order is not in the base currency
+	ExchangeRate *ExchangeRate `json:"exchange_rate,omitempty"`
+	// Payments are the gift card and store credit amounts applied at checkout
+	Payments []*OrderPayment `json:"payments,omitempty"`
+	// PaymentReference identifies the gateway charge for the amount left due
+	PaymentReference *string `json:"payment_reference,omitempty"`
+	// RefundedTotal is the amount refunded to store credit so far
+	RefundedTotal Money `json:"refunded_total"`
+	// PointsRedeemed are the loyalty points spent on Breakdown.PointsDiscount
+	PointsRedeemed  int64    `json:"points_redeemed,omitempty"`
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+	BillingAddress  *Address `json:"billing_address,omitempty"`
+	// ClientIP is the address the order was placed from, used for fraud screening
+	// It is not shown to customers, admins see it on the order's fraud screening
+	ClientIP *string     `json:"-"`
+	Status   OrderStatus `json:"status"`
+	// Version is incremented by every update of the order, it guards against lost updates
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// OrderBreakdown itemises how the order total was calculated
+type OrderBreakdown struct {
+	Subtotal          Money `json:"subtotal"`
+	LineDiscountTotal Money `json:"line_discount_total"`
+	OrderDiscount     Money `json:"order_discount"`
+	PointsDiscount    Money `json:"points_discount"`
+	DiscountTotal     Money `json:"discount_total"`
+	Tax               Money `json:"tax"`
+	Shipping          Money `json:"shipping"`
+	Total             Money `json:"total"`
+}
+
+// OrderPayment is an amount of an order paid from a balance
+type OrderPayment struct {
+	Method PaymentMethod `json:"method"`
+	// AccountID is the gift card ID or, for store credit, the user ID
+	AccountID string `json:"account_id"`
+	Amount    Money  `json:"amount"`
+}
+
+// OrderItemStatus represents the fulfilment state of an order item
+type OrderItemStatus string
+
+const (
+	OrderItemStatusAllocated   OrderItemStatus = "allocated"
+	OrderItemStatusBackordered OrderItemStatus = "backordered"
+)
+
 // OrderItem represents an item in an order
 type OrderItem struct {
-	ID        string  `json:"id"`
-	ProductID string  `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	ID        string          `json:"id"`
+	ProductID string          `json:"product_id"`
+	VariantID string          `json:"variant_id"`
+	Quantity  int             `json:"quantity"`
+	Price     Money           `json:"price"`
+	Discount  Money           `json:"discount"`
+	Status    OrderItemStatus `json:"status"`
+	// BackorderedQuantity is the part of Quantity still waiting for stock
+	BackorderedQuantity int        `json:"backordered_quantity"`
+	ExpectedAt          *time.Time `json:"expected_at,omitempty"`
 }
 
 // NewOrder creates a new Order entity
-func NewOrder(id, userID string, items []*OrderItem, total float64) *Order {
+func NewOrder(id, userID string, items []*OrderItem, breakdown OrderBreakdown) *Order {
 	now := time.Now()
 	return &Order{
-		ID:        id,
-		UserID:    userID,
-		Items:     items,
-		Total:     total,
-		Status:    OrderStatusPending,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:            id,
+		UserID:        userID,
+		Items:         items,
+		Total:         breakdown.Total,
+		Breakdown:     breakdown,
+		RefundedTotal: Zero(breakdown.Total.Currency),
+		Status:        OrderStatusPending,
+		Version:       1,
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}
 }
 
 // NewOrderItem creates a new OrderItem
-func NewOrderItem(id, productID string, quantity int, price float64) *OrderItem {
+func NewOrderItem(id, productID, variantID string, quantity int, price Money) *OrderItem {
 	return &OrderItem{
 		ID:        id,
 		ProductID: productID,
+		VariantID: variantID,
 		Quantity:  quantity,
 		Price:     price,
+		Discount:  Zero(price.Currency),
+		Status:    OrderItemStatusAllocated,
+	}
+}
+
+// Subtotal returns the line amount before discounts
+func (i *OrderItem) Subtotal() (Money, error) {
+	return i.Price.Mul(i.Quantity)
+}
+
+// Total returns the line amount after its discount
+func (i *OrderItem) Total() (Money, error) {
+	subtotal, err := i.Subtotal()
+	if err != nil {
+		return Money{}, err
+	}
+	return subtotal.Sub(i.Discount), nil
+}
+
+// Backorder records that part of the item is waiting for stock
+func (i *OrderItem) Backorder(quantity int, expectedAt *time.Time) {
+	i.BackorderedQuantity = quantity
+	i.ExpectedAt = expectedAt
+	i.Status = OrderItemStatusAllocated
+	if quantity > 0 {
+		i.Status = OrderItemStatusBackordered
+	}
+}
+
+// AllocatedQuantity returns the part of the item taken from stock
+func (i *OrderItem) AllocatedQuantity() int {
+	return i.Quantity - i.BackorderedQuantity
+}
+
+// BaseTotal returns the total in the base currency, converted back at the rate locked at checkout
+func (o *Order) BaseTotal() Money {
+	if o.ExchangeRate == nil {
+		return o.Total
+	}
+	return ConvertMoney(o.Total, o.ExchangeRate.BaseCurrency, new(big.Rat).Inv(o.ExchangeRate.Rat()), RoundHalfUp)
+}
+
+// AmountPaidFromBalances returns the total of the gift card and store credit payments
+func (o *Order) AmountPaidFromBalances() Money {
+	paid := Zero(o.Total.Currency)
+	for _, payment := range o.Payments {
+		paid = paid.Add(payment.Amount)
+	}
+	return paid
+}
+
+// AmountDue returns what is left to charge through the payment gateway
+func (o *Order) AmountDue() Money {
+	due := o.Total.Sub(o.AmountPaidFromBalances())
+	if due.IsNegative() {
+		return Zero(o.Total.Currency)
+	}
+	return due
+}
+
+// RefundableAmount returns how much of a paid order can still be refunded
+func (o *Order) RefundableAmount() Money {
+	if !o.IsInvoiceable() && o.Status != OrderStatusRefunded {
+		return Zero(o.Total.Currency)
 	}
+	return o.Total.Sub(o.RefundedTotal)
 }
 
-// UpdateStatus updates the order status
-func (o *Order) UpdateStatus(status OrderStatus) {
-	o.Status = status
+// Refund records a refund and marks the order refunded once it is refunded in full
+func (o *Order) Refund(amount Money) error {
+	if !amount.IsPositive() || amount.Cmp(o.RefundableAmount()) > 0 {
+		return ErrInvalidRefund
+	}
+	o.RefundedTotal = o.RefundedTotal.Add(amount)
+	if o.RefundedTotal.Cmp(o.Total) == 0 {
+		o.Status = OrderStatusRefunded
+	}
 	o.UpdatedAt = time.Now()
+	return nil
+}
+
+// HasBackorders checks if any item of the order is still waiting for stock
+func (o *Order) HasBackorders() bool {
+	for _, item := range o.Items {
+		if item.BackorderedQuantity > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateStatus moves the order to a status it can reach from its current one
+func (o *Order) UpdateStatus(status OrderStatus) error {
+	switch status {
+	case OrderStatusOnHold:
+		return o.Hold()
+	case OrderStatusPaid:
+		return o.MarkAsPaid()
+	case OrderStatusShipped:
+		return o.MarkAsShipped()
+	case OrderStatusCompleted:
+		return o.MarkAsCompleted()
+	case OrderStatusCancelled:
+		return o.Cancel()
+	}
+	return ErrInvalidOrder
 }
 
 // CanBePaid checks if the order can be paid
@@ -68,13 +247,24 @@ func (o *Order) CanBePaid() bool {
 }
 
 // CanBeShipped checks if the order can be shipped
+// Orders with backordered items wait until all their stock has arrived
 func (o *Order) CanBeShipped() bool {
-	return o.Status == OrderStatusPaid
+	return o.Status == OrderStatusPaid && !o.HasBackorders()
 }
 
 // CanBeCancelled checks if the order can be cancelled
+// Orders that were partly refunded are settled through refunds only
 func (o *Order) CanBeCancelled() bool {
-	return o.Status == OrderStatusPending || o.Status == OrderStatusPaid
+	switch o.Status {
+	case OrderStatusPending, OrderStatusOnHold, OrderStatusPaid:
+		return o.RefundedTotal.IsZero()
+	}
+	return false
+}
+
+// IsInvoiceable checks if the order has been paid and so needs an invoice
+func (o *Order) IsInvoiceable() bool {
+	return o.Status == OrderStatusPaid || o.Status == OrderStatusShipped || o.Status == OrderStatusCompleted
 }
 
 // Cancel cancels the order
@@ -87,6 +277,26 @@ func (o *Order) Cancel() error {
 	return nil
 }
 
+// Hold puts an order awaiting payment on hold for a fraud review
+func (o *Order) Hold() error {
+	if !o.CanBePaid() {
+		return ErrInvalidOrder
+	}
+	o.Status = OrderStatusOnHold
+	o.UpdatedAt = time.Now()
+	return nil
+}
+
+// ReleaseHold returns a held order to pending so its payment can complete
+func (o *Order) ReleaseHold() error {
+	if o.Status != OrderStatusOnHold {
+		return ErrInvalidOrder
+	}
+	o.Status = OrderStatusPending
+	o.UpdatedAt = time.Now()
+	return nil
+}
+
 // MarkAsPaid marks the order as paid
 func (o *Order) MarkAsPaid() error {
 	if !o.CanBePaid() {
@@ -98,8 +308,12 @@ func (o *Order) MarkAsPaid() error {
 }
 
 // MarkAsShipped marks the order as shipped
+// It returns ErrOrderBackordered for a paid order still waiting for stock
 func (o *Order) MarkAsShipped() error {
 	if !o.CanBeShipped() {
+		if o.Status == OrderStatusPaid {
+			return ErrOrderBackordered
+		}
 		return ErrInvalidOrder
 	}
 	o.Status = OrderStatusShipped
@@ -107,10 +321,14 @@ func (o *Order) MarkAsShipped() error {
 	return nil
 }
 
-// MarkAsCompleted marks the order as completed
-func (o *Order) MarkAsCompleted() {
+// MarkAsCompleted marks a shipped order as completed
+func (o *Order) MarkAsCompleted() error {
+	if o.Status != OrderStatusShipped {
+		return ErrInvalidOrder
+	}
 	o.Status = OrderStatusCompleted
 	o.UpdatedAt = time.Now()
+	return nil
 }
 
 // GetItemCount returns the total number of items in the order
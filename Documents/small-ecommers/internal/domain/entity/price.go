@@ -0,0 +1,133 @@
+package entity
+
+import "time"
+
+// PriceChangeSource is what changed the price of a product
+type PriceChangeSource string
+
+const (
+	PriceChangeSourceManual   PriceChangeSource = "manual"
+	PriceChangeSourceImport   PriceChangeSource = "import"
+	PriceChangeSourceSchedule PriceChangeSource = "schedule"
+)
+
+// PriceChange records a change of the base price of a product
+type PriceChange struct {
+	ID        string            `json:"id"`
+	ProductID string            `json:"product_id"`
+	OldPrice  Money             `json:"old_price"`
+	NewPrice  Money             `json:"new_price"`
+	Source    PriceChangeSource `json:"source"`
+	// ActorID is the user who changed the price, or who scheduled the change, empty for system changes
+	ActorID string `json:"actor_id,omitempty"`
+	// ScheduledPriceID is the scheduled price that made the change
+	ScheduledPriceID *string   `json:"scheduled_price_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// NewPriceChange creates a new PriceChange entity
+func NewPriceChange(id, productID string, oldPrice, newPrice Money, source PriceChangeSource, actorID string, at time.Time) *PriceChange {
+	return &PriceChange{
+		ID:        id,
+		ProductID: productID,
+		OldPrice:  oldPrice,
+		NewPrice:  newPrice,
+		Source:    source,
+		ActorID:   actorID,
+		CreatedAt: at,
+	}
+}
+
+// PriceChanged checks if two prices differ in amount or currency
+func PriceChanged(oldPrice, newPrice Money) bool {
+	return !oldPrice.SameCurrency(newPrice) || oldPrice.Cmp(newPrice) != 0
+}
+
+// ScheduledPriceStatus is where a scheduled price is in its lifecycle
+type ScheduledPriceStatus string
+
+const (
+	// ScheduledPriceStatusPending waits for its start
+	ScheduledPriceStatusPending ScheduledPriceStatus = "pending"
+	// ScheduledPriceStatusActive has started and waits for its end
+	ScheduledPriceStatusActive ScheduledPriceStatus = "active"
+	// ScheduledPriceStatusCompleted has been applied, and reverted if it had an end
+	ScheduledPriceStatusCompleted ScheduledPriceStatus = "completed"
+	// ScheduledPriceStatusCancelled was cancelled, or could no longer be applied
+	ScheduledPriceStatusCancelled ScheduledPriceStatus = "cancelled"
+)
+
+// ScheduledPrice is a future base price of a product
+// A scheduled price with an end, such as a sale, gives way to the price it replaced when it ends
+type ScheduledPrice struct {
+	ID        string               `json:"id"`
+	ProductID string               `json:"product_id"`
+	Price     Money                `json:"price"`
+	StartsAt  time.Time            `json:"starts_at"`
+	EndsAt    *time.Time           `json:"ends_at,omitempty"`
+	Status    ScheduledPriceStatus `json:"status"`
+	// PreviousPrice is the price replaced at the start, restored at the end
+	PreviousPrice *Money    `json:"previous_price,omitempty"`
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// NewScheduledPrice creates a new ScheduledPrice entity
+func NewScheduledPrice(id, productID string, price Money, startsAt time.Time, endsAt *time.Time, createdBy string) *ScheduledPrice {
+	now := time.Now()
+	return &ScheduledPrice{
+		ID:        id,
+		ProductID: productID,
+		Price:     price,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		Status:    ScheduledPriceStatusPending,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsOpen checks if the scheduled price still has to start or end
+func (s *ScheduledPrice) IsOpen() bool {
+	return s.Status == ScheduledPriceStatusPending || s.Status == ScheduledPriceStatusActive
+}
+
+// Overlaps checks if two scheduled prices are in effect at the same time
+// A scheduled price without an end only takes the moment it starts
+func (s *ScheduledPrice) Overlaps(other *ScheduledPrice) bool {
+	return !s.StartsAt.After(other.lastMoment()) && !other.StartsAt.After(s.lastMoment())
+}
+
+// lastMoment returns the end of a scheduled price, or its start when it has none
+func (s *ScheduledPrice) lastMoment() time.Time {
+	if s.EndsAt != nil {
+		return *s.EndsAt
+	}
+	return s.StartsAt
+}
+
+// Start puts the scheduled price in effect, replacing current
+// A scheduled price without an end is completed at once
+func (s *ScheduledPrice) Start(current Money, at time.Time) {
+	s.PreviousPrice = &current
+	s.Status = ScheduledPriceStatusActive
+	if s.EndsAt == nil {
+		s.Status = ScheduledPriceStatusCompleted
+	}
+	s.UpdatedAt = at
+}
+
+// Finish ends the scheduled price with a status, completed or cancelled
+// It returns the price to restore, nil when the current price is no longer the scheduled one
+func (s *ScheduledPrice) Finish(current Money, status ScheduledPriceStatus, at time.Time) *Money {
+	wasActive := s.Status == ScheduledPriceStatusActive
+	s.Status = status
+	s.UpdatedAt = at
+
+	if !wasActive || s.PreviousPrice == nil || PriceChanged(current, s.Price) {
+		return nil
+	}
+	return s.PreviousPrice
+}
@@ -1,52 +1,162 @@
 package entity
 
 import (
+	"math"
 	"time"
 )
 
+// StockPolicy decides whether a product can be ordered beyond its stock
+type StockPolicy string
+
+const (
+	StockPolicyDeny      StockPolicy = "deny"
+	StockPolicyBackorder StockPolicy = "backorder"
+	StockPolicyPreorder  StockPolicy = "preorder"
+)
+
+// IsValid checks if the stock policy is known
+func (s StockPolicy) IsValid() bool {
+	return s == StockPolicyDeny || s == StockPolicyBackorder || s == StockPolicyPreorder
+}
+
+// ProductStatus is where a product is in its lifecycle
+type ProductStatus string
+
+const (
+	ProductStatusActive   ProductStatus = "active"
+	ProductStatusArchived ProductStatus = "archived"
+)
+
+// IsValid checks if the product status is known
+func (s ProductStatus) IsValid() bool {
+	return s == ProductStatusActive || s == ProductStatusArchived
+}
+
 // Product represents a product entity in the domain
 type Product struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description *string   `json:"description,omitempty"` // Optional field, can be nil
-	Price       float64   `json:"price"`
-	Stock       int       `json:"stock"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"` // Optional field, can be nil
+	Price       Money   `json:"price"`
+	Prices      []Money `json:"prices,omitempty"` // Explicit prices in other currencies
+	// CategoryIDs are the categories the product is assigned to
+	CategoryIDs []string `json:"category_ids,omitempty"`
+	// Options are the dimensions the variants of the product differ in
+	Options ProductOptions `json:"options,omitempty"`
+	// Attributes are the values of the attributes defined by the product's categories
+	Attributes ProductAttributes `json:"attributes,omitempty"`
+	Variants   []*Variant        `json:"variants,omitempty"`
+	// Images are in display order, the first is the main image
+	Images []*ProductImage `json:"images,omitempty"`
+	// Stock is the total stock of the variants
+	Stock       int         `json:"stock"`
+	StockPolicy StockPolicy `json:"stock_policy"`
+	// BackorderLimit caps the units of each variant owed to backorders, nil means no limit
+	BackorderLimit *int `json:"backorder_limit,omitempty"`
+	// Backordered is the total of the variants' ordered units still waiting for stock
+	Backordered int `json:"backordered"`
+	// AvailableAt is when backordered or pre-ordered units are expected
+	AvailableAt *time.Time `json:"available_at,omitempty"`
+	// Status is archived for products taken out of the catalog, they remain for order history
+	Status     ProductStatus `json:"status"`
+	ArchivedAt *time.Time    `json:"archived_at,omitempty"`
+	// Version is incremented by every update of the product, it guards against lost updates
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
-// NewProduct creates a new Product entity
-func NewProduct(id, name string, description *string, price float64, stock int) *Product {
+// NewProduct creates a new Product entity with its default variant
+// The default variant shares the product ID and takes the initial stock
+func NewProduct(id, name string, description *string, price Money, stock int, sku string) *Product {
 	now := time.Now()
+	variant := NewVariant(id, id, sku, nil, nil, stock)
+	variant.IsDefault = true
 	return &Product{
 		ID:          id,
 		Name:        name,
 		Description: description,
 		Price:       price,
+		Variants:    []*Variant{variant},
 		Stock:       stock,
+		StockPolicy: StockPolicyDeny,
+		Status:      ProductStatusActive,
+		Version:     1,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
 }
 
-// HasStock checks if product has enough stock
-func (p *Product) HasStock(quantity int) bool {
-	return p.Stock >= quantity
+// PriceIn returns the explicit price of the product in a currency, if one is set
+func (p *Product) PriceIn(currency string) (Money, bool) {
+	if p.Price.Currency == currency {
+		return p.Price, true
+	}
+	for _, price := range p.Prices {
+		if price.Currency == currency {
+			return price, true
+		}
+	}
+	return Money{}, false
+}
+
+// IsArchived checks if the product has been taken out of the catalog
+func (p *Product) IsArchived() bool {
+	return p.Status == ProductStatusArchived
 }
 
-// ReduceStock reduces the product stock by the given quantity
-// Returns error if not enough stock
-func (p *Product) ReduceStock(quantity int) error {
-	if !p.HasStock(quantity) {
-		return ErrInsufficientStock
+// Archive takes the product out of the catalog, it can no longer be added to carts or ordered
+func (p *Product) Archive(at time.Time) {
+	if p.IsArchived() {
+		return
 	}
-	p.Stock -= quantity
-	p.UpdatedAt = time.Now()
-	return nil
+	p.Status = ProductStatusArchived
+	p.ArchivedAt = &at
+	p.UpdatedAt = at
 }
 
-// AddStock increases the product stock by the given quantity
-func (p *Product) AddStock(quantity int) {
-	p.Stock += quantity
+// Restore puts an archived product back in the catalog
+func (p *Product) Restore() {
+	p.Status = ProductStatusActive
+	p.ArchivedAt = nil
 	p.UpdatedAt = time.Now()
 }
+
+// AllowsBackorders checks if the product can be ordered beyond its stock
+func (p *Product) AllowsBackorders() bool {
+	return p.StockPolicy == StockPolicyBackorder || p.StockPolicy == StockPolicyPreorder
+}
+
+// MaxOrderable returns the largest quantity of a variant that can currently be ordered
+func (p *Product) MaxOrderable(variant *Variant) int {
+	stock := max(variant.Stock, 0)
+	if !p.AllowsBackorders() {
+		return stock
+	}
+	if p.BackorderLimit == nil {
+		return math.MaxInt
+	}
+	return stock + max(*p.BackorderLimit-variant.Backordered, 0)
+}
+
+// CanFulfill checks if the quantity of a variant can be ordered, from stock or as a backorder
+func (p *Product) CanFulfill(variant *Variant, quantity int) bool {
+	return quantity <= p.MaxOrderable(variant)
+}
+
+// Allocate takes the quantity from the variant stock and backorders whatever stock cannot cover
+// Returns the number of units that were backordered
+func (p *Product) Allocate(variant *Variant, quantity int) (int, error) {
+	if !p.CanFulfill(variant, quantity) {
+		return 0, ErrInsufficientStock
+	}
+
+	allocated := min(max(variant.Stock, 0), quantity)
+	backordered := quantity - allocated
+
+	variant.Stock -= allocated
+	variant.Backordered += backordered
+	variant.UpdatedAt = time.Now()
+	p.SumVariantStock()
+	return backordered, nil
+}
@@ -0,0 +1,137 @@
+package entity
+
+import "time"
+
+// SubscriptionStatus represents the status of a subscription
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive    SubscriptionStatus = "active"
+	SubscriptionStatusPaused    SubscriptionStatus = "paused"
+	SubscriptionStatusCancelled SubscriptionStatus = "cancelled"
+)
+
+// MaxSubscriptionFailures is the number of failed runs in a row after which a subscription is paused
+const MaxSubscriptionFailures = 3
+
+// Subscription represents a recurring order delivered every IntervalWeeks weeks
+type Subscription struct {
+	ID            string              `json:"id"`
+	UserID        string              `json:"user_id"`
+	Items         []*SubscriptionItem `json:"items"`
+	Currency      string              `json:"currency"`
+	IntervalWeeks int                 `json:"interval_weeks"`
+	Status        SubscriptionStatus  `json:"status"`
+	NextRunAt     time.Time           `json:"next_run_at"`
+	LastRunAt     *time.Time          `json:"last_run_at,omitempty"`
+	LastOrderID   *string             `json:"last_order_id,omitempty"`
+	// FailureCount is the number of consecutive runs that did not produce a paid order
+	FailureCount int       `json:"failure_count"`
+	LastError    *string   `json:"last_error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SubscriptionItem represents a product variant delivered with every run of a subscription
+type SubscriptionItem struct {
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// NewSubscription creates a new active Subscription entity whose first run is at startAt
+func NewSubscription(id, userID, currency string, intervalWeeks int, items []*SubscriptionItem, startAt, now time.Time) *Subscription {
+	return &Subscription{
+		ID:            id,
+		UserID:        userID,
+		Items:         items,
+		Currency:      currency,
+		IntervalWeeks: intervalWeeks,
+		Status:        SubscriptionStatusActive,
+		NextRunAt:     startAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// Interval returns the time between two runs
+func (s *Subscription) Interval() time.Duration {
+	return time.Duration(s.IntervalWeeks) * 7 * 24 * time.Hour
+}
+
+// IsDue checks if the subscription should run at the given time
+func (s *Subscription) IsDue(now time.Time) bool {
+	return s.Status == SubscriptionStatusActive && !s.NextRunAt.After(now)
+}
+
+// Advance moves the next run past now, skipping runs that were missed
+func (s *Subscription) Advance(now time.Time) {
+	for !s.NextRunAt.After(now) {
+		s.NextRunAt = s.NextRunAt.Add(s.Interval())
+	}
+	s.UpdatedAt = now
+}
+
+// RecordSuccess records a run that produced a paid order
+func (s *Subscription) RecordSuccess(orderID string, now time.Time) {
+	s.LastRunAt = &now
+	s.LastOrderID = &orderID
+	s.FailureCount = 0
+	s.LastError = nil
+	s.UpdatedAt = now
+}
+
+// RecordPending records a run whose order is held for review instead of paid
+// The failures in a row are kept as they are until a run is paid or fails
+func (s *Subscription) RecordPending(orderID string, now time.Time) {
+	s.LastRunAt = &now
+	s.LastOrderID = &orderID
+	s.UpdatedAt = now
+}
+
+// RecordFailure records a failed run and pauses the subscription after too many in a row
+func (s *Subscription) RecordFailure(reason string, now time.Time) {
+	s.LastRunAt = &now
+	s.FailureCount++
+	s.LastError = &reason
+	if s.FailureCount >= MaxSubscriptionFailures {
+		s.Status = SubscriptionStatusPaused
+	}
+	s.UpdatedAt = now
+}
+
+// Pause pauses an active subscription
+func (s *Subscription) Pause(now time.Time) error {
+	if s.Status != SubscriptionStatusActive {
+		return ErrInvalidSubscription
+	}
+	s.Status = SubscriptionStatusPaused
+	s.UpdatedAt = now
+	return nil
+}
+
+// Resume resumes a paused subscription
+// Runs missed while paused are skipped, a run that is already overdue happens right away
+func (s *Subscription) Resume(now time.Time) error {
+	if s.Status != SubscriptionStatusPaused {
+		return ErrInvalidSubscription
+	}
+	s.Status = SubscriptionStatusActive
+	s.FailureCount = 0
+	if s.NextRunAt.Before(now) {
+		s.NextRunAt = now
+	}
+	s.UpdatedAt = now
+	return nil
+}
+
+// Cancel cancels the subscription for good
+func (s *Subscription) Cancel(now time.Time) error {
+	if s.Status == SubscriptionStatusCancelled {
+		return ErrInvalidSubscription
+	}
+	s.Status = SubscriptionStatusCancelled
+	s.UpdatedAt = now
+	return nil
+}
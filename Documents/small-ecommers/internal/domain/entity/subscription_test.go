@@ -0,0 +1,139 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func TestSubscriptionAdvanceAcrossMonthEnds(t *testing.T) {
+	tests := []struct {
+		name          string
+		intervalWeeks int
+		nextRunAt     time.Time
+		want          time.Time
+	}{
+		{"January into February", 4, date(2026, time.January, 31), date(2026, time.February, 28)},
+		{"January into a leap February", 4, date(2028, time.January, 31), date(2028, time.February, 28)},
+		{"leap day", 1, date(2028, time.February, 22), date(2028, time.February, 29)},
+		{"month end into the next month", 1, date(2026, time.April, 30), date(2026, time.May, 7)},
+		{"year end", 2, date(2026, time.December, 31), date(2027, time.January, 14)},
+	}
+
+	for _, tt := range tests {
+		subscription := NewSubscription("s", "u", "USD", tt.intervalWeeks, nil, tt.nextRunAt, tt.nextRunAt)
+		subscription.Advance(tt.nextRunAt)
+		if !subscription.NextRunAt.Equal(tt.want) {
+			t.Errorf("%s: next run %v, want %v", tt.name, subscription.NextRunAt, tt.want)
+		}
+	}
+}
+
+func TestSubscriptionAdvanceSkipsMissedRuns(t *testing.T) {
+	start := date(2026, time.January, 1)
+	subscription := NewSubscription("s", "u", "USD", 1, nil, start, start)
+
+	// The scheduler was down for three and a half weeks
+	now := start.Add(3*7*24*time.Hour + 12*time.Hour)
+	subscription.Advance(now)
+
+	if want := start.Add(4 * 7 * 24 * time.Hour); !subscription.NextRunAt.Equal(want) {
+		t.Errorf("next run %v, want %v", subscription.NextRunAt, want)
+	}
+	if subscription.IsDue(now) {
+		t.Error("subscription is due again right after advancing")
+	}
+}
+
+func TestSubscriptionAdvanceOnRunTime(t *testing.T) {
+	start := date(2026, time.January, 1)
+	subscription := NewSubscription("s", "u", "USD", 2, nil, start, start)
+
+	// A run exactly at its scheduled time moves one interval on, not zero
+	subscription.Advance(start)
+
+	if want := date(2026, time.January, 15); !subscription.NextRunAt.Equal(want) {
+		t.Errorf("next run %v, want %v", subscription.NextRunAt, want)
+	}
+}
+
+func TestSubscriptionFailuresPauseAfterMax(t *testing.T) {
+	now := date(2026, time.January, 1)
+	subscription := NewSubscription("s", "u", "USD", 1, nil, now, now)
+
+	for i := 1; i < MaxSubscriptionFailures; i++ {
+		subscription.RecordFailure("payment declined", now)
+		if subscription.Status != SubscriptionStatusActive {
+			t.Fatalf("paused after %d failures, want %d", i, MaxSubscriptionFailures)
+		}
+	}
+
+	subscription.RecordFailure("payment declined", now)
+	if subscription.Status != SubscriptionStatusPaused {
+		t.Errorf("status %s after %d failures, want paused", subscription.Status, MaxSubscriptionFailures)
+	}
+	if subscription.FailureCount != MaxSubscriptionFailures {
+		t.Errorf("failure count %d, want %d", subscription.FailureCount, MaxSubscriptionFailures)
+	}
+}
+
+func TestSubscriptionSuccessResetsFailures(t *testing.T) {
+	now := date(2026, time.January, 1)
+	subscription := NewSubscription("s", "u", "USD", 1, nil, now, now)
+
+	for i := 1; i < MaxSubscriptionFailures; i++ {
+		subscription.RecordFailure("payment declined", now)
+	}
+	subscription.RecordSuccess("order-1", now)
+
+	if subscription.FailureCount != 0 || subscription.LastError != nil {
+		t.Errorf("failure count %d, last error %v after a success", subscription.FailureCount, subscription.LastError)
+	}
+	if subscription.LastOrderID == nil || *subscription.LastOrderID != "order-1" {
+		t.Errorf("last order %v, want order-1", subscription.LastOrderID)
+	}
+
+	// The failures before the success no longer count towards a pause
+	subscription.RecordFailure("payment declined", now)
+	if subscription.Status != SubscriptionStatusActive {
+		t.Errorf("status %s, want active", subscription.Status)
+	}
+}
+
+func TestSubscriptionResume(t *testing.T) {
+	start := date(2026, time.January, 1)
+
+	overdue := NewSubscription("s", "u", "USD", 1, nil, start, start)
+	if err := overdue.Pause(start); err != nil {
+		t.Fatal(err)
+	}
+	now := date(2026, time.February, 10)
+	if err := overdue.Resume(now); err != nil {
+		t.Fatal(err)
+	}
+	if !overdue.NextRunAt.Equal(now) {
+		t.Errorf("overdue subscription runs at %v, want %v", overdue.NextRunAt, now)
+	}
+
+	upcoming := NewSubscription("s", "u", "USD", 1, nil, date(2026, time.March, 1), start)
+	upcoming.RecordFailure("a", start)
+	if err := upcoming.Pause(start); err != nil {
+		t.Fatal(err)
+	}
+	if err := upcoming.Resume(now); err != nil {
+		t.Fatal(err)
+	}
+	if want := date(2026, time.March, 1); !upcoming.NextRunAt.Equal(want) {
+		t.Errorf("upcoming subscription runs at %v, want %v", upcoming.NextRunAt, want)
+	}
+	if upcoming.FailureCount != 0 {
+		t.Errorf("failure count %d after resuming, want 0", upcoming.FailureCount)
+	}
+
+	if err := upcoming.Resume(now); err != ErrInvalidSubscription {
+		t.Errorf("resuming an active subscription: got %v, want ErrInvalidSubscription", err)
+	}
+}
@@ -0,0 +1,213 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ProductOption is a dimension products vary in, such as size or colour, with its allowed values
+type ProductOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// ProductOptions are the options of a product, stored as JSON
+type ProductOptions []ProductOption
+
+// Value implements driver.Valuer, storing the options as JSON
+func (o ProductOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]ProductOption(o))
+}
+
+// Scan implements sql.Scanner, reading options stored as JSON
+func (o *ProductOptions) Scan(src interface{}) error {
+	return scanJSON(src, o)
+}
+
+// OptionValues maps option names to the values a variant has, stored as JSON
+type OptionValues map[string]string
+
+// Value implements driver.Valuer, storing the option values as JSON
+func (v OptionValues) Value() (driver.Value, error) {
+	if v == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]string(v))
+}
+
+// Scan implements sql.Scanner, reading option values stored as JSON
+func (v *OptionValues) Scan(src interface{}) error {
+	return scanJSON(src, v)
+}
+
+// scanJSON unmarshals a JSON column into dest
+func scanJSON(src interface{}, dest interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, dest)
+	}
+}
+
+// Variant is a purchasable version of a product with its own SKU and stock
+// Every product has a default variant, whose ID is the product ID, used whenever no variant is given
+type Variant struct {
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku"`
+	// Options maps each product option name to the value of this variant
+	Options OptionValues `json:"options,omitempty"`
+	// Price overrides the product price, in the product currency, nil means the product price applies
+	Price *Money `json:"price,omitempty"`
+	Stock int    `json:"stock"`
+	// Backordered is the number of ordered units of this variant still waiting for stock
+	Backordered int  `json:"backordered"`
+	IsDefault   bool `json:"is_default"`
+	// Version is incremented by every update of the variant, it guards against lost updates
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewVariant creates a new Variant entity
+func NewVariant(id, productID, sku string, options OptionValues, price *Money, stock int) *Variant {
+	now := time.Now()
+	return &Variant{
+		ID:        id,
+		ProductID: productID,
+		SKU:       sku,
+		Options:   options,
+		Price:     price,
+		Stock:     stock,
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsValidSKU checks if a SKU is non-empty, at most 64 characters and free of whitespace
+func IsValidSKU(sku string) bool {
+	return sku != "" && len(sku) <= 64 && !strings.ContainsAny(sku, " \t\r\n")
+}
+
+// ValidateOptions checks the option names are unique and each has unique, non-empty values
+func ValidateOptions(options ProductOptions) error {
+	names := make(map[string]bool, len(options))
+	for _, option := range options {
+		if option.Name == "" || names[option.Name] {
+			return fmt.Errorf("%w: option names must be unique and non-empty", ErrInvalidVariant)
+		}
+		names[option.Name] = true
+
+		if len(option.Values) == 0 {
+			return fmt.Errorf("%w: option %q has no values", ErrInvalidVariant, option.Name)
+		}
+		values := make(map[string]bool, len(option.Values))
+		for _, value := range option.Values {
+			if value == "" || values[value] {
+				return fmt.Errorf("%w: values of option %q must be unique and non-empty", ErrInvalidVariant, option.Name)
+			}
+			values[value] = true
+		}
+	}
+	return nil
+}
+
+// DefaultVariant returns the variant used when none is specified
+func (p *Product) DefaultVariant() *Variant {
+	for _, variant := range p.Variants {
+		if variant.IsDefault {
+			return variant
+		}
+	}
+	return nil
+}
+
+// Variant returns the variant of the product with the given ID
+func (p *Product) Variant(id string) (*Variant, error) {
+	for _, variant := range p.Variants {
+		if variant.ID == id {
+			return variant, nil
+		}
+	}
+	return nil, ErrVariantNotFound
+}
+
+// ValidateVariant checks a variant against the options and the other variants of the product
+// The default variant may leave options out, other variants must set every option of the product.
+// No two variants may share the same option values.
+func (p *Product) ValidateVariant(variant *Variant) error {
+	if !IsValidSKU(variant.SKU) {
+		return fmt.Errorf("%w: SKU must be 1 to 64 characters without spaces", ErrInvalidVariant)
+	}
+
+	if variant.Price != nil && (!variant.Price.IsPositive() || !variant.Price.SameCurrency(p.Price)) {
+		return fmt.Errorf("%w: price must be positive and in %s", ErrInvalidVariant, p.Price.Currency)
+	}
+
+	if !variant.IsDefault && len(p.Options) == 0 {
+		return fmt.Errorf("%w: product has no options to vary", ErrInvalidVariant)
+	}
+
+	for name, value := range variant.Options {
+		i := slices.IndexFunc(p.Options, func(option ProductOption) bool { return option.Name == name })
+		if i < 0 {
+			return fmt.Errorf("%w: unknown option %q", ErrInvalidVariant, name)
+		}
+		if !slices.Contains(p.Options[i].Values, value) {
+			return fmt.Errorf("%w: unknown value %q of option %q", ErrInvalidVariant, value, name)
+		}
+	}
+
+	if !variant.IsDefault && len(variant.Options) != len(p.Options) {
+		return fmt.Errorf("%w: every option must be set", ErrInvalidVariant)
+	}
+
+	for _, other := range p.Variants {
+		if other.ID != variant.ID && len(variant.Options) > 0 && maps.Equal(other.Options, variant.Options) {
+			return fmt.Errorf("%w: variant %s already has these options", ErrInvalidVariant, other.SKU)
+		}
+	}
+
+	return nil
+}
+
+// VariantPriceIn returns the explicit price of a variant in a currency, if one is set
+// A variant price override only exists in the product currency and replaces the product prices
+func (p *Product) VariantPriceIn(variant *Variant, currency string) (Money, bool) {
+	if variant == nil || variant.Price == nil {
+		return p.PriceIn(currency)
+	}
+	if variant.Price.Currency == currency {
+		return *variant.Price, true
+	}
+	return Money{}, false
+}
+
+// VariantBasePrice returns the price of a variant in the product currency
+func (p *Product) VariantBasePrice(variant *Variant) Money {
+	if variant == nil || variant.Price == nil {
+		return p.Price
+	}
+	return *variant.Price
+}
+
+// SumVariantStock sets the product stock and backordered units to the totals of its variants
+func (p *Product) SumVariantStock() {
+	p.Stock, p.Backordered = 0, 0
+	for _, variant := range p.Variants {
+		p.Stock += variant.Stock
+		p.Backordered += variant.Backordered
+	}
+}
@@ -17,7 +17,8 @@ type CartRepository interface {
 	// GetByUserID retrieves a cart by user ID
 	GetByUserID(ctx context.Context, userID string) (*entity.Cart, error)
 
-	// Update updates an existing cart
+	// Update updates an existing cart and its items
+	// ErrVersionConflict is returned if the cart version moved since the cart was read
 	Update(ctx context.Context, cart *entity.Cart) error
 
 	// Delete deletes a cart by ID
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// CategoryRepository defines the interface for category data operations
+type CategoryRepository interface {
+	// Create creates a new category
+	Create(ctx context.Context, category *entity.Category) error
+
+	// GetByID retrieves a category by ID
+	GetByID(ctx context.Context, id string) (*entity.Category, error)
+
+	// GetBySlug retrieves a category by slug
+	GetBySlug(ctx context.Context, slug string) (*entity.Category, error)
+
+	// GetByIDs retrieves the categories with the given IDs, unknown IDs are ignored
+	GetByIDs(ctx context.Context, ids []string) ([]*entity.Category, error)
+
+	// List retrieves all categories, without linking them into a tree
+	List(ctx context.Context) ([]*entity.Category, error)
+
+	// GetDescendantIDs retrieves the ID of a category and of every category below it
+	GetDescendantIDs(ctx context.Context, id string) ([]string, error)
+
+	// Update updates an existing category
+	Update(ctx context.Context, category *entity.Category) error
+
+	// Delete deletes a category, which must not have children
+	// Products assigned to it are unassigned
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// ExchangeRateRepository defines the interface for exchange rate data operations
+type ExchangeRateRepository interface {
+	// Upsert creates or replaces the rate for a currency pair
+	Upsert(ctx context.Context, rate *entity.ExchangeRate) error
+
+	// UpsertMany creates or replaces several rates atomically
+	UpsertMany(ctx context.Context, rates []*entity.ExchangeRate) error
+
+	// Get retrieves the rate for a currency pair
+	Get(ctx context.Context, baseCurrency, quoteCurrency string) (*entity.ExchangeRate, error)
+
+	// List retrieves all rates
+	List(ctx context.Context) ([]*entity.ExchangeRate, error)
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// FraudRepository defines the interface for fraud screening data operations
+type FraudRepository interface {
+	// Create records a screening together with its rule hits
+	Create(ctx context.Context, screening *entity.FraudScreening) error
+
+	// GetLatestByOrderID retrieves the most recent screening of an order
+	GetLatestByOrderID(ctx context.Context, orderID string) (*entity.FraudScreening, error)
+
+	// UpdateReview records the admin review of a screening
+	UpdateReview(ctx context.Context, screening *entity.FraudScreening) error
+
+	// ListPendingReviews retrieves the screenings that held an order and await review, oldest first
+	ListPendingReviews(ctx context.Context) ([]*entity.FraudScreening, error)
+}
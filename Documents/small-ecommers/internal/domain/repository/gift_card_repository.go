@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// GiftCardRepository defines the interface for gift card data operations
+type GiftCardRepository interface {
+	// Create creates a new gift card together with the ledger entry issuing its balance
+	Create(ctx context.Context, card *entity.GiftCard, issue *entity.LedgerEntry) error
+
+	// GetByCode retrieves a gift card by its code
+	GetByCode(ctx context.Context, code string) (*entity.GiftCard, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// InvoiceRepository defines the interface for invoice data operations
+type InvoiceRepository interface {
+	// Create numbers the invoice with the next number of its year and saves it
+	// store is called before the number is committed, if it fails the number is released
+	Create(ctx context.Context, invoice *entity.Invoice, store func(*entity.Invoice) error) error
+
+	// GetByOrderID retrieves the invoice of an order
+	GetByOrderID(ctx context.Context, orderID string) (*entity.Invoice, error)
+}
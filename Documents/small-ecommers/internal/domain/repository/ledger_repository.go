@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// LedgerRepository defines the interface for balance ledger operations
+// Entries are never updated or deleted, balances only change by posting new entries
+type LedgerRepository interface {
+	// Post applies the entries to their balances atomically and fills in their BalanceAfter
+	// It fails with ErrInsufficientBalance if a balance would become negative,
+	// and with ErrGiftCardExpired when debiting an expired gift card
+	Post(ctx context.Context, entries []*entity.LedgerEntry) error
+
+	// GetByAccount retrieves the entries of an account, oldest first
+	GetByAccount(ctx context.Context, accountType entity.LedgerAccountType, accountID string) ([]*entity.LedgerEntry, error)
+
+	// GetByOrderID retrieves the entries posted for an order, oldest first
+	GetByOrderID(ctx context.Context, orderID string) ([]*entity.LedgerEntry, error)
+
+	// GetStoreCreditBalances retrieves the store credit of a user, one balance per currency
+	GetStoreCreditBalances(ctx context.Context, userID string) ([]entity.Money, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// LoyaltyRepository defines the interface for loyalty points ledger operations
+// Entries are never updated or deleted, balances are derived by replaying them
+type LoyaltyRepository interface {
+	// GetByUserID retrieves the points history of a user, oldest first
+	GetByUserID(ctx context.Context, userID string) ([]*entity.LoyaltyEntry, error)
+
+	// Apply locks the points of a user, passes their history to fn and records the entries it returns
+	// Everything happens in one transaction, so fn sees every entry posted before it
+	Apply(ctx context.Context, userID string, fn func(history []*entity.LoyaltyEntry) ([]*entity.LoyaltyEntry, error)) ([]*entity.LoyaltyEntry, error)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"small-ecommers/internal/domain/entity"
 )
@@ -18,14 +19,75 @@ type OrderRepository interface {
 	GetByUserID(ctx context.Context, userID string) ([]*entity.Order, error)
 
 	// Update updates an existing order
+	// ErrVersionConflict is returned if the order version moved since the order was read
 	Update(ctx context.Context, order *entity.Order) error
 
-	// UpdateStatus updates the status of an order
-	UpdateStatus(ctx context.Context, id string, status entity.OrderStatus) error
+	// UpdateStatus saves the status of an order
+	// ErrVersionConflict is returned if the order version moved since the order was read
+	UpdateStatus(ctx context.Context, order *entity.Order) error
 
 	// Delete deletes an order by ID
 	Delete(ctx context.Context, id string) error
 
 	// List retrieves all orders
 	List(ctx context.Context) ([]*entity.Order, error)
+
+	// Search retrieves a page of the orders matching a filter, in the requested order
+	Search(ctx context.Context, search *OrderSearch) ([]*entity.Order, error)
+
+	// Count counts the orders matching a filter
+	Count(ctx context.Context, filter *OrderFilter) (int, error)
+
+	// CountByUserSince counts the orders a user placed since the given time
+	CountByUserSince(ctx context.Context, userID string, since time.Time) (int, error)
+
+	// CountByClientIPSince counts the orders placed from an IP address since the given time
+	CountByClientIPSince(ctx context.Context, clientIP string, since time.Time) (int, error)
+
+	// GetPaidTotals counts a user's paid orders in a currency and sums their totals
+	// Orders that were paid and later shipped or completed are included
+	GetPaidTotals(ctx context.Context, userID, currency string) (int, entity.Money, error)
+}
+
+// OrderSortField is what order search results can be sorted by
+type OrderSortField string
+
+const (
+	OrderSortCreatedAt OrderSortField = "created_at"
+	OrderSortTotal     OrderSortField = "total"
+)
+
+// OrderFilter selects orders, fields left empty do not filter
+type OrderFilter struct {
+	Statuses  []entity.OrderStatus
+	UserID    string
+	ProductID string
+	// CreatedFrom is inclusive and CreatedTo exclusive
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Currency    string
+	// MinTotal and MaxTotal are inclusive and only match orders in their currency
+	MinTotal *entity.Money
+	MaxTotal *entity.Money
+}
+
+// OrderCursor is the position of the last order of a page
+// Only the field of the sort in use and the ID are compared
+type OrderCursor struct {
+	CreatedAt time.Time
+	Total     entity.Money
+	ID        string
+}
+
+// OrderSearch selects a page of orders
+// Orders are sorted by Sort and then by ID, so pages never overlap or skip orders
+type OrderSearch struct {
+	Filter     OrderFilter
+	Sort       OrderSortField
+	Descending bool
+	// After is the last order of the previous page, nil for the first page
+	After *OrderCursor
+	Limit int
+	// IncludeItems loads the items and balance payments of each order
+	IncludeItems bool
 }
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// PriceRepository defines the interface for price history and scheduled price data operations
+// Price changes made by product updates and imports are recorded by the ProductRepository
+type PriceRepository interface {
+	// ListChanges retrieves up to limit price changes of a product, newest first
+	// after is the last change of the previous page, nil for the first page
+	ListChanges(ctx context.Context, productID string, after *PriceChangeCursor, limit int) ([]*entity.PriceChange, error)
+
+	// CreateSchedule creates a new scheduled price
+	CreateSchedule(ctx context.Context, scheduled *entity.ScheduledPrice) error
+
+	// GetSchedule retrieves a scheduled price by ID
+	GetSchedule(ctx context.Context, id string) (*entity.ScheduledPrice, error)
+
+	// ListSchedules retrieves the scheduled prices of a product, by start
+	ListSchedules(ctx context.Context, productID string) ([]*entity.ScheduledPrice, error)
+
+	// ListDue retrieves up to limit scheduled prices due to start or end at or before now, earliest first
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.ScheduledPrice, error)
+
+	// Apply saves a scheduled price that was in status from, and the price change it makes if any
+	// The product price only changes while it is still the old price of the change. Apply reports false,
+	// writing nothing, when the scheduled price left status from or the product price changed meanwhile.
+	Apply(ctx context.Context, scheduled *entity.ScheduledPrice, from entity.ScheduledPriceStatus, change *entity.PriceChange) (bool, error)
+}
+
+// PriceChangeCursor is the position of the last price change of a page
+type PriceChangeCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
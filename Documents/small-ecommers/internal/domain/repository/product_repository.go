@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"small-ecommers/internal/domain/entity"
 )
@@ -17,15 +18,118 @@ type ProductRepository interface {
 	// List retrieves all products
 	List(ctx context.Context) ([]*entity.Product, error)
 
-	// Update updates an existing product
-	Update(ctx context.Context, product *entity.Product) error
+	// Search retrieves a page of the products matching a filter, in the requested order
+	Search(ctx context.Context, search *ProductSearch) ([]*entity.Product, error)
 
-	// Delete deletes a product by ID
-	Delete(ctx context.Context, id string) error
+	// Count counts the products matching a filter
+	Count(ctx context.Context, filter *ProductFilter) (int, error)
 
-	// UpdateStock updates the stock of a product
-	UpdateStock(ctx context.Context, id string, stock int) error
+	// Update updates an existing product, except for its variants
+	// priceChange is recorded with the update, nil when the price did not change
+	// ErrVersionConflict is returned if the product version moved since the product was read
+	Update(ctx context.Context, product *entity.Product, priceChange *entity.PriceChange) error
+
+	// UpdateStatus saves the status of a product and when it was archived
+	// Products are never deleted, so orders keep referring to them
+	// ErrVersionConflict is returned if the product version moved since the product was read
+	UpdateStatus(ctx context.Context, product *entity.Product) error
+
+	// CreateVariant adds a variant to a product
+	CreateVariant(ctx context.Context, variant *entity.Variant) error
+
+	// UpdateVariant updates an existing variant, except for its stock
+	UpdateVariant(ctx context.Context, variant *entity.Variant) error
+
+	// DeleteVariant deletes a variant by ID
+	// It returns ErrVariantInUse while an order, a cart or a subscription references the variant
+	DeleteVariant(ctx context.Context, id string) error
+
+	// CreateImage adds an image to a product
+	CreateImage(ctx context.Context, image *entity.ProductImage) error
+
+	// UpdateImage updates the alt text of an image
+	UpdateImage(ctx context.Context, image *entity.ProductImage) error
+
+	// DeleteImage deletes an image by ID
+	DeleteImage(ctx context.Context, id string) error
+
+	// SetImagePositions numbers the images of a product in the given order, starting at zero
+	SetImagePositions(ctx context.Context, productID string, imageIDs []string) error
+
+	// UpdateStock sets the stock of a variant
+	// Waiting backorders are filled first come, first served and returned
+	UpdateStock(ctx context.Context, variantID string, stock int) ([]*entity.Backorder, error)
+
+	// ReleaseStock returns the allocated and backordered units of a cancelled order item to its variant
+	// Released stock goes to waiting backorders first, the filled backorders are returned
+	ReleaseStock(ctx context.Context, variantID string, allocated, backordered int) ([]*entity.Backorder, error)
 
 	// GetByIDs retrieves products by multiple IDs
 	GetByIDs(ctx context.Context, ids []string) ([]*entity.Product, error)
+
+	// GetVariantsBySKUs retrieves the variants with the given SKUs, unknown SKUs are ignored
+	GetVariantsBySKUs(ctx context.Context, skus []string) ([]*entity.Variant, error)
+
+	// Import creates or updates products and their imported variants in one transaction
+	// Nothing is written if any of them fails
+	Import(ctx context.Context, imports []*ProductImport) error
+}
+
+// ProductImport is a product written by an import
+type ProductImport struct {
+	Product *entity.Product
+	// New creates the product, otherwise it is updated including its status
+	New bool
+	// Variants are created or updated, other variants of the product are left untouched
+	Variants []*entity.Variant
+	// Stock sets the stock of variants by ID, waiting backorders are filled first
+	Stock map[string]int
+	// PriceChange is recorded with an updated product, nil when its price did not change
+	PriceChange *entity.PriceChange
+}
+
+// ProductSortField is what product listings can be sorted by
+type ProductSortField string
+
+const (
+	ProductSortCreatedAt ProductSortField = "created_at"
+	ProductSortPrice     ProductSortField = "price"
+	ProductSortName      ProductSortField = "name"
+)
+
+// ProductFilter selects products, fields left empty do not filter
+type ProductFilter struct {
+	// MinPrice and MaxPrice are inclusive and only match products priced in their currency
+	MinPrice *entity.Money
+	MaxPrice *entity.Money
+	// InStock keeps only products with a variant in stock
+	InStock bool
+	// NamePrefix matches the start of the name, ignoring case
+	NamePrefix string
+	// CategoryIDs keeps products assigned to any of the categories
+	CategoryIDs []string
+	// Status keeps products in that status
+	Status entity.ProductStatus
+	// Attributes keeps products having all of these attribute values
+	Attributes entity.ProductAttributes
+}
+
+// ProductCursor is the position of the last product of a page
+// Only the field of the sort in use and the ID are compared
+type ProductCursor struct {
+	CreatedAt time.Time
+	Price     entity.Money
+	Name      string
+	ID        string
+}
+
+// ProductSearch selects a page of products
+// Products are sorted by Sort and then by ID, so pages never overlap or skip products
+type ProductSearch struct {
+	Filter     ProductFilter
+	Sort       ProductSortField
+	Descending bool
+	// After is the last product of the previous page, nil for the first page
+	After *ProductCursor
+	Limit int
 }
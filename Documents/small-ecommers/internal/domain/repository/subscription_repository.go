@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// SubscriptionRepository defines the interface for subscription data operations
+type SubscriptionRepository interface {
+	// Create creates a new subscription
+	Create(ctx context.Context, subscription *entity.Subscription) error
+
+	// GetByID retrieves a subscription by ID
+	GetByID(ctx context.Context, id string) (*entity.Subscription, error)
+
+	// GetByUserID retrieves all subscriptions for a user
+	GetByUserID(ctx context.Context, userID string) ([]*entity.Subscription, error)
+
+	// Update updates an existing subscription
+	Update(ctx context.Context, subscription *entity.Subscription) error
+
+	// ListDue retrieves up to limit active subscriptions whose next run is at or before now
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.Subscription, error)
+
+	// Claim moves the next run of a subscription from scheduledAt to nextRunAt
+	// It reports false when another worker already claimed the run
+	Claim(ctx context.Context, id string, scheduledAt, nextRunAt time.Time) (bool, error)
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"strconv"
 
+	"small-ecommers/internal/domain/entity"
 	"small-ecommers/internal/usecase"
 
 	"github.com/gofiber/fiber/v2"
@@ -22,7 +24,7 @@ func NewCartHandler(cartUseCase *usecase.CartUseCase) *CartHandler {
 
 // GetCart handles getting a user's cart
 // @Summary Get user's cart
-// @Description Get the shopping cart for the authenticated user
+// @Description Get the shopping cart for the authenticated user, the ETag header holds the version to send as If-Match when updating it
 // @Tags cart
 // @Produce json
 // @Success 200 {object} entity.Cart
@@ -38,6 +40,7 @@ func (h *CartHandler) GetCart(c *fiber.Ctx) error {
 		})
 	}
 
+	setETag(c, cart.Version)
 	return c.JSON(cart)
 }
 
@@ -70,11 +73,17 @@ func (h *CartHandler) AddItem(c *fiber.Ctx) error {
 
 	cart, err := h.cartUseCase.AddItem(c.Context(), userID, &req)
 	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setETag(c, cart.Version)
 	return c.JSON(cart)
 }
 
@@ -82,46 +91,55 @@ func (h *CartHandler) AddItem(c *fiber.Ctx) error {
 // @Summary Remove item from cart
 // @Description Remove an item from the user's shopping cart
 // @Tags cart
-// @Param id path string true "Product ID"
+// @Param id path string true "Variant ID, or product ID for its default variant"
 // @Success 200 {object} entity.Cart
 // @Failure 404 {object} map[string]string
 // @Router /api/v1/cart/items/{id} [delete]
 func (h *CartHandler) RemoveItem(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
-	productID := c.Params("id")
+	variantID := c.Params("id")
 
-	if productID == "" {
+	if variantID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Product ID is required",
+			"error": "Variant ID is required",
 		})
 	}
 
-	cart, err := h.cartUseCase.RemoveItem(c.Context(), userID, productID)
+	cart, err := h.cartUseCase.RemoveItem(c.Context(), userID, variantID)
 	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setETag(c, cart.Version)
 	return c.JSON(cart)
 }
 
 // UpdateItemQuantity handles updating the quantity of an item in the cart
 // @Summary Update item quantity in cart
-// @Description Update the quantity of an item in the user's shopping cart
+// @Description Update the quantity of an item in the user's shopping cart, unless it changed since the version in If-Match
 // @Tags cart
-// @Param id path string true "Product ID"
+// @Param id path string true "Variant ID, or product ID for its default variant"
 // @Param quantity query int true "New quantity"
+// @Param If-Match header string true "ETag of the cart the update was made to, or *"
 // @Success 200 {object} entity.Cart
 // @Failure 400 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /api/v1/cart/items/{id} [put]
 func (h *CartHandler) UpdateItemQuantity(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
-	productID := c.Params("id")
+	variantID := c.Params("id")
 
-	if productID == "" {
+	if variantID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Product ID is required",
+			"error": "Variant ID is required",
 		})
 	}
 
@@ -145,13 +163,22 @@ func (h *CartHandler) UpdateItemQuantity(c *fiber.Ctx) error {
 		})
 	}
 
-	cart, err := h.cartUseCase.UpdateItemQuantity(c.Context(), userID, productID, quantity)
+	version, err := ifMatchVersion(c)
 	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	cart, err := h.cartUseCase.UpdateItemQuantity(c.Context(), userID, variantID, version, quantity)
+	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return preconditionError(c, err)
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setETag(c, cart.Version)
 	return c.JSON(cart)
 }
 
@@ -167,10 +194,64 @@ func (h *CartHandler) ClearCart(c *fiber.Ctx) error {
 
 	cart, err := h.cartUseCase.ClearCart(c.Context(), userID)
 	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setETag(c, cart.Version)
+	return c.JSON(cart)
+}
+
+// SetCurrency handles changing the currency of the cart
+// @Summary Set cart currency
+// @Description Switch the cart to another currency and re-price its items, unless it changed since the version in If-Match
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param If-Match header string true "ETag of the cart the update was made to, or *"
+// @Param request body usecase.SetCurrencyRequest true "Set currency request"
+// @Success 200 {object} entity.Cart
+// @Failure 400 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
+// @Router /api/v1/cart/currency [put]
+func (h *CartHandler) SetCurrency(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req usecase.SetCurrencyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Currency == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Currency is required",
+		})
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	cart, err := h.cartUseCase.SetCurrency(c.Context(), userID, version, &req)
+	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return preconditionError(c, err)
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	setETag(c, cart.Version)
 	return c.JSON(cart)
 }
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CategoryHandler handles HTTP requests for product category operations
+type CategoryHandler struct {
+	categoryUseCase *usecase.CategoryUseCase
+}
+
+// NewCategoryHandler creates a new CategoryHandler
+func NewCategoryHandler(categoryUseCase *usecase.CategoryUseCase) *CategoryHandler {
+	return &CategoryHandler{
+		categoryUseCase: categoryUseCase,
+	}
+}
+
+// GetCategoryTree handles getting the category tree
+// @Summary Get category tree
+// @Description Get all categories, nested under their parents and ordered by position
+// @Tags categories
+// @Produce json
+// @Success 200 {array} entity.Category
+// @Router /api/v1/categories [get]
+func (h *CategoryHandler) GetCategoryTree(c *fiber.Ctx) error {
+	tree, err := h.categoryUseCase.GetCategoryTree(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(tree)
+}
+
+// CreateCategory handles creating a category
+// @Summary Create a category
+// @Description Create a category, at the top level or below a parent (admin only)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param request body usecase.CreateCategoryRequest true "Create category request"
+// @Success 201 {object} entity.Category
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/admin/categories [post]
+func (h *CategoryHandler) CreateCategory(c *fiber.Ctx) error {
+	var req usecase.CreateCategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	category, err := h.categoryUseCase.CreateCategory(c.Context(), &req)
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(category)
+}
+
+// UpdateCategory handles updating a category
+// @Summary Update a category
+// @Description Rename, reorder or move a category (admin only)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param request body usecase.UpdateCategoryRequest true "Update category request"
+// @Success 200 {object} entity.Category
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/admin/categories/{id} [put]
+func (h *CategoryHandler) UpdateCategory(c *fiber.Ctx) error {
+	var req usecase.UpdateCategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	category, err := h.categoryUseCase.UpdateCategory(c.Context(), c.Params("id"), &req)
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.JSON(category)
+}
+
+// DeleteCategory handles deleting a category
+// @Summary Delete a category
+// @Description Delete a category without children, its products are unassigned from it (admin only)
+// @Tags categories
+// @Param id path string true "Category ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/admin/categories/{id} [delete]
+func (h *CategoryHandler) DeleteCategory(c *fiber.Ctx) error {
+	if err := h.categoryUseCase.DeleteCategory(c.Context(), c.Params("id")); err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// categoryError responds with the status matching a category error
+func categoryError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, entity.ErrCategoryNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, entity.ErrInvalidCategory):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, entity.ErrCategoryExists), errors.Is(err, entity.ErrCategoryHasChildren):
+		status = fiber.StatusConflict
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
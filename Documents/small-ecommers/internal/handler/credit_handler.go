@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreditHandler handles HTTP requests for gift card and store credit operations
+type CreditHandler struct {
+	creditUseCase *usecase.CreditUseCase
+}
+
+// NewCreditHandler creates a new CreditHandler
+func NewCreditHandler(creditUseCase *usecase.CreditUseCase) *CreditHandler {
+	return &CreditHandler{
+		creditUseCase: creditUseCase,
+	}
+}
+
+// IssueGiftCard handles issuing a new gift card
+// @Summary Issue gift card
+// @Description Issue a gift card with a random code (admin only)
+// @Tags gift-cards
+// @Accept json
+// @Produce json
+// @Param request body usecase.IssueGiftCardRequest true "Issue gift card request"
+// @Success 201 {object} entity.GiftCard
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/gift-cards [post]
+func (h *CreditHandler) IssueGiftCard(c *fiber.Ctx) error {
+	var req usecase.IssueGiftCardRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	card, err := h.creditUseCase.IssueGiftCard(c.Context(), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(card)
+}
+
+// GetGiftCard handles checking the balance of a gift card
+// @Summary Get gift card
+// @Description Get the balance and expiry of a gift card by its code
+// @Tags gift-cards
+// @Produce json
+// @Param code path string true "Gift card code"
+// @Success 200 {object} entity.GiftCard
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/gift-cards/{code} [get]
+func (h *CreditHandler) GetGiftCard(c *fiber.Ctx) error {
+	card, err := h.creditUseCase.GetGiftCard(c.Context(), c.Params("code"))
+	if err != nil {
+		return giftCardError(c, err)
+	}
+
+	return c.JSON(card)
+}
+
+// GetGiftCardStatement handles getting a gift card with its ledger entries
+// @Summary Get gift card statement
+// @Description Get a gift card and every entry posted to it (admin only)
+// @Tags gift-cards
+// @Produce json
+// @Param code path string true "Gift card code"
+// @Success 200 {object} usecase.GiftCardStatement
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/gift-cards/{code} [get]
+func (h *CreditHandler) GetGiftCardStatement(c *fiber.Ctx) error {
+	statement, err := h.creditUseCase.GetGiftCardStatement(c.Context(), c.Params("code"))
+	if err != nil {
+		return giftCardError(c, err)
+	}
+
+	return c.JSON(statement)
+}
+
+// GetStoreCredit handles getting the store credit of the authenticated user
+// @Summary Get store credit
+// @Description Get the store credit balances and ledger entries of the authenticated user
+// @Tags store-credit
+// @Produce json
+// @Success 200 {object} usecase.StoreCreditStatement
+// @Router /api/v1/store-credit [get]
+func (h *CreditHandler) GetStoreCredit(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	statement, err := h.creditUseCase.GetStoreCredit(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(statement)
+}
+
+// AdjustStoreCredit handles crediting or debiting a user's store credit
+// @Summary Adjust store credit
+// @Description Post a manual store credit adjustment for a user (admin only)
+// @Tags store-credit
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body usecase.AdjustStoreCreditRequest true "Adjust store credit request"
+// @Success 201 {object} entity.LedgerEntry
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/users/{id}/store-credit [post]
+func (h *CreditHandler) AdjustStoreCredit(c *fiber.Ctx) error {
+	var req usecase.AdjustStoreCreditRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	entry, err := h.creditUseCase.AdjustStoreCredit(c.Context(), c.Params("id"), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// giftCardError responds to a failed gift card lookup
+func giftCardError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, entity.ErrGiftCardNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"small-ecommers/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// If-Match errors, the update is refused before it is attempted
+var (
+	errIfMatchRequired = errors.New("If-Match header with the ETag of the resource is required")
+	errIfMatchInvalid  = errors.New("If-Match header must be a single ETag of the resource or *")
+)
+
+// setETag sets the ETag of a response to the version of the product, order or cart it returns
+func setETag(c *fiber.Ctx, version int) {
+	c.Set(fiber.HeaderETag, strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatchVersion reads the version an update was made to from the If-Match header
+// "*" matches any version, weak ETags are compared like strong ones
+func ifMatchVersion(c *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		return 0, errIfMatchRequired
+	}
+
+	if header == "*" {
+		return usecase.AnyVersion, nil
+	}
+
+	tag, err := strconv.Unquote(strings.TrimPrefix(header, "W/"))
+	if err != nil {
+		return 0, errIfMatchInvalid
+	}
+
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, errIfMatchInvalid
+	}
+
+	return version, nil
+}
+
+// preconditionError responds to a conditional update whose If-Match header was missing or did not match
+func preconditionError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusPreconditionFailed
+	if errors.Is(err, errIfMatchRequired) {
+		status = fiber.StatusPreconditionRequired
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
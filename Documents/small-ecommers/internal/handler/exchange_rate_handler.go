@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+
+	"small-ecommers/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExchangeRateHandler handles HTTP requests for exchange rate operations
+type ExchangeRateHandler struct {
+	exchangeRateUseCase *usecase.ExchangeRateUseCase
+}
+
+// NewExchangeRateHandler creates a new ExchangeRateHandler
+func NewExchangeRateHandler(exchangeRateUseCase *usecase.ExchangeRateUseCase) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		exchangeRateUseCase: exchangeRateUseCase,
+	}
+}
+
+// ListRates handles listing all exchange rates
+// @Summary List exchange rates
+// @Description Get all exchange rates quoted against the base currency
+// @Tags exchange-rates
+// @Produce json
+// @Success 200 {array} entity.ExchangeRate
+// @Router /api/v1/exchange-rates [get]
+func (h *ExchangeRateHandler) ListRates(c *fiber.Ctx) error {
+	rates, err := h.exchangeRateUseCase.ListRates(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(rates)
+}
+
+// SetRate handles setting the exchange rate for a currency
+// @Summary Set exchange rate
+// @Description Set the rate from the base currency into a quote currency (admin only)
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param currency path string true "Quote currency"
+// @Param request body usecase.SetRateRequest true "Set rate request"
+// @Success 200 {object} entity.ExchangeRate
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/exchange-rates/{currency} [put]
+func (h *ExchangeRateHandler) SetRate(c *fiber.Ctx) error {
+	currency := c.Params("currency")
+	if currency == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Currency is required",
+		})
+	}
+
+	var req usecase.SetRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	rate, err := h.exchangeRateUseCase.SetRate(c.Context(), currency, &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(rate)
+}
+
+// ImportRates handles importing exchange rates from a CSV file
+// @Summary Import exchange rates
+// @Description Import rates from a CSV upload (field "file") or a text/csv body (admin only)
+// @Tags exchange-rates
+// @Accept multipart/form-data
+// @Produce json
+// @Success 200 {array} entity.ExchangeRate
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/exchange-rates/import [post]
+func (h *ExchangeRateHandler) ImportRates(c *fiber.Ctx) error {
+	var body io.Reader = bytes.NewReader(c.Body())
+
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid file upload",
+			})
+		}
+		defer file.Close()
+		body = file
+	}
+
+	rates, err := h.exchangeRateUseCase.ImportRates(c.Context(), body)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(rates)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FraudHandler handles HTTP requests for fraud screening operations
+type FraudHandler struct {
+	screener *usecase.FraudScreener
+}
+
+// NewFraudHandler creates a new FraudHandler
+func NewFraudHandler(screener *usecase.FraudScreener) *FraudHandler {
+	return &FraudHandler{
+		screener: screener,
+	}
+}
+
+// ListPendingReviews handles listing the orders held for fraud review
+// @Summary List pending fraud reviews
+// @Description List the screenings of orders on hold that await an admin decision (admin only)
+// @Tags fraud
+// @Produce json
+// @Success 200 {array} entity.FraudScreening
+// @Router /api/v1/admin/fraud/reviews [get]
+func (h *FraudHandler) ListPendingReviews(c *fiber.Ctx) error {
+	screenings, err := h.screener.ListPendingReviews(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(screenings)
+}
+
+// GetScreening handles getting the latest fraud screening of an order
+// @Summary Get order fraud screening
+// @Description Get the score, outcome and rule hits of an order's latest screening (admin only)
+// @Tags fraud
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} entity.FraudScreening
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/orders/{id}/screening [get]
+func (h *FraudHandler) GetScreening(c *fiber.Ctx) error {
+	screening, err := h.screener.GetScreening(c.Context(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, entity.ErrFraudScreeningNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(screening)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// InvoiceHandler handles HTTP requests for invoice operations
+type InvoiceHandler struct {
+	invoiceUseCase *usecase.InvoiceUseCase
+}
+
+// NewInvoiceHandler creates a new InvoiceHandler
+func NewInvoiceHandler(invoiceUseCase *usecase.InvoiceUseCase) *InvoiceHandler {
+	return &InvoiceHandler{
+		invoiceUseCase: invoiceUseCase,
+	}
+}
+
+// GetInvoice handles downloading the invoice of an order
+// @Summary Get order invoice
+// @Description Download the PDF invoice of a paid order owned by the user
+// @Tags orders
+// @Produce application/pdf
+// @Param id path string true "Order ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/orders/{id}/invoice [get]
+func (h *InvoiceHandler) GetInvoice(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Order ID is required",
+		})
+	}
+
+	invoice, content, err := h.invoiceUseCase.GetInvoice(c.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, entity.ErrOrderNotFound) || errors.Is(err, entity.ErrInvoiceNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", invoice.FileName()))
+
+	return c.SendStream(content)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"small-ecommers/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LoyaltyHandler handles HTTP requests for loyalty points operations
+type LoyaltyHandler struct {
+	loyaltyUseCase *usecase.LoyaltyUseCase
+}
+
+// NewLoyaltyHandler creates a new LoyaltyHandler
+func NewLoyaltyHandler(loyaltyUseCase *usecase.LoyaltyUseCase) *LoyaltyHandler {
+	return &LoyaltyHandler{
+		loyaltyUseCase: loyaltyUseCase,
+	}
+}
+
+// GetBalance handles getting the loyalty points balance of the authenticated user
+// @Summary Get loyalty balance
+// @Description Get the points the authenticated user can spend and when the next points expire
+// @Tags loyalty
+// @Produce json
+// @Success 200 {object} entity.LoyaltyBalance
+// @Router /api/v1/me/loyalty [get]
+func (h *LoyaltyHandler) GetBalance(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	balance, err := h.loyaltyUseCase.GetBalance(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(balance)
+}
+
+// GetHistory handles getting the loyalty points history of the authenticated user
+// @Summary Get loyalty history
+// @Description Get every points entry of the authenticated user, oldest first
+// @Tags loyalty
+// @Produce json
+// @Success 200 {array} entity.LoyaltyEntry
+// @Router /api/v1/me/loyalty/history [get]
+func (h *LoyaltyHandler) GetHistory(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	history, err := h.loyaltyUseCase.GetHistory(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(history)
+}
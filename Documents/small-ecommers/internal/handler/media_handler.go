@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MediaHandler serves stored product images
+type MediaHandler struct {
+	productUseCase *usecase.ProductUseCase
+	maxAge         time.Duration
+}
+
+// NewMediaHandler creates a new MediaHandler
+// Responses may be cached for maxAge, stored files never change as every upload gets a new key
+func NewMediaHandler(productUseCase *usecase.ProductUseCase, maxAge time.Duration) *MediaHandler {
+	return &MediaHandler{
+		productUseCase: productUseCase,
+		maxAge:         maxAge,
+	}
+}
+
+// ServeMedia handles serving a stored image file
+// @Summary Get an image file
+// @Description Serve an original or thumbnail product image with long-lived cache headers
+// @Tags media
+// @Produce image/jpeg,image/png,image/gif
+// @Param key path string true "Storage key"
+// @Success 200 {file} binary
+// @Failure 404 {object} map[string]string
+// @Router /media/{key} [get]
+func (h *MediaHandler) ServeMedia(c *fiber.Ctx) error {
+	content, contentType, err := h.productUseCase.OpenImageFile(c.Context(), c.Params("*"))
+	if err != nil {
+		if errors.Is(err, entity.ErrBlobNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "File not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d, immutable", int(h.maxAge.Seconds())))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+
+	return c.SendStream(content)
+}
@@ -1,6 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
 	"small-ecommers/internal/domain/entity"
 	"small-ecommers/internal/usecase"
 
@@ -21,28 +26,42 @@ func NewOrderHandler(orderUseCase *usecase.OrderUseCase) *OrderHandler {
 
 // CreateOrder handles creating a new order from cart
 // @Summary Create order from cart
-// @Description Create a new order from the user's shopping cart
+// @Description Create a new order from the user's shopping cart, optionally paying with gift cards and store credit
 // @Tags orders
+// @Accept json
 // @Produce json
+// @Param request body usecase.CheckoutRequest false "Checkout request"
 // @Success 201 {object} entity.Order
 // @Failure 400 {object} map[string]string
 // @Router /api/v1/orders [post]
 func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
 
-	order, err := h.orderUseCase.CreateOrder(c.Context(), userID)
+	var req usecase.CheckoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	req.ClientIP = c.IP()
+
+	order, err := h.orderUseCase.CreateOrder(c.Context(), userID, &req)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setETag(c, order.Version)
 	return c.Status(fiber.StatusCreated).JSON(order)
 }
 
 // GetOrder handles getting an order by ID
 // @Summary Get order by ID
-// @Description Get an order by its ID
+// @Description Get one of the authenticated user's orders by its ID, the ETag header holds the version to send as If-Match when updating it
 // @Tags orders
 // @Produce json
 // @Param id path string true "Order ID"
@@ -50,6 +69,8 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 // @Failure 404 {object} map[string]string
 // @Router /api/v1/orders/{id} [get]
 func (h *OrderHandler) GetOrder(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
 	id := c.Params("id")
 	if id == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -57,45 +78,70 @@ func (h *OrderHandler) GetOrder(c *fiber.Ctx) error {
 		})
 	}
 
-	order, err := h.orderUseCase.GetOrder(c.Context(), id)
+	order, err := h.orderUseCase.GetOrder(c.Context(), userID, id)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		if errors.Is(err, entity.ErrOrderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setETag(c, order.Version)
 	return c.JSON(order)
 }
 
-// GetUserOrders handles getting all orders for a user
+// GetUserOrders handles getting the order history of a user
 // @Summary Get user's orders
-// @Description Get all orders for the authenticated user
+// @Description Get the authenticated user's orders, newest first, a page at a time
 // @Tags orders
 // @Produce json
-// @Success 200 {array} entity.Order
+// @Param status query string false "Comma separated statuses"
+// @Param include query string false "items to include the items of each order"
+// @Param limit query int false "Page size, at most 200"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} usecase.OrderPage
+// @Failure 400 {object} map[string]string
 // @Router /api/v1/orders [get]
 func (h *OrderHandler) GetUserOrders(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
 
-	orders, err := h.orderUseCase.GetUserOrders(c.Context(), userID)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	req := &usecase.OrderHistoryRequest{
+		Statuses:     parseStatuses(c),
+		Cursor:       c.Query("cursor"),
+		IncludeItems: includesItems(c),
+	}
+
+	var err error
+	if req.Limit, err = parseLimit(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return c.JSON(orders)
+	page, err := h.orderUseCase.GetUserOrders(c.Context(), userID, req)
+	if err != nil {
+		return orderSearchError(c, err)
+	}
+
+	return c.JSON(page)
 }
 
 // PayOrder handles paying an order
 // @Summary Pay order
-// @Description Mark an order as paid
+// @Description Pay one of the authenticated user's orders
 // @Tags orders
 // @Param id path string true "Order ID"
 // @Success 200 {object} entity.Order
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /api/v1/orders/{id}/pay [post]
 func (h *OrderHandler) PayOrder(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
 	id := c.Params("id")
 	if id == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -103,25 +149,49 @@ func (h *OrderHandler) PayOrder(c *fiber.Ctx) error {
 		})
 	}
 
-	order, err := h.orderUseCase.PayOrder(c.Context(), id)
+	order, err := h.orderUseCase.PayOrder(c.Context(), userID, id)
 	if err != nil {
+		if errors.Is(err, entity.ErrOrderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, entity.ErrPaymentDeclined) {
+			return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, entity.ErrOrderRejected) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setETag(c, order.Version)
 	return c.JSON(order)
 }
 
 // CancelOrder handles cancelling an order
 // @Summary Cancel order
-// @Description Cancel an order
+// @Description Cancel one of the authenticated user's orders, a paid order is refunded
 // @Tags orders
 // @Param id path string true "Order ID"
 // @Success 200 {object} entity.Order
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /api/v1/orders/{id}/cancel [post]
 func (h *OrderHandler) CancelOrder(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
 	id := c.Params("id")
 	if id == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -129,25 +199,41 @@ func (h *OrderHandler) CancelOrder(c *fiber.Ctx) error {
 		})
 	}
 
-	order, err := h.orderUseCase.CancelOrder(c.Context(), id)
+	order, err := h.orderUseCase.CancelOrder(c.Context(), userID, id)
 	if err != nil {
+		if errors.Is(err, entity.ErrOrderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setETag(c, order.Version)
 	return c.JSON(order)
 }
 
 // UpdateOrderStatus handles updating the status of an order
 // @Summary Update order status
-// @Description Update the status of an order (admin only)
+// @Description Update the status of an order, unless it changed since the version in If-Match (admin only)
 // @Tags orders
 // @Param id path string true "Order ID"
 // @Param status query string true "New status"
+// @Param If-Match header string true "ETag of the order the update was made to, or *"
 // @Success 200 {object} entity.Order
 // @Failure 400 {object} map[string]string
-// @Router /api/v1/orders/{id}/status [put]
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/admin/orders/{id}/status [put]
 func (h *OrderHandler) UpdateOrderStatus(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
@@ -166,22 +252,375 @@ func (h *OrderHandler) UpdateOrderStatus(c *fiber.Ctx) error {
 	status := entity.OrderStatus(statusStr)
 
 	// Validate status
-	switch status {
-	case entity.OrderStatusPending, entity.OrderStatusPaid, entity.OrderStatusShipped,
-		entity.OrderStatusCompleted, entity.OrderStatusCancelled:
-		// Valid status
-	default:
+	if !status.IsValid() {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid status",
 		})
 	}
 
-	order, err := h.orderUseCase.UpdateOrderStatus(c.Context(), id, status)
+	version, err := ifMatchVersion(c)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		return preconditionError(c, err)
+	}
+
+	order, err := h.orderUseCase.UpdateOrderStatus(c.Context(), id, version, status)
+	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return preconditionError(c, err)
+		}
+		httpStatus := fiber.StatusBadRequest
+		switch {
+		case errors.Is(err, entity.ErrOrderNotFound):
+			httpStatus = fiber.StatusNotFound
+		case errors.Is(err, entity.ErrOrderBackordered):
+			httpStatus = fiber.StatusConflict
+		}
+		return c.Status(httpStatus).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setETag(c, order.Version)
 	return c.JSON(order)
 }
+
+// Reorder handles copying a previous order into the cart
+// @Summary Reorder
+// @Description Copy the still available items of a previous order into the cart at current prices
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} usecase.ReorderResult
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/orders/{id}/reorder [post]
+func (h *OrderHandler) Reorder(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Order ID is required",
+		})
+	}
+
+	result, err := h.orderUseCase.Reorder(c.Context(), userID, id)
+	if err != nil {
+		if errors.Is(err, entity.ErrOrderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// RefundOrder handles refunding an order to store credit
+// @Summary Refund order
+// @Description Refund a paid order, in full or in part, to the customer's store credit (admin only)
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body usecase.RefundRequest false "Refund request"
+// @Success 200 {object} entity.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/orders/{id}/refund [post]
+func (h *OrderHandler) RefundOrder(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Order ID is required",
+		})
+	}
+
+	var req usecase.RefundRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	order, err := h.orderUseCase.RefundOrder(c.Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, entity.ErrOrderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	setETag(c, order.Version)
+	return c.JSON(order)
+}
+
+// ApproveOrder handles approving an order held by fraud screening
+// @Summary Approve held order
+// @Description Release an order held by fraud screening and complete its payment (admin only)
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body usecase.ReviewOrderRequest false "Review request"
+// @Success 200 {object} entity.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/orders/{id}/approve [post]
+func (h *OrderHandler) ApproveOrder(c *fiber.Ctx) error {
+	reviewerID := c.Locals("user_id").(string)
+
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Order ID is required",
+		})
+	}
+
+	var req usecase.ReviewOrderRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	order, err := h.orderUseCase.ApproveOrder(c.Context(), id, reviewerID, &req)
+	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, entity.ErrOrderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	setETag(c, order.Version)
+	return c.JSON(order)
+}
+
+// RejectOrder handles rejecting an order held by fraud screening
+// @Summary Reject held order
+// @Description Cancel an order held by fraud screening (admin only)
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body usecase.ReviewOrderRequest false "Review request"
+// @Success 200 {object} entity.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/orders/{id}/reject [post]
+func (h *OrderHandler) RejectOrder(c *fiber.Ctx) error {
+	reviewerID := c.Locals("user_id").(string)
+
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Order ID is required",
+		})
+	}
+
+	var req usecase.ReviewOrderRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	order, err := h.orderUseCase.RejectOrder(c.Context(), id, reviewerID, &req)
+	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, entity.ErrOrderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	setETag(c, order.Version)
+	return c.JSON(order)
+}
+
+// SearchOrders handles searching all orders
+// @Summary Search orders
+// @Description Search orders by status, user, product, date and total, a page at a time (admin only)
+// @Tags orders
+// @Produce json
+// @Param status query string false "Comma separated statuses"
+// @Param user_id query string false "User ID"
+// @Param product_id query string false "Product ID"
+// @Param from query string false "Placed at or after, RFC 3339 or YYYY-MM-DD"
+// @Param to query string false "Placed before, RFC 3339 or YYYY-MM-DD"
+// @Param currency query string false "Currency, required with a total range"
+// @Param min_total query string false "Minimum total"
+// @Param max_total query string false "Maximum total"
+// @Param sort query string false "created_at, total, -created_at (default) or -total"
+// @Param include query string false "items to include the items of each order"
+// @Param limit query int false "Page size, at most 200"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} usecase.OrderPage
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/orders [get]
+func (h *OrderHandler) SearchOrders(c *fiber.Ctx) error {
+	req, err := parseOrderSearch(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	page, err := h.orderUseCase.SearchOrders(c.Context(), req)
+	if err != nil {
+		return orderSearchError(c, err)
+	}
+
+	return c.JSON(page)
+}
+
+// CountOrders handles counting the orders matching search filters
+// @Summary Count orders
+// @Description Count the orders matching the same filters as the order search (admin only)
+// @Tags orders
+// @Produce json
+// @Success 200 {object} map[string]int
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/orders/count [get]
+func (h *OrderHandler) CountOrders(c *fiber.Ctx) error {
+	req, err := parseOrderSearch(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	count, err := h.orderUseCase.CountOrders(c.Context(), req)
+	if err != nil {
+		return orderSearchError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"count": count,
+	})
+}
+
+// parseOrderSearch reads the order search filters from the query string
+func parseOrderSearch(c *fiber.Ctx) (*usecase.OrderSearchRequest, error) {
+	req := &usecase.OrderSearchRequest{
+		UserID:       c.Query("user_id"),
+		ProductID:    c.Query("product_id"),
+		Currency:     strings.ToUpper(c.Query("currency")),
+		MinTotal:     c.Query("min_total"),
+		MaxTotal:     c.Query("max_total"),
+		Sort:         c.Query("sort"),
+		Cursor:       c.Query("cursor"),
+		Statuses:     parseStatuses(c),
+		IncludeItems: includesItems(c),
+	}
+
+	var err error
+	if req.From, err = parseQueryTime(c, "from"); err != nil {
+		return nil, err
+	}
+	if req.To, err = parseQueryTime(c, "to"); err != nil {
+		return nil, err
+	}
+	if req.Limit, err = parseLimit(c); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// parseStatuses reads the comma separated statuses of the status query parameter
+func parseStatuses(c *fiber.Ctx) []entity.OrderStatus {
+	var statuses []entity.OrderStatus
+	for _, status := range strings.Split(c.Query("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses = append(statuses, entity.OrderStatus(status))
+		}
+	}
+	return statuses
+}
+
+// includesItems reports whether the include query parameter asks for order items
+func includesItems(c *fiber.Ctx) bool {
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		if strings.TrimSpace(include) == "items" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLimit reads the page size of the limit query parameter, 0 if it is absent
+func parseLimit(c *fiber.Ctx) (int, error) {
+	limit := c.Query("limit")
+	if limit == "" {
+		return 0, nil
+	}
+
+	value, err := strconv.Atoi(limit)
+	if err != nil {
+		return 0, errors.New("invalid limit")
+	}
+	return value, nil
+}
+
+// parseQueryTime reads a query parameter holding an RFC 3339 time or a date, nil if it is absent
+func parseQueryTime(c *fiber.Ctx, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, errors.New("invalid " + key + ", expected RFC 3339 or YYYY-MM-DD")
+}
+
+// orderSearchError maps an order search error to its HTTP response
+func orderSearchError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, entity.ErrInvalidOrderQuery) || errors.Is(err, entity.ErrInvalidCursor) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProductCSVHandler handles HTTP requests for product imports and exports
+type ProductCSVHandler struct {
+	productCSVUseCase *usecase.ProductCSVUseCase
+}
+
+// NewProductCSVHandler creates a new ProductCSVHandler
+func NewProductCSVHandler(productCSVUseCase *usecase.ProductCSVUseCase) *ProductCSVHandler {
+	return &ProductCSVHandler{
+		productCSVUseCase: productCSVUseCase,
+	}
+}
+
+// ImportProducts handles creating and updating products from a CSV file
+// @Summary Import products
+// @Description Create and update products and variants by SKU from a CSV upload (field "file") or a text/csv body (admin only)
+// @Description Returns a report with an error per invalid row, products with an invalid row are skipped
+// @Tags products
+// @Accept multipart/form-data
+// @Produce json
+// @Param atomic query bool false "Import nothing unless every row is valid"
+// @Param dry_run query bool false "Validate and report without writing"
+// @Success 200 {object} usecase.ProductImportReport
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/products/import [post]
+func (h *ProductCSVHandler) ImportProducts(c *fiber.Ctx) error {
+	var body io.Reader = bytes.NewReader(c.Body())
+
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid file upload",
+			})
+		}
+		defer file.Close()
+		body = file
+	}
+
+	report, err := h.productCSVUseCase.ImportProducts(c.Context(), &usecase.ProductImportRequest{
+		Data:    body,
+		Atomic:  c.QueryBool("atomic"),
+		DryRun:  c.QueryBool("dry_run"),
+		ActorID: c.Locals("user_id").(string),
+	})
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, entity.ErrInvalidImport):
+			status = fiber.StatusBadRequest
+		case errors.Is(err, entity.ErrVersionConflict):
+			// A product was edited while the atomic import ran
+			status = fiber.StatusConflict
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// ExportProducts handles streaming the products as a CSV file
+// @Summary Export products
+// @Description Stream the products, one row per variant, in the format accepted by the import (admin only)
+// @Tags products
+// @Produce text/csv
+// @Param status query string false "active or archived, every product if empty"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/products/export [get]
+func (h *ProductCSVHandler) ExportProducts(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	write, err := h.productCSVUseCase.ExportProducts(ctx, entity.ProductStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	filename := fmt.Sprintf("products-%s.csv", time.Now().Format(time.DateOnly))
+
+	c.Set(fiber.HeaderContentType, usecase.ExportFormatCSV.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	// The body is written after the handler returns, so a failure can only end the stream early
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := write(w); err != nil {
+			log.Printf("Failed to export products: %v", err)
+		}
+	})
+
+	return nil
+}
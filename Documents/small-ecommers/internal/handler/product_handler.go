@@ -1,6 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"small-ecommers/internal/domain/entity"
 	"small-ecommers/internal/usecase"
 
 	"github.com/gofiber/fiber/v2"
@@ -37,7 +42,7 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	}
 
 	// Validate request
-	if req.Name == "" || req.Price <= 0 {
+	if req.Name == "" || !req.Price.IsPositive() {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Name and valid price are required",
 		})
@@ -51,17 +56,29 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 
 	product, err := h.productUseCase.CreateProduct(c.Context(), &req)
 	if err != nil {
+		if errors.Is(err, entity.ErrInvalidCategory) || errors.Is(err, entity.ErrInvalidVariant) ||
+			errors.Is(err, entity.ErrInvalidAttribute) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, entity.ErrSKUExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setETag(c, product.Version)
 	return c.Status(fiber.StatusCreated).JSON(product)
 }
 
 // GetProduct handles getting a product by ID
 // @Summary Get product by ID
-// @Description Get a product by its ID
+// @Description Get a product by its ID, the ETag header holds the version to send as If-Match when updating it
 // @Tags products
 // @Produce json
 // @Param id path string true "Product ID"
@@ -83,37 +100,115 @@ func (h *ProductHandler) GetProduct(c *fiber.Ctx) error {
 		})
 	}
 
+	setETag(c, product.Version)
 	return c.JSON(product)
 }
 
-// ListProducts handles listing all products
-// @Summary List all products
-// @Description Get a list of all products
+// ListProducts handles listing a page of products
+// @Summary List products
+// @Description Get a page of products filtered by price, stock, name prefix and category
 // @Tags products
 // @Produce json
-// @Success 200 {array} entity.Product
+// @Param min_price query string false "Minimum price, a decimal in currency"
+// @Param max_price query string false "Maximum price, a decimal in currency"
+// @Param currency query string false "Currency of the price range, defaults to the store currency"
+// @Param in_stock query bool false "Only list products in stock"
+// @Param name_prefix query string false "Case insensitive name prefix"
+// @Param category query string false "Category ID or slug, including the categories below it"
+// @Param status query string false "active, other statuses are listed through /api/v1/admin/products"
+// @Param attr.{key} query string false "Attribute value products must have, such as attr.material=cotton, repeatable for several attributes"
+// @Param sort query string false "newest (default), price, -price, name or -name"
+// @Param limit query int false "Page size"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} usecase.ProductPage
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /api/v1/products [get]
 func (h *ProductHandler) ListProducts(c *fiber.Ctx) error {
-	products, err := h.productUseCase.ListProducts(c.Context())
+	if status := entity.ProductStatus(c.Query("status")); status != "" && status != entity.ProductStatusActive {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Forbidden - admin access required to list products that are not active",
+		})
+	}
+
+	return h.listProducts(c)
+}
+
+// ListAllProducts handles listing products of any status
+// @Summary List products of any status
+// @Description Get a page of products filtered like the public listing, including archived products (admin only)
+// @Tags products
+// @Produce json
+// @Param status query string false "active (default) or archived"
+// @Param limit query int false "Page size"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} usecase.ProductPage
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/products [get]
+func (h *ProductHandler) ListAllProducts(c *fiber.Ctx) error {
+	return h.listProducts(c)
+}
+
+// listProducts responds with the page of products selected by the query parameters
+func (h *ProductHandler) listProducts(c *fiber.Ctx) error {
+	limit, err := parseLimit(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	page, err := h.productUseCase.ListProducts(c.Context(), &usecase.ProductListRequest{
+		Currency:   c.Query("currency"),
+		MinPrice:   c.Query("min_price"),
+		MaxPrice:   c.Query("max_price"),
+		InStock:    c.QueryBool("in_stock"),
+		NamePrefix: c.Query("name_prefix"),
+		Category:   c.Query("category"),
+		Attributes: attributeFilters(c),
+		Status:     entity.ProductStatus(c.Query("status")),
+		Sort:       c.Query("sort"),
+		Cursor:     c.Query("cursor"),
+		Limit:      limit,
+	})
 	if err != nil {
+		if errors.Is(err, entity.ErrInvalidProductQuery) || errors.Is(err, entity.ErrInvalidCursor) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return c.JSON(products)
+	return c.JSON(page)
+}
+
+// attributeFilters collects the attr.{key} query parameters of a product listing
+func attributeFilters(c *fiber.Ctx) map[string]string {
+	filters := make(map[string]string)
+	for name, value := range c.Queries() {
+		if key, ok := strings.CutPrefix(name, "attr."); ok {
+			filters[key] = value
+		}
+	}
+	return filters
 }
 
 // UpdateProduct handles updating a product
 // @Summary Update a product
-// @Description Update an existing product
+// @Description Update an existing product, unless it changed since the version in If-Match
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param If-Match header string true "ETag of the product the update was made to, or *"
 // @Param request body usecase.UpdateProductRequest true "Update product request"
 // @Success 200 {object} entity.Product
 // @Failure 400 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /api/v1/products/{id} [put]
 func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -123,6 +218,11 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 		})
 	}
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
 	var req usecase.UpdateProductRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -131,7 +231,7 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 	}
 
 	// Validate price if provided
-	if req.Price != nil && *req.Price <= 0 {
+	if req.Price != nil && !req.Price.IsPositive() {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Price must be greater than 0",
 		})
@@ -144,37 +244,445 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	product, err := h.productUseCase.UpdateProduct(c.Context(), id, &req)
+	actorID := c.Locals("user_id").(string)
+
+	product, err := h.productUseCase.UpdateProduct(c.Context(), id, actorID, version, &req)
 	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return preconditionError(c, err)
+		}
+		if errors.Is(err, entity.ErrInvalidCategory) || errors.Is(err, entity.ErrInvalidVariant) ||
+			errors.Is(err, entity.ErrInvalidAttribute) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setETag(c, product.Version)
 	return c.JSON(product)
 }
 
-// DeleteProduct handles deleting a product
-// @Summary Delete a product
-// @Description Delete a product by ID
+// ArchiveProduct handles archiving a product
+// @Summary Archive a product
+// @Description Take a product out of the catalog, it remains available to existing orders and carts (admin only)
 // @Tags products
 // @Param id path string true "Product ID"
 // @Success 204
 // @Failure 404 {object} map[string]string
-// @Router /api/v1/products/{id} [delete]
-func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
-	id := c.Params("id")
-	if id == "" {
+// @Router /api/v1/admin/products/{id} [delete]
+func (h *ProductHandler) ArchiveProduct(c *fiber.Ctx) error {
+	if _, err := h.productUseCase.ArchiveProduct(c.Context(), c.Params("id")); err != nil {
+		return productStatusError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RestoreProduct handles putting an archived product back in the catalog
+// @Summary Restore a product
+// @Description Put an archived product back in the catalog (admin only)
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} entity.Product
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(c *fiber.Ctx) error {
+	product, err := h.productUseCase.RestoreProduct(c.Context(), c.Params("id"))
+	if err != nil {
+		return productStatusError(c, err)
+	}
+
+	setETag(c, product.Version)
+	return c.JSON(product)
+}
+
+// productStatusError responds with the status matching an error of archiving or restoring a product
+func productStatusError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, entity.ErrProductNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, entity.ErrVersionConflict):
+		status = fiber.StatusConflict
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// CreateVariant handles adding a variant to a product
+// @Summary Create a product variant
+// @Description Add a variant with its own SKU, option values, price override and stock (admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param request body usecase.CreateVariantRequest true "Create variant request"
+// @Success 201 {object} entity.Variant
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/admin/products/{id}/variants [post]
+func (h *ProductHandler) CreateVariant(c *fiber.Ctx) error {
+	var req usecase.CreateVariantRequest
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Product ID is required",
+			"error": "Invalid request body",
 		})
 	}
 
-	if err := h.productUseCase.DeleteProduct(c.Context(), id); err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
+	if req.Stock < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Stock cannot be negative",
+		})
+	}
+
+	variant, err := h.productUseCase.CreateVariant(c.Context(), c.Params("id"), &req)
+	if err != nil {
+		return variantError(c, err)
+	}
+
+	setETag(c, variant.Version)
+	return c.Status(fiber.StatusCreated).JSON(variant)
+}
+
+// UpdateVariant handles updating a product variant
+// @Summary Update a product variant
+// @Description Update the SKU, option values, price override or stock of a variant, unless it changed since the version in If-Match (admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variantId path string true "Variant ID"
+// @Param If-Match header string true "ETag of the variant the update was made to, or *"
+// @Param request body usecase.UpdateVariantRequest true "Update variant request"
+// @Success 200 {object} entity.Variant
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
+// @Router /api/v1/admin/products/{id}/variants/{variantId} [put]
+func (h *ProductHandler) UpdateVariant(c *fiber.Ctx) error {
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	var req usecase.UpdateVariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Stock != nil && *req.Stock < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Stock cannot be negative",
 		})
 	}
 
+	variant, err := h.productUseCase.UpdateVariant(c.Context(), c.Params("id"), c.Params("variantId"), version, &req)
+	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return preconditionError(c, err)
+		}
+		return variantError(c, err)
+	}
+
+	setETag(c, variant.Version)
+	return c.JSON(variant)
+}
+
+// DeleteVariant handles deleting a product variant
+// @Summary Delete a product variant
+// @Description Delete a variant other than the default variant of a product, unless orders, carts or subscriptions reference it (admin only)
+// @Tags products
+// @Param id path string true "Product ID"
+// @Param variantId path string true "Variant ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/admin/products/{id}/variants/{variantId} [delete]
+func (h *ProductHandler) DeleteVariant(c *fiber.Ctx) error {
+	if err := h.productUseCase.DeleteVariant(c.Context(), c.Params("id"), c.Params("variantId")); err != nil {
+		return variantError(c, err)
+	}
+
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// variantError responds with the status matching a variant error
+func variantError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, entity.ErrInvalidVariant), errors.Is(err, entity.ErrInvalidAmount),
+		errors.Is(err, entity.ErrInvalidCurrency), errors.Is(err, entity.ErrInsufficientStock):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, entity.ErrProductNotFound), errors.Is(err, entity.ErrVariantNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, entity.ErrSKUExists), errors.Is(err, entity.ErrVariantInUse):
+		status = fiber.StatusConflict
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// UploadImage handles uploading an image of a product
+// @Summary Upload a product image
+// @Description Upload a JPEG, PNG or GIF image, a thumbnail is generated from it (admin only)
+// @Tags products
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param image formData file true "Image file"
+// @Param alt_text formData string false "Alt text"
+// @Param position formData int false "Position to insert the image at, appended by default"
+// @Success 201 {object} entity.ProductImage
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /api/v1/admin/products/{id}/images [post]
+func (h *ProductHandler) UploadImage(c *fiber.Ctx) error {
+	header, err := c.FormFile("image")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Image file is required",
+		})
+	}
+
+	req := usecase.UploadImageRequest{
+		AltText: c.FormValue("alt_text"),
+	}
+
+	if value := c.FormValue("position"); value != "" {
+		position, err := strconv.Atoi(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid position",
+			})
+		}
+		req.Position = &position
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid image file",
+		})
+	}
+	defer file.Close()
+	req.Data = file
+
+	image, err := h.productUseCase.UploadImage(c.Context(), c.Params("id"), &req)
+	if err != nil {
+		return imageError(c, err)
+	}
+
+	setETag(c, image.Version)
+	return c.Status(fiber.StatusCreated).JSON(image)
+}
+
+// UpdateImage handles updating a product image
+// @Summary Update a product image
+// @Description Update the alt text of an image or move it to another position, unless it changed since the version in If-Match (admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param imageId path string true "Image ID"
+// @Param If-Match header string true "ETag of the image the update was made to, or *"
+// @Param request body usecase.UpdateImageRequest true "Update image request"
+// @Success 200 {object} entity.ProductImage
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
+// @Router /api/v1/admin/products/{id}/images/{imageId} [put]
+func (h *ProductHandler) UpdateImage(c *fiber.Ctx) error {
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	var req usecase.UpdateImageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	image, err := h.productUseCase.UpdateImage(c.Context(), c.Params("id"), c.Params("imageId"), version, &req)
+	if err != nil {
+		if errors.Is(err, entity.ErrVersionConflict) {
+			return preconditionError(c, err)
+		}
+		return imageError(c, err)
+	}
+
+	setETag(c, image.Version)
+	return c.JSON(image)
+}
+
+// DeleteImage handles deleting a product image
+// @Summary Delete a product image
+// @Description Delete an image and its files, the following images move up (admin only)
+// @Tags products
+// @Param id path string true "Product ID"
+// @Param imageId path string true "Image ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/products/{id}/images/{imageId} [delete]
+func (h *ProductHandler) DeleteImage(c *fiber.Ctx) error {
+	if err := h.productUseCase.DeleteImage(c.Context(), c.Params("id"), c.Params("imageId")); err != nil {
+		return imageError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// imageError responds with the status matching an image error
+func imageError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, entity.ErrInvalidImage):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, entity.ErrImageTooLarge):
+		status = fiber.StatusRequestEntityTooLarge
+	case errors.Is(err, entity.ErrProductNotFound), errors.Is(err, entity.ErrImageNotFound):
+		status = fiber.StatusNotFound
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// GetPriceHistory handles listing the price changes of a product
+// @Summary Get the price history of a product
+// @Description List the changes of the base price of a product, newest first, with who made them and how (admin only)
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param limit query int false "Page size"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} usecase.PriceHistoryPage
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/products/{id}/price-history [get]
+func (h *ProductHandler) GetPriceHistory(c *fiber.Ctx) error {
+	limit, err := parseLimit(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	page, err := h.productUseCase.GetPriceHistory(c.Context(), c.Params("id"), &usecase.PriceHistoryRequest{
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	})
+	if err != nil {
+		return priceError(c, err)
+	}
+
+	return c.JSON(page)
+}
+
+// ListScheduledPrices handles listing the scheduled prices of a product
+// @Summary List scheduled prices
+// @Description List the past and future scheduled prices of a product, by start (admin only)
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {array} entity.ScheduledPrice
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/products/{id}/scheduled-prices [get]
+func (h *ProductHandler) ListScheduledPrices(c *fiber.Ctx) error {
+	schedules, err := h.productUseCase.ListScheduledPrices(c.Context(), c.Params("id"))
+	if err != nil {
+		return priceError(c, err)
+	}
+
+	return c.JSON(schedules)
+}
+
+// SchedulePrice handles scheduling a future price of a product
+// @Summary Schedule a price
+// @Description Schedule a price to apply from starts_at, and until ends_at for a temporary price such as a sale (admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param request body usecase.SchedulePriceRequest true "Schedule price request"
+// @Success 201 {object} entity.ScheduledPrice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/products/{id}/scheduled-prices [post]
+func (h *ProductHandler) SchedulePrice(c *fiber.Ctx) error {
+	var req usecase.SchedulePriceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	actorID := c.Locals("user_id").(string)
+
+	scheduled, err := h.productUseCase.SchedulePrice(c.Context(), c.Params("id"), actorID, &req)
+	if err != nil {
+		return priceError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(scheduled)
+}
+
+// CancelScheduledPrice handles cancelling a scheduled price
+// @Summary Cancel a scheduled price
+// @Description Cancel a scheduled price, one that already started ends at once and the price it replaced applies again (admin only)
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param scheduleId path string true "Scheduled price ID"
+// @Success 200 {object} entity.ScheduledPrice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/admin/products/{id}/scheduled-prices/{scheduleId} [delete]
+func (h *ProductHandler) CancelScheduledPrice(c *fiber.Ctx) error {
+	actorID := c.Locals("user_id").(string)
+
+	scheduled, err := h.productUseCase.CancelScheduledPrice(c.Context(), c.Params("id"), c.Params("scheduleId"), actorID)
+	if err != nil {
+		return priceError(c, err)
+	}
+
+	return c.JSON(scheduled)
+}
+
+// priceError responds with the status matching a price history or scheduled price error
+func priceError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, entity.ErrInvalidScheduledPrice), errors.Is(err, entity.ErrInvalidAmount),
+		errors.Is(err, entity.ErrInvalidCurrency), errors.Is(err, entity.ErrInvalidProductQuery),
+		errors.Is(err, entity.ErrInvalidCursor):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, entity.ErrProductNotFound), errors.Is(err, entity.ErrScheduledPriceNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, entity.ErrScheduledPriceChanged):
+		status = fiber.StatusConflict
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
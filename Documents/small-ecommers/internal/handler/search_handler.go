@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SearchHandler handles HTTP requests for product search
+type SearchHandler struct {
+	searchUseCase *usecase.SearchUseCase
+}
+
+// NewSearchHandler creates a new SearchHandler
+func NewSearchHandler(searchUseCase *usecase.SearchUseCase) *SearchHandler {
+	return &SearchHandler{
+		searchUseCase: searchUseCase,
+	}
+}
+
+// SearchProducts handles searching products by text
+// @Summary Search products
+// @Description Full-text search over product names and descriptions, best match first
+// @Description Each word matches as a prefix, and names similar to the query are returned when nothing matches
+// @Tags products
+// @Produce json
+// @Param q query string true "Search text"
+// @Param limit query int false "Page size"
+// @Param offset query int false "Number of matches to skip"
+// @Success 200 {object} usecase.ProductSearchPage
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/products/search [get]
+func (h *SearchHandler) SearchProducts(c *fiber.Ctx) error {
+	limit, err := parseLimit(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	offset := 0
+	if value := c.Query("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid offset",
+			})
+		}
+	}
+
+	page, err := h.searchUseCase.SearchProducts(c.Context(), &usecase.SearchProductsRequest{
+		Query:  c.Query("q"),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidProductQuery) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(page)
+}
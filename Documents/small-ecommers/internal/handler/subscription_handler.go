@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SubscriptionHandler handles HTTP requests for subscription operations
+type SubscriptionHandler struct {
+	subscriptionUseCase *usecase.SubscriptionUseCase
+}
+
+// NewSubscriptionHandler creates a new SubscriptionHandler
+func NewSubscriptionHandler(subscriptionUseCase *usecase.SubscriptionUseCase) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		subscriptionUseCase: subscriptionUseCase,
+	}
+}
+
+// CreateSubscription handles creating a new subscription
+// @Summary Create subscription
+// @Description Subscribe to a recurring delivery of products every N weeks
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body usecase.CreateSubscriptionRequest true "Create subscription request"
+// @Success 201 {object} entity.Subscription
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/subscriptions [post]
+func (h *SubscriptionHandler) CreateSubscription(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req usecase.CreateSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	subscription, err := h.subscriptionUseCase.CreateSubscription(c.Context(), userID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(subscription)
+}
+
+// GetUserSubscriptions handles getting all subscriptions for a user
+// @Summary Get user's subscriptions
+// @Description Get all subscriptions of the authenticated user
+// @Tags subscriptions
+// @Produce json
+// @Success 200 {array} entity.Subscription
+// @Router /api/v1/subscriptions [get]
+func (h *SubscriptionHandler) GetUserSubscriptions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	subscriptions, err := h.subscriptionUseCase.GetUserSubscriptions(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(subscriptions)
+}
+
+// GetSubscription handles getting a subscription by ID
+// @Summary Get subscription by ID
+// @Description Get a subscription of the authenticated user
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} entity.Subscription
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/subscriptions/{id} [get]
+func (h *SubscriptionHandler) GetSubscription(c *fiber.Ctx) error {
+	return h.respond(c, h.subscriptionUseCase.GetSubscription)
+}
+
+// PauseSubscription handles pausing a subscription
+// @Summary Pause subscription
+// @Description Pause deliveries of an active subscription
+// @Tags subscriptions
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} entity.Subscription
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) PauseSubscription(c *fiber.Ctx) error {
+	return h.respond(c, h.subscriptionUseCase.PauseSubscription)
+}
+
+// ResumeSubscription handles resuming a subscription
+// @Summary Resume subscription
+// @Description Resume deliveries of a paused subscription
+// @Tags subscriptions
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} entity.Subscription
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) ResumeSubscription(c *fiber.Ctx) error {
+	return h.respond(c, h.subscriptionUseCase.ResumeSubscription)
+}
+
+// CancelSubscription handles cancelling a subscription
+// @Summary Cancel subscription
+// @Description Stop all future deliveries of a subscription
+// @Tags subscriptions
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} entity.Subscription
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) CancelSubscription(c *fiber.Ctx) error {
+	return h.respond(c, h.subscriptionUseCase.CancelSubscription)
+}
+
+// respond runs a use case on the subscription in the path and writes the result
+func (h *SubscriptionHandler) respond(c *fiber.Ctx, action func(ctx context.Context, id, userID string) (*entity.Subscription, error)) error {
+	userID := c.Locals("user_id").(string)
+
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Subscription ID is required",
+		})
+	}
+
+	subscription, err := action(c.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, entity.ErrSubscriptionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(subscription)
+}
@@ -56,7 +56,8 @@ func NewPostgresConnection(cfg *Config) (*sql.DB, error) {
 }
 
 // Migrate runs database migrations
-func Migrate(db *sql.DB) error {
+// Rows created before amounts carried a currency are assigned defaultCurrency
+func Migrate(db *sql.DB, defaultCurrency string) error {
 	// Create users table
 	if _, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS users (
@@ -142,6 +143,311 @@ func Migrate(db *sql.DB) error {
 		return fmt.Errorf("failed to create order_items table: %w", err)
 	}
 
+	// Add itemised order total columns
+	if _, err := db.Exec(`
+		ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS subtotal DECIMAL(10, 2) NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS line_discount_total DECIMAL(10, 2) NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS order_discount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS tax_total DECIMAL(10, 2) NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS shipping_total DECIMAL(10, 2) NOT NULL DEFAULT 0
+	`); err != nil {
+		return fmt.Errorf("failed to add breakdown columns to orders table: %w", err)
+	}
+
+	// Orders placed before the breakdown existed were a plain sum of their lines
+	if _, err := db.Exec(`UPDATE orders SET subtotal = total WHERE subtotal = 0 AND total <> 0`); err != nil {
+		return fmt.Errorf("failed to backfill orders.subtotal: %w", err)
+	}
+
+	if _, err := db.Exec(`
+		ALTER TABLE order_items
+			ADD COLUMN IF NOT EXISTS discount DECIMAL(10, 2) NOT NULL DEFAULT 0
+	`); err != nil {
+		return fmt.Errorf("failed to add discount column to order_items table: %w", err)
+	}
+
+	// Add currency columns alongside every monetary amount
+	for _, table := range []string{"products", "carts", "cart_items", "orders"} {
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS currency VARCHAR(3)`, table)); err != nil {
+			return fmt.Errorf("failed to add currency column to %s table: %w", table, err)
+		}
+
+		if _, err := db.Exec(fmt.Sprintf(`UPDATE %s SET currency = $1 WHERE currency IS NULL`, table), defaultCurrency); err != nil {
+			return fmt.Errorf("failed to backfill %s.currency: %w", table, err)
+		}
+
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN currency SET NOT NULL`, table)); err != nil {
+			return fmt.Errorf("failed to require %s.currency: %w", table, err)
+		}
+	}
+
+	// Create product_prices table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS product_prices (
+			product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			currency VARCHAR(3) NOT NULL,
+			amount DECIMAL(10, 2) NOT NULL,
+			PRIMARY KEY (product_id, currency)
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create product_prices table: %w", err)
+	}
+
+	// Create exchange_rates table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS exchange_rates (
+			base_currency VARCHAR(3) NOT NULL,
+			quote_currency VARCHAR(3) NOT NULL,
+			rate NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (base_currency, quote_currency)
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create exchange_rates table: %w", err)
+	}
+
+	// Add the exchange rate locked at checkout to orders
+	if _, err := db.Exec(`
+		ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS exchange_base_currency VARCHAR(3),
+			ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(18, 8),
+			ADD COLUMN IF NOT EXISTS exchange_rate_updated_at TIMESTAMP
+	`); err != nil {
+		return fmt.Errorf("failed to add exchange rate columns to orders table: %w", err)
+	}
+
+	// Create invoice_sequences table, one counter row per calendar year
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS invoice_sequences (
+			year INTEGER PRIMARY KEY,
+			last_number INTEGER NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create invoice_sequences table: %w", err)
+	}
+
+	// Create invoices table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS invoices (
+			id VARCHAR(36) PRIMARY KEY,
+			order_id VARCHAR(36) NOT NULL UNIQUE REFERENCES orders(id),
+			number VARCHAR(32) NOT NULL UNIQUE,
+			year INTEGER NOT NULL,
+			sequence INTEGER NOT NULL,
+			storage_key VARCHAR(255) NOT NULL,
+			issued_at TIMESTAMP NOT NULL,
+			UNIQUE(year, sequence)
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create invoices table: %w", err)
+	}
+
+	// Add stock policy columns to products
+	if _, err := db.Exec(`
+		ALTER TABLE products
+			ADD COLUMN IF NOT EXISTS stock_policy VARCHAR(20) NOT NULL DEFAULT 'deny',
+			ADD COLUMN IF NOT EXISTS backorder_limit INTEGER,
+			ADD COLUMN IF NOT EXISTS backordered INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS available_at TIMESTAMP
+	`); err != nil {
+		return fmt.Errorf("failed to add stock policy columns to products table: %w", err)
+	}
+
+	// Add backorder columns to order_items
+	if _, err := db.Exec(`
+		ALTER TABLE order_items
+			ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'allocated',
+			ADD COLUMN IF NOT EXISTS backordered_quantity INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS expected_at TIMESTAMP
+	`); err != nil {
+		return fmt.Errorf("failed to add backorder columns to order_items table: %w", err)
+	}
+
+	// Create subscriptions table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS subscriptions (
+			id VARCHAR(36) PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			interval_weeks INTEGER NOT NULL CHECK (interval_weeks > 0),
+			status VARCHAR(20) NOT NULL DEFAULT 'active',
+			next_run_at TIMESTAMP NOT NULL,
+			last_run_at TIMESTAMP,
+			last_order_id VARCHAR(36),
+			failure_count INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create subscriptions table: %w", err)
+	}
+
+	// Create subscription_items table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS subscription_items (
+			id VARCHAR(36) PRIMARY KEY,
+			subscription_id VARCHAR(36) NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+			product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			quantity INTEGER NOT NULL CHECK (quantity > 0),
+			UNIQUE(subscription_id, product_id)
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create subscription_items table: %w", err)
+	}
+
+	// Create gift_cards table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS gift_cards (
+			id VARCHAR(36) PRIMARY KEY,
+			code VARCHAR(32) NOT NULL UNIQUE,
+			currency VARCHAR(3) NOT NULL,
+			initial_balance DECIMAL(10, 2) NOT NULL CHECK (initial_balance > 0),
+			balance DECIMAL(10, 2) NOT NULL CHECK (balance >= 0),
+			expires_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create gift_cards table: %w", err)
+	}
+
+	// Create store_credit_accounts table, one balance per user and currency
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS store_credit_accounts (
+			user_id VARCHAR(36) NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			balance DECIMAL(10, 2) NOT NULL CHECK (balance >= 0),
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, currency)
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create store_credit_accounts table: %w", err)
+	}
+
+	// Create ledger_entries table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ledger_entries (
+			id VARCHAR(36) PRIMARY KEY,
+			account_type VARCHAR(20) NOT NULL,
+			account_id VARCHAR(36) NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			amount DECIMAL(10, 2) NOT NULL CHECK (amount <> 0),
+			balance_after DECIMAL(10, 2) NOT NULL CHECK (balance_after >= 0),
+			reason VARCHAR(20) NOT NULL,
+			order_id VARCHAR(36) REFERENCES orders(id),
+			note TEXT,
+			created_at TIMESTAMP NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create ledger_entries table: %w", err)
+	}
+
+	// Ledger entries are an audit trail, reject any attempt to rewrite them
+	if _, err := db.Exec(`
+		CREATE OR REPLACE FUNCTION reject_ledger_change() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'ledger entries are immutable';
+		END;
+		$$ LANGUAGE plpgsql
+	`); err != nil {
+		return fmt.Errorf("failed to create reject_ledger_change function: %w", err)
+	}
+
+	if _, err := db.Exec(`
+		DROP TRIGGER IF EXISTS ledger_entries_immutable ON ledger_entries;
+		CREATE TRIGGER ledger_entries_immutable
+			BEFORE UPDATE OR DELETE ON ledger_entries
+			FOR EACH ROW EXECUTE FUNCTION reject_ledger_change()
+	`); err != nil {
+		return fmt.Errorf("failed to create ledger_entries trigger: %w", err)
+	}
+
+	// Add gateway payment and refund columns to orders
+	if _, err := db.Exec(`
+		ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS payment_reference VARCHAR(255),
+			ADD COLUMN IF NOT EXISTS refunded_total DECIMAL(10, 2) NOT NULL DEFAULT 0
+	`); err != nil {
+		return fmt.Errorf("failed to add payment columns to orders table: %w", err)
+	}
+
+	// Create loyalty_entries table, seq keeps the order entries were posted in
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS loyalty_entries (
+			id VARCHAR(36) PRIMARY KEY,
+			seq BIGSERIAL NOT NULL UNIQUE,
+			user_id VARCHAR(36) NOT NULL,
+			points BIGINT NOT NULL CHECK (points <> 0),
+			reason VARCHAR(20) NOT NULL,
+			order_id VARCHAR(36) REFERENCES orders(id),
+			expires_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create loyalty_entries table: %w", err)
+	}
+
+	if _, err := db.Exec(`
+		DROP TRIGGER IF EXISTS loyalty_entries_immutable ON loyalty_entries;
+		CREATE TRIGGER loyalty_entries_immutable
+			BEFORE UPDATE OR DELETE ON loyalty_entries
+			FOR EACH ROW EXECUTE FUNCTION reject_ledger_change()
+	`); err != nil {
+		return fmt.Errorf("failed to create loyalty_entries trigger: %w", err)
+	}
+
+	// Add loyalty points redemption columns to orders
+	if _, err := db.Exec(`
+		ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS points_discount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS points_redeemed BIGINT NOT NULL DEFAULT 0
+	`); err != nil {
+		return fmt.Errorf("failed to add loyalty columns to orders table: %w", err)
+	}
+
+	// Add the addresses and client IP captured at checkout to orders
+	if _, err := db.Exec(`
+		ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS shipping_address JSONB,
+			ADD COLUMN IF NOT EXISTS billing_address JSONB,
+			ADD COLUMN IF NOT EXISTS client_ip VARCHAR(45)
+	`); err != nil {
+		return fmt.Errorf("failed to add checkout columns to orders table: %w", err)
+	}
+
+	// Create fraud_screenings table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS fraud_screenings (
+			id VARCHAR(36) PRIMARY KEY,
+			order_id VARCHAR(36) NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			score INTEGER NOT NULL,
+			outcome VARCHAR(20) NOT NULL,
+			reviewed_by VARCHAR(36),
+			review_decision VARCHAR(20),
+			review_note TEXT,
+			reviewed_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create fraud_screenings table: %w", err)
+	}
+
+	// Create fraud_rule_hits table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS fraud_rule_hits (
+			screening_id VARCHAR(36) NOT NULL REFERENCES fraud_screenings(id) ON DELETE CASCADE,
+			position INTEGER NOT NULL,
+			rule VARCHAR(50) NOT NULL,
+			score INTEGER NOT NULL,
+			reason TEXT NOT NULL,
+			PRIMARY KEY (screening_id, position)
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create fraud_rule_hits table: %w", err)
+	}
+
 	// Create indexes
 	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_users_email ON users(email)`); err != nil {
 		return fmt.Errorf("failed to create index on users.email: %w", err)
@@ -163,6 +469,423 @@ func Migrate(db *sql.DB) error {
 		return fmt.Errorf("failed to create index on order_items.order_id: %w", err)
 	}
 
+	if _, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_order_items_backordered
+		ON order_items(product_id, created_at) WHERE backordered_quantity > 0
+	`); err != nil {
+		return fmt.Errorf("failed to create index on backordered order_items: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON subscriptions(user_id)`); err != nil {
+		return fmt.Errorf("failed to create index on subscriptions.user_id: %w", err)
+	}
+
+	if _, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_subscriptions_due
+		ON subscriptions(next_run_at) WHERE status = 'active'
+	`); err != nil {
+		return fmt.Errorf("failed to create index on due subscriptions: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries(account_type, account_id, created_at)`); err != nil {
+		return fmt.Errorf("failed to create index on ledger_entries account: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_ledger_entries_order_id ON ledger_entries(order_id)`); err != nil {
+		return fmt.Errorf("failed to create index on ledger_entries.order_id: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_loyalty_entries_user_id ON loyalty_entries(user_id, seq)`); err != nil {
+		return fmt.Errorf("failed to create index on loyalty_entries.user_id: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_orders_client_ip ON orders(client_ip, created_at)`); err != nil {
+		return fmt.Errorf("failed to create index on orders.client_ip: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_fraud_screenings_order_id ON fraud_screenings(order_id, created_at)`); err != nil {
+		return fmt.Errorf("failed to create index on fraud_screenings.order_id: %w", err)
+	}
+
+	// Keyset indexes for the admin order search end with id, which breaks ties between equal sort keys
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at, id)`); err != nil {
+		return fmt.Errorf("failed to create index on orders.created_at: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_orders_total ON orders(total, id)`); err != nil {
+		return fmt.Errorf("failed to create index on orders.total: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_orders_status_created_at ON orders(status, created_at, id)`); err != nil {
+		return fmt.Errorf("failed to create index on orders.status, created_at: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_orders_user_id_created_at ON orders(user_id, created_at, id)`); err != nil {
+		return fmt.Errorf("failed to create index on orders.user_id, created_at: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items(product_id, order_id)`); err != nil {
+		return fmt.Errorf("failed to create index on order_items.product_id: %w", err)
+	}
+
+	// Keyset indexes for the product listing, one per sort
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_created_at ON products(created_at, id)`); err != nil {
+		return fmt.Errorf("failed to create index on products.created_at: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_price ON products(price, id)`); err != nil {
+		return fmt.Errorf("failed to create index on products.price: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_name ON products(name, id)`); err != nil {
+		return fmt.Errorf("failed to create index on products.name: %w", err)
+	}
+
+	// text_pattern_ops lets LIKE prefix searches use the index whatever the collation
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_lower_name ON products(lower(name) text_pattern_ops)`); err != nil {
+		return fmt.Errorf("failed to create index on lower(products.name): %w", err)
+	}
+
+	// Full-text search over product names and descriptions, names weighing more
+	if _, err := db.Exec(`
+		ALTER TABLE products
+			ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+				setweight(to_tsvector('english', name), 'A') ||
+				setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+			) STORED
+	`); err != nil {
+		return fmt.Errorf("failed to add search_vector column to products table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)`); err != nil {
+		return fmt.Errorf("failed to create index on products.search_vector: %w", err)
+	}
+
+	// Trigram matching lets searches with typos still find products by name
+	if _, err := db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`); err != nil {
+		return fmt.Errorf("failed to create pg_trgm extension: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops)`); err != nil {
+		return fmt.Errorf("failed to create trigram index on products.name: %w", err)
+	}
+
+	// Create categories table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS categories (
+			id VARCHAR(36) PRIMARY KEY,
+			parent_id VARCHAR(36) REFERENCES categories(id),
+			name VARCHAR(255) NOT NULL,
+			slug VARCHAR(100) NOT NULL UNIQUE,
+			position INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create categories table: %w", err)
+	}
+
+	// Create product_categories table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS product_categories (
+			product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			category_id VARCHAR(36) NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+			PRIMARY KEY (product_id, category_id)
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create product_categories table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id)`); err != nil {
+		return fmt.Errorf("failed to create index on categories.parent_id: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_product_categories_category_id ON product_categories(category_id, product_id)`); err != nil {
+		return fmt.Errorf("failed to create index on product_categories.category_id: %w", err)
+	}
+
+	// Add options column to products
+	if _, err := db.Exec(`ALTER TABLE products ADD COLUMN IF NOT EXISTS options JSONB NOT NULL DEFAULT '[]'`); err != nil {
+		return fmt.Errorf("failed to add options column to products table: %w", err)
+	}
+
+	// Create product_variants table
+	// The stock and backordered columns of products are superseded by those of the variants
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS product_variants (
+			id VARCHAR(36) PRIMARY KEY,
+			product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			sku VARCHAR(64) NOT NULL UNIQUE,
+			options JSONB NOT NULL DEFAULT '{}',
+			price DECIMAL(10, 2),
+			currency VARCHAR(3),
+			stock INTEGER NOT NULL DEFAULT 0,
+			backordered INTEGER NOT NULL DEFAULT 0,
+			is_default BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create product_variants table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants(product_id)`); err != nil {
+		return fmt.Errorf("failed to create index on product_variants.product_id: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_default ON product_variants(product_id) WHERE is_default`); err != nil {
+		return fmt.Errorf("failed to create index on default product_variants: %w", err)
+	}
+
+	// Give products without variants a default variant holding their stock
+	// It shares the product ID, so items referring to the product refer to it too
+	if _, err := db.Exec(`
+		INSERT INTO product_variants (id, product_id, sku, stock, backordered, is_default, created_at, updated_at)
+		SELECT p.id, p.id, p.id, p.stock, p.backordered, TRUE, p.created_at, p.updated_at
+		FROM products p
+		WHERE NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+	`); err != nil {
+		return fmt.Errorf("failed to create default product variants: %w", err)
+	}
+
+	// Cart items refer to a variant, existing items to the default variant of their product
+	if _, err := db.Exec(`
+		ALTER TABLE cart_items
+			ADD COLUMN IF NOT EXISTS variant_id VARCHAR(36) REFERENCES product_variants(id) ON DELETE CASCADE
+	`); err != nil {
+		return fmt.Errorf("failed to add variant_id column to cart_items table: %w", err)
+	}
+
+	if _, err := db.Exec(`UPDATE cart_items SET variant_id = product_id WHERE variant_id IS NULL`); err != nil {
+		return fmt.Errorf("failed to set variant_id of cart_items: %w", err)
+	}
+
+	if _, err := db.Exec(`ALTER TABLE cart_items ALTER COLUMN variant_id SET NOT NULL`); err != nil {
+		return fmt.Errorf("failed to require variant_id of cart_items: %w", err)
+	}
+
+	if _, err := db.Exec(`ALTER TABLE cart_items DROP CONSTRAINT IF EXISTS cart_items_cart_id_product_id_key`); err != nil {
+		return fmt.Errorf("failed to drop unique product constraint of cart_items: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_items_cart_id_variant_id ON cart_items(cart_id, variant_id)`); err != nil {
+		return fmt.Errorf("failed to create index on cart_items.variant_id: %w", err)
+	}
+
+	// Order items refer to a variant, existing items to the default variant of their product
+	if _, err := db.Exec(`ALTER TABLE order_items ADD COLUMN IF NOT EXISTS variant_id VARCHAR(36)`); err != nil {
+		return fmt.Errorf("failed to add variant_id column to order_items table: %w", err)
+	}
+
+	if _, err := db.Exec(`UPDATE order_items SET variant_id = product_id WHERE variant_id IS NULL`); err != nil {
+		return fmt.Errorf("failed to set variant_id of order_items: %w", err)
+	}
+
+	if _, err := db.Exec(`ALTER TABLE order_items ALTER COLUMN variant_id SET NOT NULL`); err != nil {
+		return fmt.Errorf("failed to require variant_id of order_items: %w", err)
+	}
+
+	if _, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_order_items_variant_backordered
+		ON order_items(variant_id, created_at) WHERE backordered_quantity > 0
+	`); err != nil {
+		return fmt.Errorf("failed to create index on backordered order_items variants: %w", err)
+	}
+
+	// Create product_images table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS product_images (
+			id VARCHAR(36) PRIMARY KEY,
+			product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			position INTEGER NOT NULL DEFAULT 0,
+			alt_text TEXT NOT NULL DEFAULT '',
+			content_type VARCHAR(64) NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			size BIGINT NOT NULL,
+			storage_key VARCHAR(255) NOT NULL,
+			url TEXT NOT NULL,
+			thumbnail_key VARCHAR(255) NOT NULL,
+			thumbnail_url TEXT NOT NULL,
+			thumbnail_width INTEGER NOT NULL,
+			thumbnail_height INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create product_images table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_product_images_product_id_position ON product_images(product_id, position)`); err != nil {
+		return fmt.Errorf("failed to create index on product_images.product_id: %w", err)
+	}
+
+	// Add lifecycle columns to products, products are archived instead of deleted
+	if _, err := db.Exec(`
+		ALTER TABLE products
+			ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'active',
+			ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP
+	`); err != nil {
+		return fmt.Errorf("failed to add lifecycle columns to products table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_status ON products(status)`); err != nil {
+		return fmt.Errorf("failed to create index on products.status: %w", err)
+	}
+
+	// Refuse to delete products still in carts instead of silently removing them from the carts
+	if _, err := db.Exec(`
+		ALTER TABLE cart_items
+			DROP CONSTRAINT IF EXISTS cart_items_product_id_fkey,
+			ADD CONSTRAINT cart_items_product_id_fkey
+				FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE RESTRICT
+	`); err != nil {
+		return fmt.Errorf("failed to restrict product deletion for cart_items: %w", err)
+	}
+
+	// Refuse to delete ordered products
+	// Not validated, order items of products deleted before archiving existed are left as they are
+	if _, err := db.Exec(`
+		ALTER TABLE order_items
+			DROP CONSTRAINT IF EXISTS order_items_product_id_fkey,
+			ADD CONSTRAINT order_items_product_id_fkey
+				FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE RESTRICT NOT VALID
+	`); err != nil {
+		return fmt.Errorf("failed to restrict product deletion for order_items: %w", err)
+	}
+
+	// Create price_changes table, the history of product prices
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS price_changes (
+			id VARCHAR(36) PRIMARY KEY,
+			product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			old_price DECIMAL(10, 2) NOT NULL,
+			old_currency VARCHAR(3) NOT NULL,
+			new_price DECIMAL(10, 2) NOT NULL,
+			new_currency VARCHAR(3) NOT NULL,
+			source VARCHAR(16) NOT NULL,
+			actor_id VARCHAR(36),
+			scheduled_price_id VARCHAR(36),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create price_changes table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_price_changes_product_id_created_at ON price_changes(product_id, created_at DESC, id DESC)`); err != nil {
+		return fmt.Errorf("failed to create index on price_changes.product_id: %w", err)
+	}
+
+	// Create scheduled_prices table
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS scheduled_prices (
+			id VARCHAR(36) PRIMARY KEY,
+			product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			price DECIMAL(10, 2) NOT NULL CHECK (price > 0),
+			currency VARCHAR(3) NOT NULL,
+			starts_at TIMESTAMP NOT NULL,
+			ends_at TIMESTAMP CHECK (ends_at > starts_at),
+			status VARCHAR(16) NOT NULL DEFAULT 'pending',
+			previous_price DECIMAL(10, 2),
+			previous_currency VARCHAR(3),
+			created_by VARCHAR(36) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create scheduled_prices table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_scheduled_prices_product_id ON scheduled_prices(product_id, starts_at)`); err != nil {
+		return fmt.Errorf("failed to create index on scheduled_prices.product_id: %w", err)
+	}
+
+	// Only pending and active scheduled prices are polled by the price scheduler
+	if _, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_scheduled_prices_due
+		ON scheduled_prices(status, starts_at, ends_at) WHERE status IN ('pending', 'active')
+	`); err != nil {
+		return fmt.Errorf("failed to create index on due scheduled_prices: %w", err)
+	}
+
+	// Version columns for optimistic concurrency control, every update increments them
+	for _, table := range []string{"products", "orders", "carts", "product_variants", "product_images"} {
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1`, table)); err != nil {
+			return fmt.Errorf("failed to add version column to %s table: %w", table, err)
+		}
+	}
+
+	// Attribute schemas of categories and attribute values of products
+	if _, err := db.Exec(`ALTER TABLE categories ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '[]'`); err != nil {
+		return fmt.Errorf("failed to add attributes column to categories table: %w", err)
+	}
+
+	if _, err := db.Exec(`ALTER TABLE products ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}'`); err != nil {
+		return fmt.Errorf("failed to add attributes column to products table: %w", err)
+	}
+
+	// jsonb_path_ops keeps the index small, it only serves the containment filters of product listings
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_attributes ON products USING GIN (attributes jsonb_path_ops)`); err != nil {
+		return fmt.Errorf("failed to create index on products.attributes: %w", err)
+	}
+
+	// Keep variants that carts or orders still reference, cart items used to be deleted with their variant
+	if _, err := db.Exec(`
+		DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'cart_items_variant_id_fkey' AND confdeltype = 'c') THEN
+				ALTER TABLE cart_items
+					DROP CONSTRAINT cart_items_variant_id_fkey,
+					ADD CONSTRAINT cart_items_variant_id_fkey FOREIGN KEY (variant_id) REFERENCES product_variants(id);
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'order_items_variant_id_fkey') THEN
+				-- Not validated, order items of variants deleted before may remain
+				ALTER TABLE order_items
+					ADD CONSTRAINT order_items_variant_id_fkey FOREIGN KEY (variant_id) REFERENCES product_variants(id) NOT VALID;
+			END IF;
+		END $$
+	`); err != nil {
+		return fmt.Errorf("failed to restrict deleting referenced variants: %w", err)
+	}
+
+	// Subscriptions deliver a variant, those created before always delivered the default variant
+	if _, err := db.Exec(`
+		ALTER TABLE subscription_items
+			ADD COLUMN IF NOT EXISTS variant_id VARCHAR(36) REFERENCES product_variants(id)
+	`); err != nil {
+		return fmt.Errorf("failed to add variant_id column to subscription_items table: %w", err)
+	}
+
+	if _, err := db.Exec(`UPDATE subscription_items SET variant_id = product_id WHERE variant_id IS NULL`); err != nil {
+		return fmt.Errorf("failed to set variant_id of subscription_items: %w", err)
+	}
+
+	if _, err := db.Exec(`ALTER TABLE subscription_items ALTER COLUMN variant_id SET NOT NULL`); err != nil {
+		return fmt.Errorf("failed to require variant_id of subscription_items: %w", err)
+	}
+
+	if _, err := db.Exec(`ALTER TABLE subscription_items DROP CONSTRAINT IF EXISTS subscription_items_subscription_id_product_id_key`); err != nil {
+		return fmt.Errorf("failed to drop unique product constraint of subscription_items: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_subscription_items_subscription_id_variant_id ON subscription_items(subscription_id, variant_id)`); err != nil {
+		return fmt.Errorf("failed to create index on subscription_items.variant_id: %w", err)
+	}
+
+	// Screenings keep the address the order was placed from, orders no longer show it to customers
+	if _, err := db.Exec(`ALTER TABLE fraud_screenings ADD COLUMN IF NOT EXISTS client_ip VARCHAR(45)`); err != nil {
+		return fmt.Errorf("failed to add client_ip column to fraud_screenings table: %w", err)
+	}
+
+	if _, err := db.Exec(`
+		UPDATE fraud_screenings fs
+		SET client_ip = o.client_ip
+		FROM orders o
+		WHERE o.id = fs.order_id AND fs.client_ip IS NULL AND o.client_ip IS NOT NULL
+	`); err != nil {
+		return fmt.Errorf("failed to set client_ip of fraud_screenings: %w", err)
+	}
+
 	log.Println("Database migrations completed successfully")
 
 	return nil
@@ -0,0 +1,50 @@
+package email
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"small-ecommers/internal/usecase"
+)
+
+// CaptureMailer implements Mailer by keeping messages in memory instead of sending them
+// It is used in development and tests to inspect what customers would receive
+type CaptureMailer struct {
+	mu       sync.Mutex
+	messages []usecase.EmailMessage
+}
+
+// NewCaptureMailer creates a new capturing mailer
+func NewCaptureMailer() *CaptureMailer {
+	return &CaptureMailer{}
+}
+
+// Send records the message
+func (m *CaptureMailer) Send(ctx context.Context, msg *usecase.EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, *msg)
+	log.Printf("Captured email to %s: %s", msg.To, msg.Subject)
+
+	return nil
+}
+
+// Messages returns a copy of the messages captured so far, oldest first
+func (m *CaptureMailer) Messages() []usecase.EmailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make([]usecase.EmailMessage, len(m.messages))
+	copy(messages, m.messages)
+	return messages
+}
+
+// Reset discards the captured messages
+func (m *CaptureMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
@@ -0,0 +1,71 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"small-ecommers/internal/usecase"
+)
+
+// SMTPMailer implements Mailer by relaying mail through an SMTP server
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a new SMTP mailer
+// Authentication is skipped when username is empty
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(host, port),
+		from: from,
+		auth: auth,
+	}
+}
+
+// Send delivers a message to the SMTP server
+func (m *SMTPMailer) Send(ctx context.Context, msg *usecase.EmailMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return fmt.Errorf("invalid recipient: %q", msg.To)
+	}
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, m.build(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// build formats a message with its headers
+func (m *SMTPMailer) build(msg *usecase.EmailMessage) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", m.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+
+	return buf.Bytes()
+}
@@ -0,0 +1,33 @@
+package payment
+
+import (
+	"context"
+	"log"
+
+	"small-ecommers/internal/usecase"
+)
+
+// ManualGateway implements PaymentGateway for payments collected outside the system
+// Every charge is approved and logged so it can be reconciled by hand
+type ManualGateway struct{}
+
+// NewManualGateway creates a new manual payment gateway
+func NewManualGateway() *ManualGateway {
+	return &ManualGateway{}
+}
+
+// Charge approves the charge, using the idempotency key as its reference
+func (g *ManualGateway) Charge(ctx context.Context, req *usecase.ChargeRequest) (*usecase.ChargeResult, error) {
+	log.Printf("Manual charge of %s for order %s (user %s)", req.Amount, req.OrderID, req.UserID)
+
+	return &usecase.ChargeResult{
+		Reference: req.IdempotencyKey,
+	}, nil
+}
+
+// Refund approves the refund and logs it so it can be paid out by hand
+func (g *ManualGateway) Refund(ctx context.Context, req *usecase.ChargeRefundRequest) error {
+	log.Printf("Manual refund of %s for order %s (charge %s)", req.Amount, req.OrderID, req.Reference)
+
+	return nil
+}
@@ -29,13 +29,14 @@ func (r *PostgresCartRepository) Create(ctx context.Context, cart *entity.Cart)
 
 	// Insert cart
 	query := `
-		INSERT INTO carts (id, user_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO carts (id, user_id, currency, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
 	`
 
 	_, err = tx.ExecContext(ctx, query,
 		cart.ID,
 		cart.UserID,
+		cart.Currency,
 		cart.CreatedAt,
 		cart.UpdatedAt,
 	)
@@ -47,16 +48,18 @@ func (r *PostgresCartRepository) Create(ctx context.Context, cart *entity.Cart)
 	// Insert cart items
 	for _, item := range cart.Items {
 		itemQuery := `
-			INSERT INTO cart_items (id, cart_id, product_id, quantity, price, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6)
+			INSERT INTO cart_items (id, cart_id, product_id, variant_id, quantity, price, currency, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		`
 
 		_, err = tx.ExecContext(ctx, itemQuery,
 			item.ID,
 			cart.ID,
 			item.ProductID,
+			item.VariantID,
 			item.Quantity,
 			item.Price,
+			item.Price.Currency,
 			time.Now(),
 		)
 
@@ -74,75 +77,16 @@ func (r *PostgresCartRepository) Create(ctx context.Context, cart *entity.Cart)
 
 // GetByID retrieves a cart by ID
 func (r *PostgresCartRepository) GetByID(ctx context.Context, id string) (*entity.Cart, error) {
-	query := `
-		SELECT id, user_id, created_at, updated_at
-		FROM carts
-		WHERE id = $1
-	`
-
-	var cart entity.Cart
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&cart.ID,
-		&cart.UserID,
-		&cart.CreatedAt,
-		&cart.UpdatedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("cart not found")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cart: %w", err)
-	}
-
-	// Get cart items
-	items, err := r.getCartItems(ctx, cart.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cart items: %w", err)
-	}
-
-	cart.Items = items
-
-	return &cart, nil
+	return r.getCart(ctx, "c.id = $1", id)
 }
 
 // GetByUserID retrieves a cart by user ID
 func (r *PostgresCartRepository) GetByUserID(ctx context.Context, userID string) (*entity.Cart, error) {
-	query := `
-		SELECT id, user_id, created_at, updated_at
-		FROM carts
-		WHERE user_id = $1
-	`
-
-	var cart entity.Cart
-
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(
-		&cart.ID,
-		&cart.UserID,
-		&cart.CreatedAt,
-		&cart.UpdatedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("cart not found")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cart: %w", err)
-	}
-
-	// Get cart items
-	items, err := r.getCartItems(ctx, cart.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cart items: %w", err)
-	}
-
-	cart.Items = items
-
-	return &cart, nil
+	return r.getCart(ctx, "c.user_id = $1", userID)
 }
 
-// Update updates an existing cart
+// Update updates an existing cart and replaces its items
+// ErrVersionConflict is returned if the cart was updated since it was read
 func (r *PostgresCartRepository) Update(ctx context.Context, cart *entity.Cart) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -153,24 +97,19 @@ func (r *PostgresCartRepository) Update(ctx context.Context, cart *entity.Cart)
 	// Update cart
 	query := `
 		UPDATE carts
-		SET updated_at = $1
-		WHERE id = $2
+		SET currency = $1, updated_at = $2, version = version + 1
+		WHERE id = $3 AND version = $4
 	`
 
 	cart.UpdatedAt = time.Now()
 
-	result, err := tx.ExecContext(ctx, query, cart.UpdatedAt, cart.ID)
+	result, err := tx.ExecContext(ctx, query, cart.Currency, cart.UpdatedAt, cart.ID, cart.Version)
 	if err != nil {
 		return fmt.Errorf("failed to update cart: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("cart not found")
+	if err := checkVersionedUpdate(ctx, tx, result, "carts", cart.ID, entity.ErrCartNotFound); err != nil {
+		return err
 	}
 
 	// Delete existing cart items
@@ -183,18 +122,20 @@ func (r *PostgresCartRepository) Update(ctx context.Context, cart *entity.Cart)
 	// Insert new cart items
 	for _, item := range cart.Items {
 		itemQuery := `
-			INSERT INTO cart_items (id, cart_id, product_id, quantity, price, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6)
-			ON CONFLICT (cart_id, product_id) 
-			DO UPDATE SET quantity = EXCLUDED.quantity, price = EXCLUDED.price
+			INSERT INTO cart_items (id, cart_id, product_id, variant_id, quantity, price, currency, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (cart_id, variant_id)
+			DO UPDATE SET quantity = EXCLUDED.quantity, price = EXCLUDED.price, currency = EXCLUDED.currency
 		`
 
 		_, err = tx.ExecContext(ctx, itemQuery,
 			item.ID,
 			cart.ID,
 			item.ProductID,
+			item.VariantID,
 			item.Quantity,
 			item.Price,
+			item.Price.Currency,
 			time.Now(),
 		)
 
@@ -207,6 +148,8 @@ func (r *PostgresCartRepository) Update(ctx context.Context, cart *entity.Cart)
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	cart.Version++
+
 	return nil
 }
 
@@ -231,43 +174,79 @@ func (r *PostgresCartRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-// getCartItems retrieves all items for a cart
-func (r *PostgresCartRepository) getCartItems(ctx context.Context, cartID string) ([]*entity.CartItem, error) {
+// getCart retrieves the cart matching condition together with its items, in a single query
+func (r *PostgresCartRepository) getCart(ctx context.Context, condition string, arg interface{}) (*entity.Cart, error) {
 	query := `
-		SELECT id, product_id, quantity, price
-		FROM cart_items
-		WHERE cart_id = $1
-		ORDER BY created_at ASC
+		SELECT c.id, c.user_id, c.currency, c.version, c.created_at, c.updated_at,
+			i.id, i.product_id, i.variant_id, i.quantity, i.price, i.currency
+		FROM carts c
+		LEFT JOIN cart_items i ON i.cart_id = c.id
+		WHERE ` + condition + `
+		ORDER BY i.created_at ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, cartID)
+	rows, err := r.db.QueryContext(ctx, query, arg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get cart items: %w", err)
+		return nil, fmt.Errorf("failed to get cart: %w", err)
 	}
 	defer rows.Close()
 
-	var items []*entity.CartItem
+	var cart *entity.Cart
 
 	for rows.Next() {
-		var item entity.CartItem
+		var header entity.Cart
+		var itemID, productID, variantID, price, currency sql.NullString
+		var quantity sql.NullInt64
 
 		err := rows.Scan(
-			&item.ID,
-			&item.ProductID,
-			&item.Quantity,
-			&item.Price,
+			&header.ID,
+			&header.UserID,
+			&header.Currency,
+			&header.Version,
+			&header.CreatedAt,
+			&header.UpdatedAt,
+			&itemID,
+			&productID,
+			&variantID,
+			&quantity,
+			&price,
+			&currency,
 		)
 
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan cart item: %w", err)
+			return nil, fmt.Errorf("failed to scan cart: %w", err)
+		}
+
+		if cart == nil {
+			cart = &header
 		}
 
-		items = append(items, &item)
+		// A cart without items comes back as a single row with NULL item columns
+		if !itemID.Valid {
+			continue
+		}
+
+		item := &entity.CartItem{
+			ID:        itemID.String,
+			ProductID: productID.String,
+			VariantID: variantID.String,
+			Quantity:  int(quantity.Int64),
+		}
+
+		if item.Price, err = entity.ParseMoney(price.String, currency.String); err != nil {
+			return nil, fmt.Errorf("failed to parse cart item price: %w", err)
+		}
+
+		cart.Items = append(cart.Items, item)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating cart items: %w", err)
 	}
 
-	return items, nil
+	if cart == nil {
+		return nil, fmt.Errorf("cart not found")
+	}
+
+	return cart, nil
 }
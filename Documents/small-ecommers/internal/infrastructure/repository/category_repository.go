@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// PostgresCategoryRepository implements CategoryRepository interface using PostgreSQL
+type PostgresCategoryRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresCategoryRepository creates a new PostgreSQL category repository
+func NewPostgresCategoryRepository(db *sql.DB) *PostgresCategoryRepository {
+	return &PostgresCategoryRepository{db: db}
+}
+
+const categoryColumns = `id, parent_id, name, slug, position, attributes, created_at, updated_at`
+
+// scanCategory reads a category selected with categoryColumns
+func scanCategory(row rowScanner) (*entity.Category, error) {
+	var category entity.Category
+	var parentID sql.NullString
+
+	err := row.Scan(
+		&category.ID,
+		&parentID,
+		&category.Name,
+		&category.Slug,
+		&category.Position,
+		&category.Attributes,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID.Valid {
+		category.ParentID = &parentID.String
+	}
+
+	return &category, nil
+}
+
+// Create creates a new category
+func (r *PostgresCategoryRepository) Create(ctx context.Context, category *entity.Category) error {
+	query := `
+		INSERT INTO categories (id, parent_id, name, slug, position, attributes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		category.ID,
+		category.ParentID,
+		category.Name,
+		category.Slug,
+		category.Position,
+		category.Attributes,
+		category.CreatedAt,
+		category.UpdatedAt,
+	)
+
+	if isUniqueViolation(err) {
+		return entity.ErrCategoryExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a category by ID
+func (r *PostgresCategoryRepository) GetByID(ctx context.Context, id string) (*entity.Category, error) {
+	return r.get(ctx, "id", id)
+}
+
+// GetBySlug retrieves a category by slug
+func (r *PostgresCategoryRepository) GetBySlug(ctx context.Context, slug string) (*entity.Category, error) {
+	return r.get(ctx, "slug", slug)
+}
+
+// get retrieves the category whose column equals value
+func (r *PostgresCategoryRepository) get(ctx context.Context, column, value string) (*entity.Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE ` + column + ` = $1`
+
+	category, err := scanCategory(r.db.QueryRowContext(ctx, query, value))
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	return category, nil
+}
+
+// GetByIDs retrieves the categories with the given IDs, unknown IDs are ignored
+func (r *PostgresCategoryRepository) GetByIDs(ctx context.Context, ids []string) ([]*entity.Category, error) {
+	if len(ids) == 0 {
+		return []*entity.Category{}, nil
+	}
+
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE id = ANY($1)`
+
+	return r.list(ctx, query, pq.Array(ids))
+}
+
+// List retrieves all categories, without linking them into a tree
+func (r *PostgresCategoryRepository) List(ctx context.Context) ([]*entity.Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories ORDER BY position, name`
+
+	return r.list(ctx, query)
+}
+
+// list runs a query selecting categoryColumns
+func (r *PostgresCategoryRepository) list(ctx context.Context, query string, args ...interface{}) ([]*entity.Category, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []*entity.Category{}
+
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+
+		categories = append(categories, category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+// GetDescendantIDs retrieves the ID of a category and of every category below it
+func (r *PostgresCategoryRepository) GetDescendantIDs(ctx context.Context, id string) ([]string, error) {
+	// UNION rather than UNION ALL stops the recursion should the tree ever contain a cycle
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+		)
+		SELECT id FROM tree
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category descendants: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+
+	for rows.Next() {
+		var descendantID string
+		if err := rows.Scan(&descendantID); err != nil {
+			return nil, fmt.Errorf("failed to scan category ID: %w", err)
+		}
+
+		ids = append(ids, descendantID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating category descendants: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return nil, entity.ErrCategoryNotFound
+	}
+
+	return ids, nil
+}
+
+// Update updates an existing category
+func (r *PostgresCategoryRepository) Update(ctx context.Context, category *entity.Category) error {
+	query := `
+		UPDATE categories
+		SET parent_id = $1, name = $2, slug = $3, position = $4, attributes = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		category.ParentID,
+		category.Name,
+		category.Slug,
+		category.Position,
+		category.Attributes,
+		category.UpdatedAt,
+		category.ID,
+	)
+
+	if isUniqueViolation(err) {
+		return entity.ErrCategoryExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return entity.ErrCategoryNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes a category, which must not have children
+// Products assigned to it are unassigned
+func (r *PostgresCategoryRepository) Delete(ctx context.Context, id string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var hasChildren bool
+	query := `SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)`
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&hasChildren); err != nil {
+		return fmt.Errorf("failed to check category children: %w", err)
+	}
+
+	if hasChildren {
+		return entity.ErrCategoryHasChildren
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return entity.ErrCategoryNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// isUniqueViolation checks if an error is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
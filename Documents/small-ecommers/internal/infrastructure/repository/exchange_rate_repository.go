@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// PostgresExchangeRateRepository implements ExchangeRateRepository interface using PostgreSQL
+type PostgresExchangeRateRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresExchangeRateRepository creates a new PostgreSQL exchange rate repository
+func NewPostgresExchangeRateRepository(db *sql.DB) *PostgresExchangeRateRepository {
+	return &PostgresExchangeRateRepository{db: db}
+}
+
+const upsertExchangeRateQuery = `
+	INSERT INTO exchange_rates (base_currency, quote_currency, rate, updated_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (base_currency, quote_currency)
+	DO UPDATE SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at
+`
+
+// Upsert creates or replaces the rate for a currency pair
+func (r *PostgresExchangeRateRepository) Upsert(ctx context.Context, rate *entity.ExchangeRate) error {
+	_, err := r.db.ExecContext(ctx, upsertExchangeRateQuery,
+		rate.BaseCurrency,
+		rate.QuoteCurrency,
+		rate.Rate,
+		rate.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to upsert exchange rate: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertMany creates or replaces several rates atomically
+func (r *PostgresExchangeRateRepository) UpsertMany(ctx context.Context, rates []*entity.ExchangeRate) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, rate := range rates {
+		_, err = tx.ExecContext(ctx, upsertExchangeRateQuery,
+			rate.BaseCurrency,
+			rate.QuoteCurrency,
+			rate.Rate,
+			rate.UpdatedAt,
+		)
+
+		if err != nil {
+			return fmt.Errorf("failed to upsert exchange rate %s/%s: %w", rate.BaseCurrency, rate.QuoteCurrency, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves the rate for a currency pair
+func (r *PostgresExchangeRateRepository) Get(ctx context.Context, baseCurrency, quoteCurrency string) (*entity.ExchangeRate, error) {
+	query := `
+		SELECT base_currency, quote_currency, rate, updated_at
+		FROM exchange_rates
+		WHERE base_currency = $1 AND quote_currency = $2
+	`
+
+	var rate entity.ExchangeRate
+
+	err := r.db.QueryRowContext(ctx, query, baseCurrency, quoteCurrency).Scan(
+		&rate.BaseCurrency,
+		&rate.QuoteCurrency,
+		&rate.Rate,
+		&rate.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrExchangeRateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+
+	return &rate, nil
+}
+
+// List retrieves all rates
+func (r *PostgresExchangeRateRepository) List(ctx context.Context) ([]*entity.ExchangeRate, error) {
+	query := `
+		SELECT base_currency, quote_currency, rate, updated_at
+		FROM exchange_rates
+		ORDER BY base_currency, quote_currency
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []*entity.ExchangeRate
+
+	for rows.Next() {
+		var rate entity.ExchangeRate
+
+		err := rows.Scan(
+			&rate.BaseCurrency,
+			&rate.QuoteCurrency,
+			&rate.Rate,
+			&rate.UpdatedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+
+		rates = append(rates, &rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating exchange rates: %w", err)
+	}
+
+	return rates, nil
+}
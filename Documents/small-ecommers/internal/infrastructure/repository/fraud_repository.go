@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// PostgresFraudRepository implements FraudRepository interface using PostgreSQL
+type PostgresFraudRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresFraudRepository creates a new PostgreSQL fraud repository
+func NewPostgresFraudRepository(db *sql.DB) *PostgresFraudRepository {
+	return &PostgresFraudRepository{db: db}
+}
+
+// fraudScreeningColumns are the columns read by scanFraudScreening, in order
+const fraudScreeningColumns = `id, order_id, score, outcome, reviewed_by, review_decision,
+		review_note, reviewed_at, created_at, client_ip`
+
+// Create records a screening together with its rule hits
+func (r *PostgresFraudRepository) Create(ctx context.Context, screening *entity.FraudScreening) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO fraud_screenings (` + fraudScreeningColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		screening.ID,
+		screening.OrderID,
+		screening.Score,
+		screening.Outcome,
+		screening.ReviewedBy,
+		screening.ReviewDecision,
+		screening.ReviewNote,
+		screening.ReviewedAt,
+		screening.CreatedAt,
+		screening.ClientIP,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create fraud screening: %w", err)
+	}
+
+	for i, hit := range screening.Hits {
+		hitQuery := `
+			INSERT INTO fraud_rule_hits (screening_id, position, rule, score, reason)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+
+		if _, err := tx.ExecContext(ctx, hitQuery, screening.ID, i, hit.Rule, hit.Score, hit.Reason); err != nil {
+			return fmt.Errorf("failed to create fraud rule hit: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestByOrderID retrieves the most recent screening of an order
+func (r *PostgresFraudRepository) GetLatestByOrderID(ctx context.Context, orderID string) (*entity.FraudScreening, error) {
+	query := `
+		SELECT ` + fraudScreeningColumns + `
+		FROM fraud_screenings
+		WHERE order_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	screening, err := scanFraudScreening(r.db.QueryRowContext(ctx, query, orderID))
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrFraudScreeningNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fraud screening: %w", err)
+	}
+
+	if screening.Hits, err = r.getHits(ctx, screening.ID); err != nil {
+		return nil, err
+	}
+
+	return screening, nil
+}
+
+// UpdateReview records the admin review of a screening
+func (r *PostgresFraudRepository) UpdateReview(ctx context.Context, screening *entity.FraudScreening) error {
+	query := `
+		UPDATE fraud_screenings
+		SET reviewed_by = $1, review_decision = $2, review_note = $3, reviewed_at = $4
+		WHERE id = $5
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		screening.ReviewedBy,
+		screening.ReviewDecision,
+		screening.ReviewNote,
+		screening.ReviewedAt,
+		screening.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update fraud screening: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return entity.ErrFraudScreeningNotFound
+	}
+
+	return nil
+}
+
+// ListPendingReviews retrieves the screenings that held an order and await review, oldest first
+func (r *PostgresFraudRepository) ListPendingReviews(ctx context.Context) ([]*entity.FraudScreening, error) {
+	query := `
+		SELECT ` + fraudScreeningColumns + `
+		FROM fraud_screenings
+		WHERE outcome = $1 AND review_decision IS NULL
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, entity.FraudOutcomeReview)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fraud screenings: %w", err)
+	}
+	defer rows.Close()
+
+	var screenings []*entity.FraudScreening
+
+	for rows.Next() {
+		screening, err := scanFraudScreening(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fraud screening: %w", err)
+		}
+
+		screenings = append(screenings, screening)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating fraud screenings: %w", err)
+	}
+
+	for _, screening := range screenings {
+		if screening.Hits, err = r.getHits(ctx, screening.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return screenings, nil
+}
+
+// getHits retrieves the rule hits of a screening
+func (r *PostgresFraudRepository) getHits(ctx context.Context, screeningID string) ([]entity.FraudRuleHit, error) {
+	query := `
+		SELECT rule, score, reason
+		FROM fraud_rule_hits
+		WHERE screening_id = $1
+		ORDER BY position ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, screeningID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fraud rule hits: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []entity.FraudRuleHit{}
+
+	for rows.Next() {
+		var hit entity.FraudRuleHit
+
+		if err := rows.Scan(&hit.Rule, &hit.Score, &hit.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan fraud rule hit: %w", err)
+		}
+
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating fraud rule hits: %w", err)
+	}
+
+	return hits, nil
+}
+
+// scanFraudScreening reads a screening selected with fraudScreeningColumns, without its hits
+func scanFraudScreening(row rowScanner) (*entity.FraudScreening, error) {
+	var screening entity.FraudScreening
+	var reviewedBy, reviewDecision, reviewNote, clientIP sql.NullString
+	var reviewedAt sql.NullTime
+
+	err := row.Scan(
+		&screening.ID,
+		&screening.OrderID,
+		&screening.Score,
+		&screening.Outcome,
+		&reviewedBy,
+		&reviewDecision,
+		&reviewNote,
+		&reviewedAt,
+		&screening.CreatedAt,
+		&clientIP,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if reviewedBy.Valid {
+		screening.ReviewedBy = &reviewedBy.String
+	}
+	if reviewDecision.Valid {
+		decision := entity.FraudReviewDecision(reviewDecision.String)
+		screening.ReviewDecision = &decision
+	}
+	if reviewNote.Valid {
+		screening.ReviewNote = &reviewNote.String
+	}
+	if reviewedAt.Valid {
+		screening.ReviewedAt = &reviewedAt.Time
+	}
+	if clientIP.Valid {
+		screening.ClientIP = &clientIP.String
+	}
+
+	return &screening, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// PostgresGiftCardRepository implements GiftCardRepository interface using PostgreSQL
+type PostgresGiftCardRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresGiftCardRepository creates a new PostgreSQL gift card repository
+func NewPostgresGiftCardRepository(db *sql.DB) *PostgresGiftCardRepository {
+	return &PostgresGiftCardRepository{db: db}
+}
+
+// Create creates a new gift card together with the ledger entry issuing its balance
+func (r *PostgresGiftCardRepository) Create(ctx context.Context, card *entity.GiftCard, issue *entity.LedgerEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO gift_cards (id, code, currency, initial_balance, balance, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		card.ID,
+		card.Code,
+		card.InitialBalance.Currency,
+		card.InitialBalance,
+		card.Balance,
+		card.ExpiresAt,
+		card.CreatedAt,
+		card.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create gift card: %w", err)
+	}
+
+	issue.BalanceAfter = card.Balance
+	if err := insertLedgerEntry(ctx, tx, issue); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetByCode retrieves a gift card by its code
+func (r *PostgresGiftCardRepository) GetByCode(ctx context.Context, code string) (*entity.GiftCard, error) {
+	query := `
+		SELECT id, code, currency, initial_balance, balance, expires_at, created_at, updated_at
+		FROM gift_cards
+		WHERE code = $1
+	`
+
+	var card entity.GiftCard
+	var currency, initialBalance, balance string
+	var expiresAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, code).Scan(
+		&card.ID,
+		&card.Code,
+		&currency,
+		&initialBalance,
+		&balance,
+		&expiresAt,
+		&card.CreatedAt,
+		&card.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrGiftCardNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gift card: %w", err)
+	}
+
+	if card.InitialBalance, err = entity.ParseMoney(initialBalance, currency); err != nil {
+		return nil, fmt.Errorf("failed to parse gift card balance: %w", err)
+	}
+	if card.Balance, err = entity.ParseMoney(balance, currency); err != nil {
+		return nil, fmt.Errorf("failed to parse gift card balance: %w", err)
+	}
+
+	if expiresAt.Valid {
+		card.ExpiresAt = &expiresAt.Time
+	}
+
+	return &card, nil
+}
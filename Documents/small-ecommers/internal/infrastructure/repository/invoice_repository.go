@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// PostgresInvoiceRepository implements InvoiceRepository interface using PostgreSQL
+type PostgresInvoiceRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresInvoiceRepository creates a new PostgreSQL invoice repository
+func NewPostgresInvoiceRepository(db *sql.DB) *PostgresInvoiceRepository {
+	return &PostgresInvoiceRepository{db: db}
+}
+
+// Create numbers the invoice with the next number of its year and saves it
+// The sequence row stays locked until commit, so concurrent invoices are numbered one after another
+// and a failed invoice rolls its number back instead of leaving a gap
+func (r *PostgresInvoiceRepository) Create(ctx context.Context, invoice *entity.Invoice, store func(*entity.Invoice) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var sequence int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO invoice_sequences (year, last_number)
+		VALUES ($1, 1)
+		ON CONFLICT (year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number
+	`, invoice.Year).Scan(&sequence)
+	if err != nil {
+		return fmt.Errorf("failed to allocate invoice number: %w", err)
+	}
+
+	invoice.AssignNumber(sequence)
+
+	query := `
+		INSERT INTO invoices (id, order_id, number, year, sequence, storage_key, issued_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		invoice.ID,
+		invoice.OrderID,
+		invoice.Number,
+		invoice.Year,
+		invoice.Sequence,
+		invoice.StorageKey,
+		invoice.IssuedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	if err := store(invoice); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetByOrderID retrieves the invoice of an order
+func (r *PostgresInvoiceRepository) GetByOrderID(ctx context.Context, orderID string) (*entity.Invoice, error) {
+	query := `
+		SELECT id, order_id, number, year, sequence, storage_key, issued_at
+		FROM invoices
+		WHERE order_id = $1
+	`
+
+	var invoice entity.Invoice
+
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
+		&invoice.ID,
+		&invoice.OrderID,
+		&invoice.Number,
+		&invoice.Year,
+		&invoice.Sequence,
+		&invoice.StorageKey,
+		&invoice.IssuedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	return &invoice, nil
+}
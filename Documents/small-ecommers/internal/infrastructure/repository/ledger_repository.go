@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// PostgresLedgerRepository implements LedgerRepository interface using PostgreSQL
+type PostgresLedgerRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresLedgerRepository creates a new PostgreSQL ledger repository
+func NewPostgresLedgerRepository(db *sql.DB) *PostgresLedgerRepository {
+	return &PostgresLedgerRepository{db: db}
+}
+
+// ledgerEntryColumns are the columns read by scanLedgerEntry, in order
+const ledgerEntryColumns = `id, account_type, account_id, currency, amount, balance_after,
+		reason, order_id, note, created_at`
+
+// Post applies the entries to their balances atomically and fills in their BalanceAfter
+func (r *PostgresLedgerRepository) Post(ctx context.Context, entries []*entity.LedgerEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		if err := postLedgerEntry(ctx, tx, entry); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetByAccount retrieves the entries of an account, oldest first
+func (r *PostgresLedgerRepository) GetByAccount(ctx context.Context, accountType entity.LedgerAccountType, accountID string) ([]*entity.LedgerEntry, error) {
+	query := `
+		SELECT ` + ledgerEntryColumns + `
+		FROM ledger_entries
+		WHERE account_type = $1 AND account_id = $2
+		ORDER BY created_at ASC, id ASC
+	`
+
+	return r.list(ctx, query, accountType, accountID)
+}
+
+// GetByOrderID retrieves the entries posted for an order, oldest first
+func (r *PostgresLedgerRepository) GetByOrderID(ctx context.Context, orderID string) ([]*entity.LedgerEntry, error) {
+	query := `
+		SELECT ` + ledgerEntryColumns + `
+		FROM ledger_entries
+		WHERE order_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	return r.list(ctx, query, orderID)
+}
+
+// GetStoreCreditBalances retrieves the store credit of a user, one balance per currency
+func (r *PostgresLedgerRepository) GetStoreCreditBalances(ctx context.Context, userID string) ([]entity.Money, error) {
+	query := `
+		SELECT currency, balance
+		FROM store_credit_accounts
+		WHERE user_id = $1
+		ORDER BY currency
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store credit: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []entity.Money
+
+	for rows.Next() {
+		var currency, balance string
+
+		if err := rows.Scan(&currency, &balance); err != nil {
+			return nil, fmt.Errorf("failed to scan store credit: %w", err)
+		}
+
+		money, err := entity.ParseMoney(balance, currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse store credit: %w", err)
+		}
+
+		balances = append(balances, money)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating store credit: %w", err)
+	}
+
+	return balances, nil
+}
+
+// list retrieves the ledger entries selected by query
+func (r *PostgresLedgerRepository) list(ctx context.Context, query string, args ...interface{}) ([]*entity.LedgerEntry, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*entity.LedgerEntry
+
+	for rows.Next() {
+		entry, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ledger entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// postLedgerEntry locks the balance of an entry's account, applies the entry and records it
+func postLedgerEntry(ctx context.Context, tx *sql.Tx, entry *entity.LedgerEntry) error {
+	var currency, balance string
+
+	switch entry.AccountType {
+	case entity.LedgerAccountGiftCard:
+		var expiresAt sql.NullTime
+
+		err := tx.QueryRowContext(ctx, `
+			SELECT currency, balance, expires_at
+			FROM gift_cards
+			WHERE id = $1
+			FOR UPDATE
+		`, entry.AccountID).Scan(&currency, &balance, &expiresAt)
+		if err == sql.ErrNoRows {
+			return entity.ErrGiftCardNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock gift card: %w", err)
+		}
+
+		if entry.Amount.IsNegative() && expiresAt.Valid && !entry.CreatedAt.Before(expiresAt.Time) {
+			return entity.ErrGiftCardExpired
+		}
+
+	case entity.LedgerAccountStoreCredit:
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO store_credit_accounts (user_id, currency, balance, updated_at)
+			VALUES ($1, $2, 0, $3)
+			ON CONFLICT (user_id, currency) DO NOTHING
+		`, entry.AccountID, entry.Amount.Currency, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to open store credit account: %w", err)
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			SELECT currency, balance
+			FROM store_credit_accounts
+			WHERE user_id = $1 AND currency = $2
+			FOR UPDATE
+		`, entry.AccountID, entry.Amount.Currency).Scan(&currency, &balance)
+		if err != nil {
+			return fmt.Errorf("failed to lock store credit account: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unknown ledger account type: %s", entry.AccountType)
+	}
+
+	current, err := entity.ParseMoney(balance, currency)
+	if err != nil {
+		return fmt.Errorf("failed to parse balance: %w", err)
+	}
+
+	if !current.SameCurrency(entry.Amount) {
+		return entity.ErrCurrencyMismatch
+	}
+
+	entry.BalanceAfter = current.Add(entry.Amount)
+	if entry.BalanceAfter.IsNegative() {
+		return entity.ErrInsufficientBalance
+	}
+
+	if entry.AccountType == entity.LedgerAccountGiftCard {
+		_, err = tx.ExecContext(ctx, `UPDATE gift_cards SET balance = $1, updated_at = $2 WHERE id = $3`,
+			entry.BalanceAfter, entry.CreatedAt, entry.AccountID)
+	} else {
+		_, err = tx.ExecContext(ctx, `UPDATE store_credit_accounts SET balance = $1, updated_at = $2 WHERE user_id = $3 AND currency = $4`,
+			entry.BalanceAfter, entry.CreatedAt, entry.AccountID, currency)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	return insertLedgerEntry(ctx, tx, entry)
+}
+
+// insertLedgerEntry records a ledger entry whose balance has already been applied
+func insertLedgerEntry(ctx context.Context, tx *sql.Tx, entry *entity.LedgerEntry) error {
+	query := `
+		INSERT INTO ledger_entries (` + ledgerEntryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := tx.ExecContext(ctx, query,
+		entry.ID,
+		entry.AccountType,
+		entry.AccountID,
+		entry.Amount.Currency,
+		entry.Amount,
+		entry.BalanceAfter,
+		entry.Reason,
+		entry.OrderID,
+		entry.Note,
+		entry.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create ledger entry: %w", err)
+	}
+
+	return nil
+}
+
+// scanLedgerEntry reads a ledger entry selected with ledgerEntryColumns
+func scanLedgerEntry(row rowScanner) (*entity.LedgerEntry, error) {
+	var entry entity.LedgerEntry
+	var currency, amount, balanceAfter string
+	var orderID, note sql.NullString
+
+	err := row.Scan(
+		&entry.ID,
+		&entry.AccountType,
+		&entry.AccountID,
+		&currency,
+		&amount,
+		&balanceAfter,
+		&entry.Reason,
+		&orderID,
+		&note,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Amount, err = entity.ParseMoney(amount, currency); err != nil {
+		return nil, fmt.Errorf("failed to parse ledger amount: %w", err)
+	}
+	if entry.BalanceAfter, err = entity.ParseMoney(balanceAfter, currency); err != nil {
+		return nil, fmt.Errorf("failed to parse ledger balance: %w", err)
+	}
+
+	if orderID.Valid {
+		entry.OrderID = &orderID.String
+	}
+	if note.Valid {
+		entry.Note = &note.String
+	}
+
+	return &entry, nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// PostgresLoyaltyRepository implements LoyaltyRepository interface using PostgreSQL
+type PostgresLoyaltyRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresLoyaltyRepository creates a new PostgreSQL loyalty repository
+func NewPostgresLoyaltyRepository(db *sql.DB) *PostgresLoyaltyRepository {
+	return &PostgresLoyaltyRepository{db: db}
+}
+
+// loyaltyHistoryQuery selects the points history of a user in the order it was posted
+const loyaltyHistoryQuery = `
+	SELECT id, user_id, points, reason, order_id, expires_at, created_at
+	FROM loyalty_entries
+	WHERE user_id = $1
+	ORDER BY seq ASC
+`
+
+// GetByUserID retrieves the points history of a user, oldest first
+func (r *PostgresLoyaltyRepository) GetByUserID(ctx context.Context, userID string) ([]*entity.LoyaltyEntry, error) {
+	rows, err := r.db.QueryContext(ctx, loyaltyHistoryQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty entries: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLoyaltyEntries(rows)
+}
+
+// Apply locks the points of a user, passes their history to fn and records the entries it returns
+func (r *PostgresLoyaltyRepository) Apply(ctx context.Context, userID string, fn func(history []*entity.LoyaltyEntry) ([]*entity.LoyaltyEntry, error)) ([]*entity.LoyaltyEntry, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialise changes to the points of one user until the transaction ends
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('loyalty:' || $1))`, userID); err != nil {
+		return nil, fmt.Errorf("failed to lock loyalty points: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, loyaltyHistoryQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty entries: %w", err)
+	}
+
+	history, err := scanLoyaltyEntries(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := fn(history)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		query := `
+			INSERT INTO loyalty_entries (id, user_id, points, reason, order_id, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`
+
+		_, err := tx.ExecContext(ctx, query,
+			entry.ID,
+			entry.UserID,
+			entry.Points,
+			entry.Reason,
+			entry.OrderID,
+			entry.ExpiresAt,
+			entry.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to create loyalty entry: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return append(history, entries...), nil
+}
+
+// scanLoyaltyEntries reads the rows selected by loyaltyHistoryQuery
+func scanLoyaltyEntries(rows *sql.Rows) ([]*entity.LoyaltyEntry, error) {
+	var entries []*entity.LoyaltyEntry
+
+	for rows.Next() {
+		var entry entity.LoyaltyEntry
+		var orderID sql.NullString
+		var expiresAt sql.NullTime
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.UserID,
+			&entry.Points,
+			&entry.Reason,
+			&orderID,
+			&expiresAt,
+			&entry.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan loyalty entry: %w", err)
+		}
+
+		if orderID.Valid {
+			entry.OrderID = &orderID.String
+		}
+		if expiresAt.Valid {
+			entry.ExpiresAt = &expiresAt.Time
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating loyalty entries: %w", err)
+	}
+
+	return entries, nil
+}
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
+
 	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
 )
 
 // PostgresOrderRepository implements OrderRepository interface using PostgreSQL
@@ -20,6 +23,8 @@ func NewPostgresOrderRepository(db *sql.DB) *PostgresOrderRepository {
 }
 
 // Create creates a new order
+// Stock is reserved for the allocated part of every item, and backorders are counted against
+// its variant, in the same transaction. ErrInsufficientStock is returned if stock ran out meanwhile.
 func (r *PostgresOrderRepository) Create(ctx context.Context, order *entity.Order) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -29,14 +34,33 @@ func (r *PostgresOrderRepository) Create(ctx context.Context, order *entity.Orde
 
 	// Insert order
 	query := `
-		INSERT INTO orders (id, user_id, total, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO orders (
+			id, user_id, currency, subtotal, line_discount_total, order_discount,
+			points_discount, points_redeemed, tax_total, shipping_total, total,
+			exchange_base_currency, exchange_rate, exchange_rate_updated_at, shipping_address,
+			billing_address, client_ip, status, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 	`
 
 	_, err = tx.ExecContext(ctx, query,
 		order.ID,
 		order.UserID,
+		order.Total.Currency,
+		order.Breakdown.Subtotal,
+		order.Breakdown.LineDiscountTotal,
+		order.Breakdown.OrderDiscount,
+		order.Breakdown.PointsDiscount,
+		order.PointsRedeemed,
+		order.Breakdown.Tax,
+		order.Breakdown.Shipping,
 		order.Total,
+		exchangeBaseCurrency(order),
+		exchangeRate(order),
+		exchangeRateUpdatedAt(order),
+		order.ShippingAddress,
+		order.BillingAddress,
+		order.ClientIP,
 		order.Status,
 		order.CreatedAt,
 		order.UpdatedAt,
@@ -49,22 +73,34 @@ func (r *PostgresOrderRepository) Create(ctx context.Context, order *entity.Orde
 	// Insert order items
 	for _, item := range order.Items {
 		itemQuery := `
-			INSERT INTO order_items (id, order_id, product_id, quantity, price, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6)
+			INSERT INTO order_items (
+				id, order_id, product_id, variant_id, quantity, price, discount, status,
+				backordered_quantity, expected_at, created_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		`
 
 		_, err = tx.ExecContext(ctx, itemQuery,
 			item.ID,
 			order.ID,
 			item.ProductID,
+			item.VariantID,
 			item.Quantity,
 			item.Price,
+			item.Discount,
+			item.Status,
+			item.BackorderedQuantity,
+			item.ExpectedAt,
 			time.Now(),
 		)
 
 		if err != nil {
 			return fmt.Errorf("failed to create order item: %w", err)
 		}
+
+		if err := reserveStock(ctx, tx, item); err != nil {
+			return err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -74,181 +110,142 @@ func (r *PostgresOrderRepository) Create(ctx context.Context, order *entity.Orde
 	return nil
 }
 
+// reserveStock takes the allocated units of an item from its variant's stock and counts its backorder
+// The update only applies if the stock and the product's backorder policy still allow it
+func reserveStock(ctx context.Context, tx *sql.Tx, item *entity.OrderItem) error {
+	query := `
+		UPDATE product_variants v
+		SET stock = v.stock - $1, backordered = v.backordered + $2, updated_at = $3
+		FROM products p
+		WHERE v.id = $4 AND p.id = v.product_id AND v.stock >= $1 AND (
+			$2 = 0 OR (
+				p.stock_policy <> $5 AND
+				(p.backorder_limit IS NULL OR v.backordered + $2 <= p.backorder_limit)
+			)
+		)
+	`
+
+	result, err := tx.ExecContext(ctx, query,
+		item.AllocatedQuantity(),
+		item.BackorderedQuantity,
+		time.Now(),
+		item.VariantID,
+		entity.StockPolicyDeny,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reserve stock: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return entity.ErrInsufficientStock
+	}
+
+	return nil
+}
+
 // GetByID retrieves an order by ID
 func (r *PostgresOrderRepository) GetByID(ctx context.Context, id string) (*entity.Order, error) {
 	query := `
-		SELECT id, user_id, total, status, created_at, updated_at
+		SELECT ` + orderColumns + `
 		FROM orders
 		WHERE id = $1
 	`
 
-	var order entity.Order
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&order.ID,
-		&order.UserID,
-		&order.Total,
-		&order.Status,
-		&order.CreatedAt,
-		&order.UpdatedAt,
-	)
-
+	order, err := scanOrder(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("order not found")
+		return nil, entity.ErrOrderNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
 
-	// Get order items
-	items, err := r.getOrderItems(ctx, order.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get order items: %w", err)
+	if err := r.loadOrderDetails(ctx, order); err != nil {
+		return nil, err
 	}
 
-	order.Items = items
-
-	return &order, nil
+	return order, nil
 }
 
 // GetByUserID retrieves all orders for a user
 func (r *PostgresOrderRepository) GetByUserID(ctx context.Context, userID string) ([]*entity.Order, error) {
 	query := `
-		SELECT id, user_id, total, status, created_at, updated_at
+		SELECT ` + orderColumns + `
 		FROM orders
 		WHERE user_id = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get orders: %w", err)
-	}
-	defer rows.Close()
-
-	var orders []*entity.Order
-
-	for rows.Next() {
-		var order entity.Order
-
-		err := rows.Scan(
-			&order.ID,
-			&order.UserID,
-			&order.Total,
-			&order.Status,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-		)
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan order: %w", err)
-		}
-
-		// Get order items
-		items, err := r.getOrderItems(ctx, order.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get order items: %w", err)
-		}
-
-		order.Items = items
-
-		orders = append(orders, &order)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating orders: %w", err)
-	}
-
-	return orders, nil
+	return r.list(ctx, query, userID)
 }
 
 // Update updates an existing order
+// Items are fixed at checkout and are not rewritten, backorder allocation updates them in place.
+// ErrVersionConflict is returned if the order was updated since it was read.
 func (r *PostgresOrderRepository) Update(ctx context.Context, order *entity.Order) error {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	// Update order
 	query := `
 		UPDATE orders
-		SET total = $1, status = $2, updated_at = $3
-		WHERE id = $4
+		SET currency = $1, subtotal = $2, line_discount_total = $3, order_discount = $4,
+			tax_total = $5, shipping_total = $6, total = $7, payment_reference = $8,
+			refunded_total = $9, status = $10, updated_at = $11, version = version + 1
+		WHERE id = $12 AND version = $13
 	`
 
 	order.UpdatedAt = time.Now()
 
-	result, err := tx.ExecContext(ctx, query, order.Total, order.Status, order.UpdatedAt, order.ID)
+	result, err := r.db.ExecContext(ctx, query,
+		order.Total.Currency,
+		order.Breakdown.Subtotal,
+		order.Breakdown.LineDiscountTotal,
+		order.Breakdown.OrderDiscount,
+		order.Breakdown.Tax,
+		order.Breakdown.Shipping,
+		order.Total,
+		order.PaymentReference,
+		order.RefundedTotal,
+		order.Status,
+		order.UpdatedAt,
+		order.ID,
+		order.Version,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to update order: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("order not found")
-	}
-
-	// Delete existing order items
-	deleteItemsQuery := `DELETE FROM order_items WHERE order_id = $1`
-	_, err = tx.ExecContext(ctx, deleteItemsQuery, order.ID)
-	if err != nil {
-		return fmt.Errorf("failed to delete order items: %w", err)
-	}
-
-	// Insert new order items
-	for _, item := range order.Items {
-		itemQuery := `
-			INSERT INTO order_items (id, order_id, product_id, quantity, price, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6)
-		`
-
-		_, err = tx.ExecContext(ctx, itemQuery,
-			item.ID,
-			order.ID,
-			item.ProductID,
-			item.Quantity,
-			item.Price,
-			time.Now(),
-		)
-
-		if err != nil {
-			return fmt.Errorf("failed to create order item: %w", err)
-		}
+	if err := checkVersionedUpdate(ctx, r.db, result, "orders", order.ID, entity.ErrOrderNotFound); err != nil {
+		return err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
+	order.Version++
 
 	return nil
 }
 
-// UpdateStatus updates the status of an order
-func (r *PostgresOrderRepository) UpdateStatus(ctx context.Context, id string, status entity.OrderStatus) error {
+// UpdateStatus saves the status of an order
+// ErrVersionConflict is returned if the order was updated since it was read.
+func (r *PostgresOrderRepository) UpdateStatus(ctx context.Context, order *entity.Order) error {
 	query := `
 		UPDATE orders
-		SET status = $1, updated_at = $2
-		WHERE id = $3
+		SET status = $1, updated_at = $2, version = version + 1
+		WHERE id = $3 AND version = $4
 	`
 
-	result, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	order.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, order.Status, order.UpdatedAt, order.ID, order.Version)
 	if err != nil {
 		return fmt.Errorf("failed to update order status: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	if err := checkVersionedUpdate(ctx, r.db, result, "orders", order.ID, entity.ErrOrderNotFound); err != nil {
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("order not found")
-	}
+	order.Version++
 
 	return nil
 }
@@ -277,44 +274,139 @@ func (r *PostgresOrderRepository) Delete(ctx context.Context, id string) error {
 // List retrieves all orders
 func (r *PostgresOrderRepository) List(ctx context.Context) ([]*entity.Order, error) {
 	query := `
-		SELECT id, user_id, total, status, created_at, updated_at
+		SELECT ` + orderColumns + `
 		FROM orders
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	return r.list(ctx, query)
+}
+
+// Search retrieves a page of the orders matching a filter, in the requested order
+func (r *PostgresOrderRepository) Search(ctx context.Context, search *repository.OrderSearch) ([]*entity.Order, error) {
+	var b queryBuilder
+	applyOrderFilter(&b, &search.Filter)
+
+	column, direction, comparison := "created_at", "ASC", ">"
+	if search.Sort == repository.OrderSortTotal {
+		column = "total"
+	}
+	if search.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if after := search.After; after != nil {
+		var value interface{} = after.CreatedAt
+		if search.Sort == repository.OrderSortTotal {
+			value = after.Total
+		}
+		b.where(fmt.Sprintf("(%s, id) %s (%s, %s)", column, comparison, b.arg(value), b.arg(after.ID)))
+	}
+
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		` + b.whereClause() + `
+		ORDER BY ` + column + ` ` + direction + `, id ` + direction + `
+		LIMIT ` + b.arg(search.Limit)
+
+	orders, err := r.scanOrders(ctx, query, b.args...)
+	if err != nil || !search.IncludeItems {
+		return orders, err
+	}
+
+	if err := r.loadOrderDetails(ctx, orders...); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// Count counts the orders matching a filter
+func (r *PostgresOrderRepository) Count(ctx context.Context, filter *repository.OrderFilter) (int, error) {
+	var b queryBuilder
+	applyOrderFilter(&b, filter)
+
+	query := `SELECT COUNT(*) FROM orders ` + b.whereClause()
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, b.args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	return count, nil
+}
+
+// applyOrderFilter adds the conditions of an order filter to a query
+func applyOrderFilter(b *queryBuilder, filter *repository.OrderFilter) {
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		b.where("status = ANY(" + b.arg(pq.Array(statuses)) + ")")
+	}
+
+	if filter.UserID != "" {
+		b.where("user_id = " + b.arg(filter.UserID))
+	}
+
+	if filter.ProductID != "" {
+		b.where("EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id AND order_items.product_id = " + b.arg(filter.ProductID) + ")")
+	}
+
+	if filter.CreatedFrom != nil {
+		b.where("created_at >= " + b.arg(*filter.CreatedFrom))
+	}
+
+	if filter.CreatedTo != nil {
+		b.where("created_at < " + b.arg(*filter.CreatedTo))
+	}
+
+	if filter.Currency != "" {
+		b.where("currency = " + b.arg(filter.Currency))
+	}
+
+	if filter.MinTotal != nil {
+		b.where("currency = " + b.arg(filter.MinTotal.Currency) + " AND total >= " + b.arg(*filter.MinTotal))
+	}
+
+	if filter.MaxTotal != nil {
+		b.where("currency = " + b.arg(filter.MaxTotal.Currency) + " AND total <= " + b.arg(*filter.MaxTotal))
+	}
+}
+
+// list retrieves the orders selected by query, with their items and payments
+func (r *PostgresOrderRepository) list(ctx context.Context, query string, args ...interface{}) ([]*entity.Order, error) {
+	orders, err := r.scanOrders(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadOrderDetails(ctx, orders...); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// scanOrders retrieves the orders selected by query, without their items and payments
+func (r *PostgresOrderRepository) scanOrders(ctx context.Context, query string, args ...interface{}) ([]*entity.Order, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list orders: %w", err)
+		return nil, fmt.Errorf("failed to get orders: %w", err)
 	}
 	defer rows.Close()
 
 	var orders []*entity.Order
 
 	for rows.Next() {
-		var order entity.Order
-
-		err := rows.Scan(
-			&order.ID,
-			&order.UserID,
-			&order.Total,
-			&order.Status,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-		)
-
+		order, err := scanOrder(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
 
-		// Get order items
-		items, err := r.getOrderItems(ctx, order.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get order items: %w", err)
-		}
-
-		order.Items = items
-
-		orders = append(orders, &order)
+		orders = append(orders, order)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -324,43 +416,335 @@ func (r *PostgresOrderRepository) List(ctx context.Context) ([]*entity.Order, er
 	return orders, nil
 }
 
-// getOrderItems retrieves all items for an order
-func (r *PostgresOrderRepository) getOrderItems(ctx context.Context, orderID string) ([]*entity.OrderItem, error) {
+// CountByUserSince counts the orders a user placed since the given time
+func (r *PostgresOrderRepository) CountByUserSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM orders WHERE user_id = $1 AND created_at >= $2`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountByClientIPSince counts the orders placed from an IP address since the given time
+func (r *PostgresOrderRepository) CountByClientIPSince(ctx context.Context, clientIP string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM orders WHERE client_ip = $1 AND created_at >= $2`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, clientIP, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetPaidTotals counts a user's paid orders in a currency and sums their totals
+func (r *PostgresOrderRepository) GetPaidTotals(ctx context.Context, userID, currency string) (int, entity.Money, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(total), 0)
+		FROM orders
+		WHERE user_id = $1 AND currency = $2 AND status IN ($3, $4, $5)
+	`
+
+	var count int
+	var sum string
+
+	err := r.db.QueryRowContext(ctx, query, userID, currency,
+		entity.OrderStatusPaid, entity.OrderStatusShipped, entity.OrderStatusCompleted,
+	).Scan(&count, &sum)
+	if err != nil {
+		return 0, entity.Money{}, fmt.Errorf("failed to sum paid orders: %w", err)
+	}
+
+	total, err := entity.ParseMoney(sum, currency)
+	if err != nil {
+		return 0, entity.Money{}, fmt.Errorf("failed to parse paid orders total: %w", err)
+	}
+
+	return count, total, nil
+}
+
+// loadOrderDetails attaches the items and balance payments to the given orders
+// Both are read with one query each, however many orders there are
+func (r *PostgresOrderRepository) loadOrderDetails(ctx context.Context, orders ...*entity.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(orders))
+	byID := make(map[string]*entity.Order, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+		byID[order.ID] = order
+		order.Items, order.Payments = nil, nil
+	}
+
+	if err := r.loadOrderItems(ctx, ids, byID); err != nil {
+		return fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	if err := r.loadOrderPayments(ctx, ids, byID); err != nil {
+		return fmt.Errorf("failed to get order payments: %w", err)
+	}
+
+	return nil
+}
+
+// loadOrderItems attaches the items of the orders with the given IDs
+// Item amounts are stored without a currency and take the currency of the order
+func (r *PostgresOrderRepository) loadOrderItems(ctx context.Context, ids []string, byID map[string]*entity.Order) error {
 	query := `
-		SELECT id, product_id, quantity, price
+		SELECT order_id, id, product_id, variant_id, quantity, price, discount, status,
+			backordered_quantity, expected_at
 		FROM order_items
-		WHERE order_id = $1
-		ORDER BY created_at ASC
+		WHERE order_id = ANY($1)
+		ORDER BY order_id, created_at ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, orderID)
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get order items: %w", err)
+		return fmt.Errorf("failed to get order items: %w", err)
 	}
 	defer rows.Close()
 
-	var items []*entity.OrderItem
-
 	for rows.Next() {
 		var item entity.OrderItem
+		var orderID, price, discount string
+		var expectedAt sql.NullTime
 
 		err := rows.Scan(
+			&orderID,
 			&item.ID,
 			&item.ProductID,
+			&item.VariantID,
 			&item.Quantity,
-			&item.Price,
+			&price,
+			&discount,
+			&item.Status,
+			&item.BackorderedQuantity,
+			&expectedAt,
 		)
 
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan order item: %w", err)
+			return fmt.Errorf("failed to scan order item: %w", err)
+		}
+
+		order, ok := byID[orderID]
+		if !ok {
+			continue
+		}
+
+		if expectedAt.Valid {
+			item.ExpectedAt = &expectedAt.Time
+		}
+
+		if item.Price, err = entity.ParseMoney(price, order.Total.Currency); err != nil {
+			return fmt.Errorf("failed to parse order item price: %w", err)
+		}
+		if item.Discount, err = entity.ParseMoney(discount, order.Total.Currency); err != nil {
+			return fmt.Errorf("failed to parse order item discount: %w", err)
+		}
+
+		order.Items = append(order.Items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating order items: %w", err)
+	}
+
+	return nil
+}
+
+// loadOrderPayments attaches the balances redeemed at checkout for the orders with the given IDs
+func (r *PostgresOrderRepository) loadOrderPayments(ctx context.Context, ids []string, byID map[string]*entity.Order) error {
+	query := `
+		SELECT order_id, account_type, account_id, currency, amount
+		FROM ledger_entries
+		WHERE order_id = ANY($1) AND reason = $2
+		ORDER BY order_id, created_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids), entity.LedgerReasonRedeem)
+	if err != nil {
+		return fmt.Errorf("failed to get order payments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var payment entity.OrderPayment
+		var orderID, currency, amount string
+
+		if err := rows.Scan(&orderID, &payment.Method, &payment.AccountID, &currency, &amount); err != nil {
+			return fmt.Errorf("failed to scan order payment: %w", err)
+		}
+
+		debit, err := entity.ParseMoney(amount, currency)
+		if err != nil {
+			return fmt.Errorf("failed to parse order payment: %w", err)
 		}
 
-		items = append(items, &item)
+		// Redemptions are debits, the payment is the amount taken from the balance
+		payment.Amount = debit.Neg()
+
+		if order, ok := byID[orderID]; ok {
+			order.Payments = append(order.Payments, &payment)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating order items: %w", err)
+		return fmt.Errorf("error iterating order payments: %w", err)
+	}
+
+	return nil
+}
+
+// orderColumns are the columns read by scanOrder, in order
+const orderColumns = `id, user_id, currency, subtotal, line_discount_total, order_discount,
+		points_discount, points_redeemed, tax_total, shipping_total, total,
+		exchange_base_currency, exchange_rate, exchange_rate_updated_at, payment_reference,
+		refunded_total, shipping_address, billing_address, client_ip, status, version, created_at, updated_at`
+
+// scanOrder reads an order selected with orderColumns, without its items and payments
+func scanOrder(row rowScanner) (*entity.Order, error) {
+	var order entity.Order
+	var amounts orderAmounts
+	var shippingAddress, billingAddress []byte
+	var clientIP sql.NullString
+
+	err := row.Scan(
+		&order.ID,
+		&order.UserID,
+		&amounts.currency,
+		&amounts.subtotal,
+		&amounts.lineDiscountTotal,
+		&amounts.orderDiscount,
+		&amounts.pointsDiscount,
+		&order.PointsRedeemed,
+		&amounts.tax,
+		&amounts.shipping,
+		&amounts.total,
+		&amounts.rateBaseCurrency,
+		&amounts.rate,
+		&amounts.rateUpdatedAt,
+		&amounts.paymentReference,
+		&amounts.refundedTotal,
+		&shippingAddress,
+		&billingAddress,
+		&clientIP,
+		&order.Status,
+		&order.Version,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if shippingAddress != nil {
+		order.ShippingAddress = &entity.Address{}
+		if err := order.ShippingAddress.Scan(shippingAddress); err != nil {
+			return nil, fmt.Errorf("failed to parse shipping address: %w", err)
+		}
+	}
+	if billingAddress != nil {
+		order.BillingAddress = &entity.Address{}
+		if err := order.BillingAddress.Scan(billingAddress); err != nil {
+			return nil, fmt.Errorf("failed to parse billing address: %w", err)
+		}
+	}
+	if clientIP.Valid {
+		order.ClientIP = &clientIP.String
+	}
+
+	if err := amounts.apply(&order); err != nil {
+		return nil, fmt.Errorf("failed to parse order amounts: %w", err)
+	}
+
+	return &order, nil
+}
+
+// orderAmounts holds the raw monetary columns of an order row until its currency is known
+type orderAmounts struct {
+	currency          string
+	subtotal          string
+	lineDiscountTotal string
+	orderDiscount     string
+	pointsDiscount    string
+	tax               string
+	shipping          string
+	total             string
+	rateBaseCurrency  sql.NullString
+	rate              sql.NullString
+	rateUpdatedAt     sql.NullTime
+	paymentReference  sql.NullString
+	refundedTotal     string
+}
+
+// apply parses the scanned amounts into the order total and breakdown
+func (a *orderAmounts) apply(order *entity.Order) error {
+	columns := []struct {
+		value string
+		dst   *entity.Money
+	}{
+		{a.subtotal, &order.Breakdown.Subtotal},
+		{a.lineDiscountTotal, &order.Breakdown.LineDiscountTotal},
+		{a.orderDiscount, &order.Breakdown.OrderDiscount},
+		{a.pointsDiscount, &order.Breakdown.PointsDiscount},
+		{a.tax, &order.Breakdown.Tax},
+		{a.shipping, &order.Breakdown.Shipping},
+		{a.total, &order.Total},
+		{a.refundedTotal, &order.RefundedTotal},
+	}
+
+	for _, column := range columns {
+		money, err := entity.ParseMoney(column.value, a.currency)
+		if err != nil {
+			return err
+		}
+		*column.dst = money
+	}
+
+	order.Breakdown.DiscountTotal = order.Breakdown.LineDiscountTotal.Add(order.Breakdown.OrderDiscount).Add(order.Breakdown.PointsDiscount)
+	order.Breakdown.Total = order.Total
+
+	if a.paymentReference.Valid {
+		order.PaymentReference = &a.paymentReference.String
+	}
+
+	if a.rate.Valid {
+		order.ExchangeRate = &entity.ExchangeRate{
+			BaseCurrency:  a.rateBaseCurrency.String,
+			QuoteCurrency: a.currency,
+			Rate:          a.rate.String,
+			UpdatedAt:     a.rateUpdatedAt.Time,
+		}
+	}
+
+	return nil
+}
+
+// exchangeBaseCurrency returns the base currency of the locked rate, or NULL
+func exchangeBaseCurrency(order *entity.Order) interface{} {
+	if order.ExchangeRate == nil {
+		return nil
 	}
+	return order.ExchangeRate.BaseCurrency
+}
 
-	return items, nil
+// exchangeRate returns the locked rate, or NULL
+func exchangeRate(order *entity.Order) interface{} {
+	if order.ExchangeRate == nil {
+		return nil
+	}
+	return order.ExchangeRate.Rate
+}
+
+// exchangeRateUpdatedAt returns when the locked rate was published, or NULL
+func exchangeRateUpdatedAt(order *entity.Order) interface{} {
+	if order.ExchangeRate == nil {
+		return nil
+	}
+	return order.ExchangeRate.UpdatedAt
 }
@@ -0,0 +1,351 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// PostgresPriceRepository implements PriceRepository interface using PostgreSQL
+type PostgresPriceRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresPriceRepository creates a new PostgreSQL price repository
+func NewPostgresPriceRepository(db *sql.DB) *PostgresPriceRepository {
+	return &PostgresPriceRepository{db: db}
+}
+
+// ListChanges retrieves up to limit price changes of a product, newest first
+func (r *PostgresPriceRepository) ListChanges(ctx context.Context, productID string, after *repository.PriceChangeCursor, limit int) ([]*entity.PriceChange, error) {
+	var b queryBuilder
+	b.where("product_id = " + b.arg(productID))
+	if after != nil {
+		b.where(fmt.Sprintf("(created_at, id) < (%s, %s)", b.arg(after.CreatedAt), b.arg(after.ID)))
+	}
+
+	query := `
+		SELECT id, product_id, old_price, old_currency, new_price, new_currency, source, actor_id,
+			scheduled_price_id, created_at
+		FROM price_changes
+		` + b.whereClause() + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + b.arg(limit)
+
+	rows, err := r.db.QueryContext(ctx, query, b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*entity.PriceChange
+
+	for rows.Next() {
+		var change entity.PriceChange
+		var oldPrice, oldCurrency, newPrice, newCurrency string
+		var actorID, scheduledPriceID sql.NullString
+
+		err := rows.Scan(
+			&change.ID,
+			&change.ProductID,
+			&oldPrice,
+			&oldCurrency,
+			&newPrice,
+			&newCurrency,
+			&change.Source,
+			&actorID,
+			&scheduledPriceID,
+			&change.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price change: %w", err)
+		}
+
+		if change.OldPrice, err = entity.ParseMoney(oldPrice, oldCurrency); err != nil {
+			return nil, fmt.Errorf("failed to parse old price: %w", err)
+		}
+		if change.NewPrice, err = entity.ParseMoney(newPrice, newCurrency); err != nil {
+			return nil, fmt.Errorf("failed to parse new price: %w", err)
+		}
+
+		change.ActorID = actorID.String
+		if scheduledPriceID.Valid {
+			change.ScheduledPriceID = &scheduledPriceID.String
+		}
+
+		changes = append(changes, &change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating price changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// insertPriceChange records a price change within a transaction
+func insertPriceChange(ctx context.Context, tx *sql.Tx, change *entity.PriceChange) error {
+	query := `
+		INSERT INTO price_changes (
+			id, product_id, old_price, old_currency, new_price, new_currency, source, actor_id,
+			scheduled_price_id, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	var actorID *string
+	if change.ActorID != "" {
+		actorID = &change.ActorID
+	}
+
+	_, err := tx.ExecContext(ctx, query,
+		change.ID,
+		change.ProductID,
+		change.OldPrice,
+		change.OldPrice.Currency,
+		change.NewPrice,
+		change.NewPrice.Currency,
+		change.Source,
+		actorID,
+		change.ScheduledPriceID,
+		change.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create price change: %w", err)
+	}
+
+	return nil
+}
+
+// CreateSchedule creates a new scheduled price
+func (r *PostgresPriceRepository) CreateSchedule(ctx context.Context, scheduled *entity.ScheduledPrice) error {
+	query := `
+		INSERT INTO scheduled_prices (
+			id, product_id, price, currency, starts_at, ends_at, status, created_by, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		scheduled.ID,
+		scheduled.ProductID,
+		scheduled.Price,
+		scheduled.Price.Currency,
+		scheduled.StartsAt,
+		scheduled.EndsAt,
+		scheduled.Status,
+		scheduled.CreatedBy,
+		scheduled.CreatedAt,
+		scheduled.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create scheduled price: %w", err)
+	}
+
+	return nil
+}
+
+// GetSchedule retrieves a scheduled price by ID
+func (r *PostgresPriceRepository) GetSchedule(ctx context.Context, id string) (*entity.ScheduledPrice, error) {
+	query := `
+		SELECT ` + scheduledPriceColumns + `
+		FROM scheduled_prices
+		WHERE id = $1
+	`
+
+	scheduled, err := scanScheduledPrice(r.db.QueryRowContext(ctx, query, id))
+
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrScheduledPriceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled price: %w", err)
+	}
+
+	return scheduled, nil
+}
+
+// ListSchedules retrieves the scheduled prices of a product, by start
+func (r *PostgresPriceRepository) ListSchedules(ctx context.Context, productID string) ([]*entity.ScheduledPrice, error) {
+	query := `
+		SELECT ` + scheduledPriceColumns + `
+		FROM scheduled_prices
+		WHERE product_id = $1
+		ORDER BY starts_at, id
+	`
+
+	return r.querySchedules(ctx, query, productID)
+}
+
+// ListDue retrieves up to limit scheduled prices due to start or end at or before now, earliest first
+func (r *PostgresPriceRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.ScheduledPrice, error) {
+	query := `
+		SELECT ` + scheduledPriceColumns + `
+		FROM scheduled_prices
+		WHERE (status = $1 AND starts_at <= $3) OR (status = $2 AND ends_at <= $3)
+		ORDER BY CASE WHEN status = $1 THEN starts_at ELSE ends_at END, id
+		LIMIT $4
+	`
+
+	return r.querySchedules(ctx, query, entity.ScheduledPriceStatusPending, entity.ScheduledPriceStatusActive, now, limit)
+}
+
+// querySchedules runs a query selecting scheduledPriceColumns
+func (r *PostgresPriceRepository) querySchedules(ctx context.Context, query string, args ...interface{}) ([]*entity.ScheduledPrice, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled prices: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*entity.ScheduledPrice
+
+	for rows.Next() {
+		scheduled, err := scanScheduledPrice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled price: %w", err)
+		}
+
+		schedules = append(schedules, scheduled)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scheduled prices: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// Apply saves a scheduled price that was in status from, and the price change it makes if any
+func (r *PostgresPriceRepository) Apply(ctx context.Context, scheduled *entity.ScheduledPrice, from entity.ScheduledPriceStatus, change *entity.PriceChange) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE scheduled_prices
+		SET status = $1, previous_price = $2, previous_currency = $3, updated_at = $4
+		WHERE id = $5 AND status = $6
+	`
+
+	var previousCurrency *string
+	if scheduled.PreviousPrice != nil {
+		previousCurrency = &scheduled.PreviousPrice.Currency
+	}
+
+	result, err := tx.ExecContext(ctx, query,
+		scheduled.Status,
+		scheduled.PreviousPrice,
+		previousCurrency,
+		scheduled.UpdatedAt,
+		scheduled.ID,
+		from,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update scheduled price: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if change != nil {
+		query := `
+			UPDATE products
+			SET price = $1, currency = $2, updated_at = $3, version = version + 1
+			WHERE id = $4 AND price = $5 AND currency = $6
+		`
+
+		result, err := tx.ExecContext(ctx, query,
+			change.NewPrice,
+			change.NewPrice.Currency,
+			change.CreatedAt,
+			change.ProductID,
+			change.OldPrice,
+			change.OldPrice.Currency,
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to update product price: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return false, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return false, nil
+		}
+
+		if err := insertPriceChange(ctx, tx, change); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// scheduledPriceColumns are the columns read by scanScheduledPrice, in order
+const scheduledPriceColumns = `id, product_id, price, currency, starts_at, ends_at, status,
+		previous_price, previous_currency, created_by, created_at, updated_at`
+
+// scanScheduledPrice reads a scheduled price selected with scheduledPriceColumns
+func scanScheduledPrice(row rowScanner) (*entity.ScheduledPrice, error) {
+	var scheduled entity.ScheduledPrice
+	var price, currency string
+	var previousPrice, previousCurrency sql.NullString
+	var endsAt sql.NullTime
+
+	err := row.Scan(
+		&scheduled.ID,
+		&scheduled.ProductID,
+		&price,
+		&currency,
+		&scheduled.StartsAt,
+		&endsAt,
+		&scheduled.Status,
+		&previousPrice,
+		&previousCurrency,
+		&scheduled.CreatedBy,
+		&scheduled.CreatedAt,
+		&scheduled.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheduled.Price, err = entity.ParseMoney(price, currency); err != nil {
+		return nil, fmt.Errorf("failed to parse scheduled price: %w", err)
+	}
+
+	if endsAt.Valid {
+		scheduled.EndsAt = &endsAt.Time
+	}
+
+	if previousPrice.Valid {
+		previous, err := entity.ParseMoney(previousPrice.String, previousCurrency.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse previous price: %w", err)
+		}
+		scheduled.PreviousPrice = &previous
+	}
+
+	return &scheduled, nil
+}
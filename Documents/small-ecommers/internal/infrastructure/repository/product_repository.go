@@ -3,11 +3,17 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
 	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
 )
 
 // PostgresProductRepository implements ProductRepository interface using PostgreSQL
@@ -22,17 +28,60 @@ func NewPostgresProductRepository(db *sql.DB) *PostgresProductRepository {
 
 // Create creates a new product
 func (r *PostgresProductRepository) Create(ctx context.Context, product *entity.Product) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertProduct(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if err := r.savePrices(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if err := r.saveCategories(ctx, tx, product); err != nil {
+		return err
+	}
+
+	for _, variant := range product.Variants {
+		if err := insertVariant(ctx, tx, variant); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertProduct inserts the product row of a product within a transaction
+func insertProduct(ctx context.Context, tx *sql.Tx, product *entity.Product) error {
 	query := `
-		INSERT INTO products (id, name, description, price, stock, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO products (
+			id, name, description, price, currency, options, attributes, stock_policy, backorder_limit,
+			available_at, status, archived_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := tx.ExecContext(ctx, query,
 		product.ID,
 		product.Name,
 		product.Description,
 		product.Price,
-		product.Stock,
+		product.Price.Currency,
+		product.Options,
+		product.Attributes,
+		product.StockPolicy,
+		product.BackorderLimit,
+		product.AvailableAt,
+		product.Status,
+		product.ArchivedAt,
 		product.CreatedAt,
 		product.UpdatedAt,
 	)
@@ -47,42 +96,31 @@ func (r *PostgresProductRepository) Create(ctx context.Context, product *entity.
 // GetByID retrieves a product by ID
 func (r *PostgresProductRepository) GetByID(ctx context.Context, id string) (*entity.Product, error) {
 	query := `
-		SELECT id, name, description, price, stock, created_at, updated_at
+		SELECT ` + productColumns + `
 		FROM products
 		WHERE id = $1
 	`
 
-	var product entity.Product
-	var description sql.NullString
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&product.ID,
-		&product.Name,
-		&description,
-		&product.Price,
-		&product.Stock,
-		&product.CreatedAt,
-		&product.UpdatedAt,
-	)
+	product, err := scanProduct(r.db.QueryRowContext(ctx, query, id))
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("product not found")
+		return nil, entity.ErrProductNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 
-	if description.Valid {
-		product.Description = &description.String
+	if err := r.loadDetails(ctx, []*entity.Product{product}); err != nil {
+		return nil, err
 	}
 
-	return &product, nil
+	return product, nil
 }
 
 // List retrieves all products
 func (r *PostgresProductRepository) List(ctx context.Context) ([]*entity.Product, error) {
 	query := `
-		SELECT id, name, description, price, stock, created_at, updated_at
+		SELECT ` + productColumns + `
 		FROM products
 		ORDER BY created_at DESC
 	`
@@ -96,79 +134,482 @@ func (r *PostgresProductRepository) List(ctx context.Context) ([]*entity.Product
 	var products []*entity.Product
 
 	for rows.Next() {
-		var product entity.Product
-		var description sql.NullString
-
-		err := rows.Scan(
-			&product.ID,
-			&product.Name,
-			&description,
-			&product.Price,
-			&product.Stock,
-			&product.CreatedAt,
-			&product.UpdatedAt,
-		)
-
+		product, err := scanProduct(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
 
-		if description.Valid {
-			product.Description = &description.String
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating products: %w", err)
+	}
+
+	if err := r.loadDetails(ctx, products); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// Search retrieves a page of the products matching a filter, in the requested order
+func (r *PostgresProductRepository) Search(ctx context.Context, search *repository.ProductSearch) ([]*entity.Product, error) {
+	var b queryBuilder
+	applyProductFilter(&b, &search.Filter)
+
+	direction, comparison := "ASC", ">"
+	if search.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	var column string
+	var value interface{}
+
+	switch search.Sort {
+	case repository.ProductSortPrice:
+		column = "price"
+		if search.After != nil {
+			value = search.After.Price
+		}
+	case repository.ProductSortName:
+		column = "name"
+		if search.After != nil {
+			value = search.After.Name
+		}
+	default:
+		column = "created_at"
+		if search.After != nil {
+			value = search.After.CreatedAt
+		}
+	}
+
+	if search.After != nil {
+		b.where(fmt.Sprintf("(%s, id) %s (%s, %s)", column, comparison, b.arg(value), b.arg(search.After.ID)))
+	}
+
+	query := `
+		SELECT ` + productColumns + `
+		FROM products
+		` + b.whereClause() + `
+		ORDER BY ` + column + ` ` + direction + `, id ` + direction + `
+		LIMIT ` + b.arg(search.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []*entity.Product
+
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
 
-		products = append(products, &product)
+		products = append(products, product)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating products: %w", err)
 	}
 
+	if err := r.loadDetails(ctx, products); err != nil {
+		return nil, err
+	}
+
 	return products, nil
 }
 
-// Update updates an existing product
-func (r *PostgresProductRepository) Update(ctx context.Context, product *entity.Product) error {
+// Count counts the products matching a filter
+func (r *PostgresProductRepository) Count(ctx context.Context, filter *repository.ProductFilter) (int, error) {
+	var b queryBuilder
+	applyProductFilter(&b, filter)
+
+	query := `SELECT COUNT(*) FROM products ` + b.whereClause()
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, b.args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	return count, nil
+}
+
+// applyProductFilter adds the conditions of a product filter to a query
+func applyProductFilter(b *queryBuilder, filter *repository.ProductFilter) {
+	if filter.MinPrice != nil {
+		b.where("currency = " + b.arg(filter.MinPrice.Currency) + " AND price >= " + b.arg(*filter.MinPrice))
+	}
+
+	if filter.MaxPrice != nil {
+		b.where("currency = " + b.arg(filter.MaxPrice.Currency) + " AND price <= " + b.arg(*filter.MaxPrice))
+	}
+
+	if filter.InStock {
+		b.where("EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.stock > 0)")
+	}
+
+	if len(filter.CategoryIDs) > 0 {
+		b.where("EXISTS (SELECT 1 FROM product_categories pc WHERE pc.product_id = products.id AND pc.category_id = ANY(" + b.arg(pq.Array(filter.CategoryIDs)) + "))")
+	}
+
+	if filter.NamePrefix != "" {
+		b.where("lower(name) LIKE " + b.arg(likePrefix(strings.ToLower(filter.NamePrefix))))
+	}
+
+	if filter.Status != "" {
+		b.where("status = " + b.arg(filter.Status))
+	}
+
+	// Containment is answered by the GIN index on attributes
+	if len(filter.Attributes) > 0 {
+		b.where("attributes @> " + b.arg(filter.Attributes))
+	}
+}
+
+// Update updates an existing product and records its price change
+// Variants are left untouched, their stock only changes through UpdateStock and orders
+func (r *PostgresProductRepository) Update(ctx context.Context, product *entity.Product, priceChange *entity.PriceChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateProduct(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if priceChange != nil {
+		if err := insertPriceChange(ctx, tx, priceChange); err != nil {
+			return err
+		}
+	}
+
+	if err := r.savePrices(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if err := r.saveCategories(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// updateProduct updates the product row of a product within a transaction, except for its status
+// ErrVersionConflict is returned if the product was updated since it was read
+func updateProduct(ctx context.Context, tx *sql.Tx, product *entity.Product) error {
 	query := `
 		UPDATE products
-		SET name = $1, description = $2, price = $3, stock = $4, updated_at = $5
-		WHERE id = $6
+		SET name = $1, description = $2, price = $3, currency = $4, options = $5, attributes = $6,
+			stock_policy = $7, backorder_limit = $8, available_at = $9, updated_at = $10, version = version + 1
+		WHERE id = $11 AND version = $12
 	`
 
 	product.UpdatedAt = time.Now()
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := tx.ExecContext(ctx, query,
 		product.Name,
 		product.Description,
 		product.Price,
-		product.Stock,
+		product.Price.Currency,
+		product.Options,
+		product.Attributes,
+		product.StockPolicy,
+		product.BackorderLimit,
+		product.AvailableAt,
 		product.UpdatedAt,
 		product.ID,
+		product.Version,
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if err := checkVersionedUpdate(ctx, tx, result, "products", product.ID, entity.ErrProductNotFound); err != nil {
+		return err
+	}
+
+	product.Version++
+
+	return nil
+}
+
+// UpdateStatus saves the status of a product and when it was archived
+func (r *PostgresProductRepository) UpdateStatus(ctx context.Context, product *entity.Product) error {
+	return updateProductStatus(ctx, r.db, product)
+}
+
+// updateProductStatus saves the status of a product, directly or within a transaction
+// ErrVersionConflict is returned if the product was updated since it was read
+func updateProductStatus(ctx context.Context, db queryExecer, product *entity.Product) error {
+	query := `
+		UPDATE products
+		SET status = $1, archived_at = $2, updated_at = $3, version = version + 1
+		WHERE id = $4 AND version = $5
+	`
+
+	result, err := db.ExecContext(ctx, query, product.Status, product.ArchivedAt, product.UpdatedAt, product.ID, product.Version)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to update product status: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+	if err := checkVersionedUpdate(ctx, db, result, "products", product.ID, entity.ErrProductNotFound); err != nil {
+		return err
 	}
 
+	product.Version++
+
 	return nil
 }
 
-// Delete deletes a product by ID
-func (r *PostgresProductRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM products WHERE id = $1`
+// UpdateStock sets the stock of a variant
+// Waiting backorders are filled from the new stock first, oldest order first
+func (r *PostgresProductRepository) UpdateStock(ctx context.Context, variantID string, stock int) ([]*entity.Backorder, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var backordered int
+	err = tx.QueryRowContext(ctx, `SELECT backordered FROM product_variants WHERE id = $1 FOR UPDATE`, variantID).Scan(&backordered)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock variant: %w", err)
+	}
+
+	filled, err := r.fillBackorders(ctx, tx, variantID, stock, backordered)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return filled, nil
+}
+
+// ReleaseStock returns the units of a cancelled order item to its variant
+// allocated units go back into stock, where waiting backorders get them first
+func (r *PostgresProductRepository) ReleaseStock(ctx context.Context, variantID string, allocated, backordered int) ([]*entity.Backorder, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var stock, waiting int
+	err = tx.QueryRowContext(ctx, `SELECT stock, backordered FROM product_variants WHERE id = $1 FOR UPDATE`, variantID).Scan(&stock, &waiting)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock variant: %w", err)
+	}
+
+	filled, err := r.fillBackorders(ctx, tx, variantID, stock+allocated, max(waiting-backordered, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return filled, nil
+}
+
+// fillBackorders allocates stock to the backorders of a locked variant and saves the result
+// backordered is the number of units owed before allocation
+func (r *PostgresProductRepository) fillBackorders(ctx context.Context, tx *sql.Tx, variantID string, stock, backordered int) ([]*entity.Backorder, error) {
+	var queue []*entity.Backorder
+
+	if stock > 0 && backordered > 0 {
+		query := `
+			SELECT oi.order_id, oi.id, oi.backordered_quantity
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			WHERE oi.variant_id = $1 AND oi.backordered_quantity > 0 AND o.status <> $2
+			ORDER BY o.created_at ASC, oi.created_at ASC
+			FOR UPDATE OF oi
+		`
+
+		rows, err := tx.QueryContext(ctx, query, variantID, entity.OrderStatusCancelled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get backorders: %w", err)
+		}
+
+		for rows.Next() {
+			var backorder entity.Backorder
+			if err := rows.Scan(&backorder.OrderID, &backorder.OrderItemID, &backorder.Quantity); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan backorder: %w", err)
+			}
+			queue = append(queue, &backorder)
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating backorders: %w", err)
+		}
+	}
+
+	stock = entity.AllocateBackorders(stock, queue)
+
+	var filled []*entity.Backorder
+	for _, backorder := range queue {
+		if backorder.Allocated == 0 {
+			continue
+		}
+
+		query := `
+			UPDATE order_items
+			SET backordered_quantity = $1,
+				status = CASE WHEN $1 = 0 THEN $2 ELSE status END
+			WHERE id = $3
+		`
+
+		if _, err := tx.ExecContext(ctx, query, backorder.Quantity, entity.OrderItemStatusAllocated, backorder.OrderItemID); err != nil {
+			return nil, fmt.Errorf("failed to allocate backorder: %w", err)
+		}
+
+		backordered -= backorder.Allocated
+		filled = append(filled, backorder)
+	}
+
+	query := `
+		UPDATE product_variants
+		SET stock = $1, backordered = $2, updated_at = $3, version = version + 1
+		WHERE id = $4
+	`
+
+	if _, err := tx.ExecContext(ctx, query, stock, max(backordered, 0), time.Now(), variantID); err != nil {
+		return nil, fmt.Errorf("failed to update variant stock: %w", err)
+	}
+
+	return filled, nil
+}
+
+// CreateVariant adds a variant to a product
+func (r *PostgresProductRepository) CreateVariant(ctx context.Context, variant *entity.Variant) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertVariant(ctx, tx, variant); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertVariant inserts a variant within a transaction
+func insertVariant(ctx context.Context, tx *sql.Tx, variant *entity.Variant) error {
+	query := `
+		INSERT INTO product_variants (
+			id, product_id, sku, options, price, currency, stock, backordered, is_default,
+			created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := tx.ExecContext(ctx, query,
+		variant.ID,
+		variant.ProductID,
+		variant.SKU,
+		variant.Options,
+		variant.Price,
+		variantCurrency(variant),
+		variant.Stock,
+		variant.Backordered,
+		variant.IsDefault,
+		variant.CreatedAt,
+		variant.UpdatedAt,
+	)
+
+	if isUniqueViolation(err) {
+		return entity.ErrSKUExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create variant: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateVariant updates an existing variant, except for its stock, unless its version moved on
+func (r *PostgresProductRepository) UpdateVariant(ctx context.Context, variant *entity.Variant) error {
+	return updateVariant(ctx, r.db, variant)
+}
+
+// updateVariant updates a variant except for its stock, directly or within a transaction
+func updateVariant(ctx context.Context, db queryExecer, variant *entity.Variant) error {
+	query := `
+		UPDATE product_variants
+		SET sku = $1, options = $2, price = $3, currency = $4, updated_at = $5, version = version + 1
+		WHERE id = $6 AND version = $7
+	`
+
+	variant.UpdatedAt = time.Now()
+
+	result, err := db.ExecContext(ctx, query,
+		variant.SKU,
+		variant.Options,
+		variant.Price,
+		variantCurrency(variant),
+		variant.UpdatedAt,
+		variant.ID,
+		variant.Version,
+	)
+
+	if isUniqueViolation(err) {
+		return entity.ErrSKUExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update variant: %w", err)
+	}
+
+	if err := checkVersionedUpdate(ctx, db, result, "product_variants", variant.ID, entity.ErrVariantNotFound); err != nil {
+		return err
+	}
+
+	variant.Version++
+
+	return nil
+}
+
+// DeleteVariant deletes a variant by ID, unless an order, a cart or a subscription references it
+func (r *PostgresProductRepository) DeleteVariant(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM product_variants v
+		WHERE v.id = $1
+			AND NOT EXISTS (SELECT 1 FROM order_items WHERE variant_id = v.id)
+			AND NOT EXISTS (SELECT 1 FROM cart_items WHERE variant_id = v.id)
+			AND NOT EXISTS (SELECT 1 FROM subscription_items WHERE variant_id = v.id)
+	`
 
 	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("failed to delete product: %w", err)
+		return fmt.Errorf("failed to delete variant: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -177,23 +618,207 @@ func (r *PostgresProductRepository) Delete(ctx context.Context, id string) error
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM product_variants WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check variant: %w", err)
+		}
+		if exists {
+			return entity.ErrVariantInUse
+		}
+		return entity.ErrVariantNotFound
 	}
 
 	return nil
 }
 
-// UpdateStock updates the stock of a product
-func (r *PostgresProductRepository) UpdateStock(ctx context.Context, id string, stock int) error {
+// GetVariantsBySKUs retrieves the variants with the given SKUs, unknown SKUs are ignored
+func (r *PostgresProductRepository) GetVariantsBySKUs(ctx context.Context, skus []string) ([]*entity.Variant, error) {
+	if len(skus) == 0 {
+		return []*entity.Variant{}, nil
+	}
+
 	query := `
-		UPDATE products
-		SET stock = $1, updated_at = $2
-		WHERE id = $3
+		SELECT ` + variantColumns + `
+		FROM product_variants
+		WHERE sku = ANY($1)
 	`
 
-	result, err := r.db.ExecContext(ctx, query, stock, time.Now(), id)
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(skus))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variants by SKUs: %w", err)
+	}
+	defer rows.Close()
+
+	var variants []*entity.Variant
+
+	for rows.Next() {
+		variant, err := scanVariant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product variant: %w", err)
+		}
+
+		variants = append(variants, variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating product variants: %w", err)
+	}
+
+	return variants, nil
+}
+
+// Import creates or updates products and their imported variants in one transaction
+// Stock is set like UpdateStock, waiting backorders are filled first
+func (r *PostgresProductRepository) Import(ctx context.Context, imports []*repository.ProductImport) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to update product stock: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, imported := range imports {
+		if err := r.importProduct(ctx, tx, imported); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// importProduct writes an imported product within a transaction
+func (r *PostgresProductRepository) importProduct(ctx context.Context, tx *sql.Tx, imported *repository.ProductImport) error {
+	product := imported.Product
+
+	if imported.New {
+		if err := insertProduct(ctx, tx, product); err != nil {
+			return err
+		}
+	} else {
+		if err := updateProduct(ctx, tx, product); err != nil {
+			return err
+		}
+		if err := updateProductStatus(ctx, tx, product); err != nil {
+			return err
+		}
+		if imported.PriceChange != nil {
+			if err := insertPriceChange(ctx, tx, imported.PriceChange); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := r.savePrices(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if err := r.saveCategories(ctx, tx, product); err != nil {
+		return err
+	}
+
+	for _, variant := range imported.Variants {
+		err := updateVariant(ctx, tx, variant)
+		if errors.Is(err, entity.ErrVariantNotFound) {
+			err = insertVariant(ctx, tx, variant)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Lock the variants in a fixed order, so concurrent imports cannot deadlock
+	for _, variantID := range slices.Sorted(maps.Keys(imported.Stock)) {
+		var backordered int
+		err := tx.QueryRowContext(ctx, `SELECT backordered FROM product_variants WHERE id = $1 FOR UPDATE`, variantID).Scan(&backordered)
+		if err == sql.ErrNoRows {
+			return entity.ErrVariantNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock variant: %w", err)
+		}
+
+		if _, err := r.fillBackorders(ctx, tx, variantID, imported.Stock[variantID], backordered); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// variantCurrency returns the currency of a variant price override, nil without one
+func variantCurrency(variant *entity.Variant) *string {
+	if variant.Price == nil {
+		return nil
+	}
+	return &variant.Price.Currency
+}
+
+// CreateImage adds an image to a product
+func (r *PostgresProductRepository) CreateImage(ctx context.Context, image *entity.ProductImage) error {
+	query := `
+		INSERT INTO product_images (
+			id, product_id, position, alt_text, content_type, width, height, size,
+			storage_key, url, thumbnail_key, thumbnail_url, thumbnail_width, thumbnail_height,
+			created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		image.ID,
+		image.ProductID,
+		image.Position,
+		image.AltText,
+		image.ContentType,
+		image.Width,
+		image.Height,
+		image.Size,
+		image.StorageKey,
+		image.URL,
+		image.ThumbnailKey,
+		image.ThumbnailURL,
+		image.ThumbnailWidth,
+		image.ThumbnailHeight,
+		image.CreatedAt,
+		image.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create product image: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateImage updates the alt text of an image, unless its version moved on
+func (r *PostgresProductRepository) UpdateImage(ctx context.Context, image *entity.ProductImage) error {
+	image.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE product_images SET alt_text = $1, updated_at = $2, version = version + 1 WHERE id = $3 AND version = $4`,
+		image.AltText, image.UpdatedAt, image.ID, image.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update product image: %w", err)
+	}
+
+	if err := checkVersionedUpdate(ctx, r.db, result, "product_images", image.ID, entity.ErrImageNotFound); err != nil {
+		return err
+	}
+
+	image.Version++
+
+	return nil
+}
+
+// DeleteImage deletes an image by ID
+func (r *PostgresProductRepository) DeleteImage(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM product_images WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete product image: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -202,7 +827,23 @@ func (r *PostgresProductRepository) UpdateStock(ctx context.Context, id string,
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+		return entity.ErrImageNotFound
+	}
+
+	return nil
+}
+
+// SetImagePositions numbers the images of a product in the given order, in a single statement
+func (r *PostgresProductRepository) SetImagePositions(ctx context.Context, productID string, imageIDs []string) error {
+	query := `
+		UPDATE product_images i
+		SET position = o.position - 1, updated_at = $3, version = version + 1
+		FROM unnest($2::text[]) WITH ORDINALITY AS o(id, position)
+		WHERE i.id = o.id AND i.product_id = $1 AND i.position <> o.position - 1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, productID, pq.Array(imageIDs), time.Now()); err != nil {
+		return fmt.Errorf("failed to set product image positions: %w", err)
 	}
 
 	return nil
@@ -222,11 +863,11 @@ func (r *PostgresProductRepository) GetByIDs(ctx context.Context, ids []string)
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, name, description, price, stock, created_at, updated_at
+		SELECT %s
 		FROM products
 		WHERE id IN (%s)
 		ORDER BY created_at DESC
-	`, strings.Join(placeholders, ", "))
+	`, productColumns, strings.Join(placeholders, ", "))
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -237,33 +878,393 @@ func (r *PostgresProductRepository) GetByIDs(ctx context.Context, ids []string)
 	var products []*entity.Product
 
 	for rows.Next() {
-		var product entity.Product
-		var description sql.NullString
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating products: %w", err)
+	}
+
+	if err := r.loadDetails(ctx, products); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// savePrices replaces the explicit per-currency prices of a product
+func (r *PostgresProductRepository) savePrices(ctx context.Context, tx *sql.Tx, product *entity.Product) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_prices WHERE product_id = $1`, product.ID); err != nil {
+		return fmt.Errorf("failed to delete product prices: %w", err)
+	}
+
+	for _, price := range product.Prices {
+		query := `
+			INSERT INTO product_prices (product_id, currency, amount)
+			VALUES ($1, $2, $3)
+		`
+
+		if _, err := tx.ExecContext(ctx, query, product.ID, price.Currency, price); err != nil {
+			return fmt.Errorf("failed to create product price: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// saveCategories replaces the category assignments of a product
+func (r *PostgresProductRepository) saveCategories(ctx context.Context, tx *sql.Tx, product *entity.Product) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_categories WHERE product_id = $1`, product.ID); err != nil {
+		return fmt.Errorf("failed to delete product categories: %w", err)
+	}
+
+	if len(product.CategoryIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO product_categories (product_id, category_id)
+		SELECT $1, unnest($2::varchar[])
+	`
+
+	if _, err := tx.ExecContext(ctx, query, product.ID, pq.Array(product.CategoryIDs)); err != nil {
+		return fmt.Errorf("failed to create product categories: %w", err)
+	}
+
+	return nil
+}
+
+// loadDetails attaches the per-currency prices, categories and variants to the given products
+func (r *PostgresProductRepository) loadDetails(ctx context.Context, products []*entity.Product) error {
+	if err := r.loadPrices(ctx, products); err != nil {
+		return err
+	}
+	if err := r.loadCategories(ctx, products); err != nil {
+		return err
+	}
+	if err := r.loadImages(ctx, products); err != nil {
+		return err
+	}
+	return r.loadVariants(ctx, products)
+}
+
+// loadImages attaches the images to the given products, in display order
+func (r *PostgresProductRepository) loadImages(ctx context.Context, products []*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(products))
+	byID := make(map[string]*entity.Product, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+		byID[product.ID] = product
+	}
+
+	query := `
+		SELECT id, product_id, position, alt_text, content_type, width, height, size,
+			storage_key, url, thumbnail_key, thumbnail_url, thumbnail_width, thumbnail_height,
+			version, created_at, updated_at
+		FROM product_images
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, position, created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get product images: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var image entity.ProductImage
 
 		err := rows.Scan(
-			&product.ID,
-			&product.Name,
-			&description,
-			&product.Price,
-			&product.Stock,
-			&product.CreatedAt,
-			&product.UpdatedAt,
+			&image.ID,
+			&image.ProductID,
+			&image.Position,
+			&image.AltText,
+			&image.ContentType,
+			&image.Width,
+			&image.Height,
+			&image.Size,
+			&image.StorageKey,
+			&image.URL,
+			&image.ThumbnailKey,
+			&image.ThumbnailURL,
+			&image.ThumbnailWidth,
+			&image.ThumbnailHeight,
+			&image.Version,
+			&image.CreatedAt,
+			&image.UpdatedAt,
 		)
+		if err != nil {
+			return fmt.Errorf("failed to scan product image: %w", err)
+		}
+
+		if product, ok := byID[image.ProductID]; ok {
+			product.Images = append(product.Images, &image)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating product images: %w", err)
+	}
 
+	return nil
+}
+
+// loadVariants attaches the variants to the given products, default variant first,
+// and sums their stock into the product
+func (r *PostgresProductRepository) loadVariants(ctx context.Context, products []*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(products))
+	byID := make(map[string]*entity.Product, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+		byID[product.ID] = product
+	}
+
+	query := `
+		SELECT ` + variantColumns + `
+		FROM product_variants
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, is_default DESC, created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get product variants: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		variant, err := scanVariant(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan product: %w", err)
+			return fmt.Errorf("failed to scan product variant: %w", err)
 		}
 
-		if description.Valid {
-			product.Description = &description.String
+		if product, ok := byID[variant.ProductID]; ok {
+			product.Variants = append(product.Variants, variant)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating product variants: %w", err)
+	}
+
+	for _, product := range products {
+		product.SumVariantStock()
+	}
+
+	return nil
+}
+
+// loadCategories attaches the category assignments to the given products
+func (r *PostgresProductRepository) loadCategories(ctx context.Context, products []*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(products))
+	byID := make(map[string]*entity.Product, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+		byID[product.ID] = product
+	}
+
+	query := `
+		SELECT product_id, category_id
+		FROM product_categories
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, category_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get product categories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID, categoryID string
+
+		if err := rows.Scan(&productID, &categoryID); err != nil {
+			return fmt.Errorf("failed to scan product category: %w", err)
 		}
 
-		products = append(products, &product)
+		if product, ok := byID[productID]; ok {
+			product.CategoryIDs = append(product.CategoryIDs, categoryID)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating products: %w", err)
+		return fmt.Errorf("error iterating product categories: %w", err)
 	}
 
-	return products, nil
+	return nil
+}
+
+// loadPrices attaches the explicit per-currency prices to the given products
+func (r *PostgresProductRepository) loadPrices(ctx context.Context, products []*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(products))
+	byID := make(map[string]*entity.Product, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+		byID[product.ID] = product
+	}
+
+	query := `
+		SELECT product_id, currency, amount
+		FROM product_prices
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, currency
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get product prices: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID, currency, amount string
+
+		if err := rows.Scan(&productID, &currency, &amount); err != nil {
+			return fmt.Errorf("failed to scan product price: %w", err)
+		}
+
+		price, err := entity.ParseMoney(amount, currency)
+		if err != nil {
+			return fmt.Errorf("failed to parse product price: %w", err)
+		}
+
+		if product, ok := byID[productID]; ok {
+			product.Prices = append(product.Prices, price)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating product prices: %w", err)
+	}
+
+	return nil
+}
+
+// productColumns are the columns read by scanProduct, in order
+// Stock is summed from the variants by loadVariants
+const productColumns = `id, name, description, price, currency, options, attributes, stock_policy,
+		backorder_limit, available_at, status, archived_at, version, created_at, updated_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// scanProduct reads a product selected with productColumns
+func scanProduct(row rowScanner) (*entity.Product, error) {
+	var product entity.Product
+	var description sql.NullString
+	var price, currency string
+	var backorderLimit sql.NullInt64
+	var availableAt, archivedAt sql.NullTime
+
+	err := row.Scan(
+		&product.ID,
+		&product.Name,
+		&description,
+		&price,
+		&currency,
+		&product.Options,
+		&product.Attributes,
+		&product.StockPolicy,
+		&backorderLimit,
+		&availableAt,
+		&product.Status,
+		&archivedAt,
+		&product.Version,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if archivedAt.Valid {
+		product.ArchivedAt = &archivedAt.Time
+	}
+
+	if description.Valid {
+		product.Description = &description.String
+	}
+
+	if backorderLimit.Valid {
+		limit := int(backorderLimit.Int64)
+		product.BackorderLimit = &limit
+	}
+
+	if availableAt.Valid {
+		product.AvailableAt = &availableAt.Time
+	}
+
+	if product.Price, err = entity.ParseMoney(price, currency); err != nil {
+		return nil, fmt.Errorf("failed to parse product price: %w", err)
+	}
+
+	return &product, nil
+}
+
+// variantColumns are the columns read by scanVariant, in order
+const variantColumns = `id, product_id, sku, options, price, currency, stock, backordered, is_default,
+			version, created_at, updated_at`
+
+// scanVariant reads a variant selected with variantColumns
+func scanVariant(row rowScanner) (*entity.Variant, error) {
+	var variant entity.Variant
+	var price, currency sql.NullString
+
+	err := row.Scan(
+		&variant.ID,
+		&variant.ProductID,
+		&variant.SKU,
+		&variant.Options,
+		&price,
+		&currency,
+		&variant.Stock,
+		&variant.Backordered,
+		&variant.IsDefault,
+		&variant.Version,
+		&variant.CreatedAt,
+		&variant.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if price.Valid {
+		override, err := entity.ParseMoney(price.String, currency.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse variant price: %w", err)
+		}
+		variant.Price = &override
+	}
+
+	return &variant, nil
 }
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queryBuilder collects the conditions of a WHERE clause and their numbered parameters
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg adds a parameter and returns its placeholder
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// where adds a condition, written with placeholders returned by arg
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+// whereClause returns the WHERE clause joining every condition, or nothing without conditions
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// likePrefix returns a LIKE pattern matching values that start with prefix
+func likePrefix(prefix string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+	return escaped + "%"
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// PostgresSubscriptionRepository implements SubscriptionRepository interface using PostgreSQL
+type PostgresSubscriptionRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresSubscriptionRepository creates a new PostgreSQL subscription repository
+func NewPostgresSubscriptionRepository(db *sql.DB) *PostgresSubscriptionRepository {
+	return &PostgresSubscriptionRepository{db: db}
+}
+
+// subscriptionColumns are the columns read by scanSubscription, in order
+const subscriptionColumns = `id, user_id, currency, interval_weeks, status, next_run_at, last_run_at,
+		last_order_id, failure_count, last_error, created_at, updated_at`
+
+// Create creates a new subscription
+func (r *PostgresSubscriptionRepository) Create(ctx context.Context, subscription *entity.Subscription) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO subscriptions (` + subscriptionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		subscription.ID,
+		subscription.UserID,
+		subscription.Currency,
+		subscription.IntervalWeeks,
+		subscription.Status,
+		subscription.NextRunAt,
+		subscription.LastRunAt,
+		subscription.LastOrderID,
+		subscription.FailureCount,
+		subscription.LastError,
+		subscription.CreatedAt,
+		subscription.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	for _, item := range subscription.Items {
+		itemQuery := `
+			INSERT INTO subscription_items (id, subscription_id, product_id, variant_id, quantity)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+
+		_, err = tx.ExecContext(ctx, itemQuery,
+			item.ID,
+			subscription.ID,
+			item.ProductID,
+			item.VariantID,
+			item.Quantity,
+		)
+
+		if err != nil {
+			return fmt.Errorf("failed to create subscription item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a subscription by ID
+func (r *PostgresSubscriptionRepository) GetByID(ctx context.Context, id string) (*entity.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE id = $1
+	`
+
+	subscription, err := scanSubscription(r.db.QueryRowContext(ctx, query, id))
+
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	if err := r.loadItems(ctx, []*entity.Subscription{subscription}); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// GetByUserID retrieves all subscriptions for a user
+func (r *PostgresSubscriptionRepository) GetByUserID(ctx context.Context, userID string) ([]*entity.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	return r.list(ctx, query, userID)
+}
+
+// ListDue retrieves up to limit active subscriptions whose next run is at or before now
+func (r *PostgresSubscriptionRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE status = $1 AND next_run_at <= $2
+		ORDER BY next_run_at ASC
+		LIMIT $3
+	`
+
+	return r.list(ctx, query, entity.SubscriptionStatusActive, now, limit)
+}
+
+// Update updates an existing subscription
+func (r *PostgresSubscriptionRepository) Update(ctx context.Context, subscription *entity.Subscription) error {
+	query := `
+		UPDATE subscriptions
+		SET status = $1, next_run_at = $2, last_run_at = $3, last_order_id = $4,
+			failure_count = $5, last_error = $6, updated_at = $7
+		WHERE id = $8
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		subscription.Status,
+		subscription.NextRunAt,
+		subscription.LastRunAt,
+		subscription.LastOrderID,
+		subscription.FailureCount,
+		subscription.LastError,
+		subscription.UpdatedAt,
+		subscription.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return entity.ErrSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// Claim moves the next run of a subscription from scheduledAt to nextRunAt
+// Only one worker can move a given run, the others report false
+func (r *PostgresSubscriptionRepository) Claim(ctx context.Context, id string, scheduledAt, nextRunAt time.Time) (bool, error) {
+	query := `
+		UPDATE subscriptions
+		SET next_run_at = $1, updated_at = $2
+		WHERE id = $3 AND status = $4 AND next_run_at = $5
+	`
+
+	result, err := r.db.ExecContext(ctx, query, nextRunAt, time.Now(), id, entity.SubscriptionStatusActive, scheduledAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim subscription run: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// list retrieves the subscriptions selected by query, with their items
+func (r *PostgresSubscriptionRepository) list(ctx context.Context, query string, args ...interface{}) ([]*entity.Subscription, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*entity.Subscription
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating subscriptions: %w", err)
+	}
+
+	if err := r.loadItems(ctx, subscriptions); err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// loadItems attaches the items to the given subscriptions
+func (r *PostgresSubscriptionRepository) loadItems(ctx context.Context, subscriptions []*entity.Subscription) error {
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(subscriptions))
+	byID := make(map[string]*entity.Subscription, len(subscriptions))
+	for i, subscription := range subscriptions {
+		ids[i] = subscription.ID
+		byID[subscription.ID] = subscription
+	}
+
+	query := `
+		SELECT id, subscription_id, product_id, variant_id, quantity
+		FROM subscription_items
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, product_id, variant_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get subscription items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.SubscriptionItem
+		var subscriptionID string
+
+		if err := rows.Scan(&item.ID, &subscriptionID, &item.ProductID, &item.VariantID, &item.Quantity); err != nil {
+			return fmt.Errorf("failed to scan subscription item: %w", err)
+		}
+
+		if subscription, ok := byID[subscriptionID]; ok {
+			subscription.Items = append(subscription.Items, &item)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating subscription items: %w", err)
+	}
+
+	return nil
+}
+
+// scanSubscription reads a subscription selected with subscriptionColumns
+func scanSubscription(row rowScanner) (*entity.Subscription, error) {
+	var subscription entity.Subscription
+	var lastRunAt sql.NullTime
+	var lastOrderID, lastError sql.NullString
+
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.UserID,
+		&subscription.Currency,
+		&subscription.IntervalWeeks,
+		&subscription.Status,
+		&subscription.NextRunAt,
+		&lastRunAt,
+		&lastOrderID,
+		&subscription.FailureCount,
+		&lastError,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastRunAt.Valid {
+		subscription.LastRunAt = &lastRunAt.Time
+	}
+	if lastOrderID.Valid {
+		subscription.LastOrderID = &lastOrderID.String
+	}
+	if lastError.Valid {
+		subscription.LastError = &lastError.String
+	}
+
+	return &subscription, nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// queryExecer is implemented by both *sql.DB and *sql.Tx
+type queryExecer interface {
+	execer
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// checkVersionedUpdate checks the result of an update guarded by "WHERE id = ... AND version = ..."
+// An update that matched no row returns notFound when the row is gone, and
+// ErrVersionConflict when another update moved its version on
+func checkVersionedUpdate(ctx context.Context, db queryExecer, result sql.Result, table, id string, notFound error) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check %s version: %w", table, err)
+	}
+
+	if !exists {
+		return notFound
+	}
+
+	return entity.ErrVersionConflict
+}
@@ -0,0 +1,189 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"small-ecommers/internal/usecase"
+)
+
+// Markers wrapped around matched terms by ts_headline
+// They are control characters so they survive HTML escaping and never occur in product text
+const (
+	startMark = "\x02"
+	stopMark  = "\x03"
+)
+
+// headlineOptions configures the highlighted name and description snippet
+const headlineOptions = `StartSel="` + startMark + `", StopSel="` + stopMark + `", MinWords=15, MaxWords=35`
+
+// snippetWords is the length of the description excerpt of fuzzy hits
+const snippetWords = 35
+
+// PostgresProductSearch implements ProductSearch with PostgreSQL full-text search
+// Products are matched against the search_vector column, ranked with ts_rank, and
+// when nothing matches, names are compared by trigram similarity to tolerate typos
+// Archived products are never returned
+type PostgresProductSearch struct {
+	db *sql.DB
+}
+
+// NewPostgresProductSearch creates a new PostgresProductSearch
+func NewPostgresProductSearch(db *sql.DB) *PostgresProductSearch {
+	return &PostgresProductSearch{db: db}
+}
+
+// Search returns the products matching a text query, best match first
+func (s *PostgresProductSearch) Search(ctx context.Context, query *usecase.ProductSearchQuery) (*usecase.ProductSearchResult, error) {
+	words := searchWords(query.Text)
+	if len(words) == 0 {
+		return &usecase.ProductSearchResult{Hits: []*usecase.ProductSearchHit{}}, nil
+	}
+
+	result, err := s.searchText(ctx, prefixQuery(words), query)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Total > 0 {
+		return result, nil
+	}
+
+	return s.searchSimilar(ctx, strings.Join(words, " "), query)
+}
+
+// searchText matches products whose name or description contain a word starting with each query word
+func (s *PostgresProductSearch) searchText(ctx context.Context, tsquery string, query *usecase.ProductSearchQuery) (*usecase.ProductSearchResult, error) {
+	result := &usecase.ProductSearchResult{Hits: []*usecase.ProductSearchHit{}}
+
+	countQuery := `SELECT COUNT(*) FROM products WHERE search_vector @@ to_tsquery('english', $1) AND status = 'active'`
+	if err := s.db.QueryRowContext(ctx, countQuery, tsquery).Scan(&result.Total); err != nil {
+		return nil, fmt.Errorf("failed to count product matches: %w", err)
+	}
+
+	if result.Total <= query.Offset {
+		return result, nil
+	}
+
+	// Headlines are only computed for the page, not for every match
+	pageQuery := `
+		SELECT id, rank,
+			ts_headline('english', name, q, $4),
+			ts_headline('english', COALESCE(description, ''), q, $4)
+		FROM (
+			SELECT id, name, description, q, ts_rank(search_vector, q) AS rank
+			FROM products, to_tsquery('english', $1) AS q
+			WHERE search_vector @@ q AND status = 'active'
+			ORDER BY rank DESC, id
+			LIMIT $2 OFFSET $3
+		) AS hits
+		ORDER BY rank DESC, id
+	`
+
+	rows, err := s.db.QueryContext(ctx, pageQuery, tsquery, query.Limit, query.Offset, headlineOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hit usecase.ProductSearchHit
+		if err := rows.Scan(&hit.ProductID, &hit.Rank, &hit.Highlight, &hit.Snippet); err != nil {
+			return nil, fmt.Errorf("failed to scan product match: %w", err)
+		}
+
+		hit.Highlight = markMatches(hit.Highlight)
+		hit.Snippet = markMatches(hit.Snippet)
+		result.Hits = append(result.Hits, &hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating product matches: %w", err)
+	}
+
+	return result, nil
+}
+
+// searchSimilar matches products with a name similar to the text, by trigram word similarity
+func (s *PostgresProductSearch) searchSimilar(ctx context.Context, text string, query *usecase.ProductSearchQuery) (*usecase.ProductSearchResult, error) {
+	result := &usecase.ProductSearchResult{Hits: []*usecase.ProductSearchHit{}, Fuzzy: true}
+
+	countQuery := `SELECT COUNT(*) FROM products WHERE $1 <% name AND status = 'active'`
+	if err := s.db.QueryRowContext(ctx, countQuery, text).Scan(&result.Total); err != nil {
+		return nil, fmt.Errorf("failed to count similar products: %w", err)
+	}
+
+	if result.Total <= query.Offset {
+		return result, nil
+	}
+
+	pageQuery := `
+		SELECT id, word_similarity($1, name) AS rank, name, COALESCE(description, '')
+		FROM products
+		WHERE $1 <% name AND status = 'active'
+		ORDER BY rank DESC, id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, pageQuery, text, query.Limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search similar products: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hit usecase.ProductSearchHit
+		var name, description string
+		if err := rows.Scan(&hit.ProductID, &hit.Rank, &name, &description); err != nil {
+			return nil, fmt.Errorf("failed to scan similar product: %w", err)
+		}
+
+		hit.Highlight = html.EscapeString(name)
+		hit.Snippet = html.EscapeString(excerpt(description, snippetWords))
+		result.Hits = append(result.Hits, &hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating similar products: %w", err)
+	}
+
+	return result, nil
+}
+
+// searchWords splits a query into lower case words, dropping punctuation
+// so the words can be put in a tsquery without being parsed as operators
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// prefixQuery builds a tsquery matching all the words, each as a prefix, so results
+// show up while the shopper is still typing
+func prefixQuery(words []string) string {
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// markMatches escapes a headline and turns its markers into <mark> tags
+func markMatches(headline string) string {
+	escaped := html.EscapeString(headline)
+	escaped = strings.ReplaceAll(escaped, startMark, "<mark>")
+	return strings.ReplaceAll(escaped, stopMark, "</mark>")
+}
+
+// excerpt returns the first words of a text
+func excerpt(text string, words int) string {
+	fields := strings.Fields(text)
+	if len(fields) <= words {
+		return strings.Join(fields, " ")
+	}
+	return strings.Join(fields[:words], " ") + " ..."
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminRequired is a middleware that only lets configured admin users through
+// It must be registered after AuthRequired, which sets the user_id local
+func AdminRequired(adminUserIDs []string) fiber.Handler {
+	admins := make(map[string]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		if id != "" {
+			admins[id] = true
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("user_id").(string)
+
+		if !admins[userID] {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Forbidden - admin access required",
+			})
+		}
+
+		return c.Next()
+	}
+}
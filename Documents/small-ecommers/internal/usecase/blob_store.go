@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore defines the interface for storing files such as generated documents and uploaded images
+type BlobStore interface {
+	// Put stores data under key, replacing any previous content
+	Put(ctx context.Context, key, contentType string, data io.Reader) error
+
+	// Get opens the data stored under key, failing with entity.ErrBlobNotFound when there is none
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the data stored under key, deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 
@@ -13,22 +15,35 @@ import (
 type CartUseCase struct {
 	cartRepo    repository.CartRepository
 	productRepo repository.ProductRepository
+	prices      *PriceResolver
 }
 
 // NewCartUseCase creates a new CartUseCase
-func NewCartUseCase(cartRepo repository.CartRepository, productRepo repository.ProductRepository) *CartUseCase {
+func NewCartUseCase(
+	cartRepo repository.CartRepository,
+	productRepo repository.ProductRepository,
+	prices *PriceResolver,
+) *CartUseCase {
 	return &CartUseCase{
 		cartRepo:    cartRepo,
 		productRepo: productRepo,
+		prices:      prices,
 	}
 }
 
 // AddItemRequest represents the request to add an item to the cart
 type AddItemRequest struct {
 	ProductID string `json:"product_id"`
+	// VariantID defaults to the default variant of the product
+	VariantID string `json:"variant_id,omitempty"`
 	Quantity  int    `json:"quantity"`
 }
 
+// SetCurrencyRequest represents the request to change the cart currency
+type SetCurrencyRequest struct {
+	Currency string `json:"currency"`
+}
+
 // GetOrCreateCart gets an existing cart or creates a new one for the user
 func (uc *CartUseCase) GetOrCreateCart(ctx context.Context, userID string) (*entity.Cart, error) {
 	cart, err := uc.cartRepo.GetByUserID(ctx, userID)
@@ -37,7 +52,7 @@ func (uc *CartUseCase) GetOrCreateCart(ctx context.Context, userID string) (*ent
 	}
 
 	// Create new cart
-	cart = entity.NewCart(uuid.New().String(), userID)
+	cart = entity.NewCart(uuid.New().String(), userID, uc.prices.BaseCurrency())
 	if err := uc.cartRepo.Create(ctx, cart); err != nil {
 		return nil, err
 	}
@@ -64,17 +79,33 @@ func (uc *CartUseCase) AddItem(ctx context.Context, userID string, req *AddItemR
 		return nil, err
 	}
 
-	// Check stock
-	if !product.HasStock(req.Quantity) {
+	if product.IsArchived() {
+		return nil, entity.ErrProductArchived
+	}
+
+	variant, err := productVariant(product, req.VariantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check stock, allowing backorders where the product permits them
+	if !product.CanFulfill(variant, req.Quantity) {
 		return nil, entity.ErrInsufficientStock
 	}
 
+	// Price the item in the cart currency
+	resolved, err := uc.prices.Resolve(ctx, product, variant, cart.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create cart item
 	cartItem := entity.NewCartItem(
 		uuid.New().String(),
 		req.ProductID,
+		variant.ID,
 		req.Quantity,
-		product.Price,
+		resolved.Price,
 	)
 
 	// Add item to cart
@@ -88,14 +119,15 @@ func (uc *CartUseCase) AddItem(ctx context.Context, userID string, req *AddItemR
 	return cart, nil
 }
 
-// RemoveItem removes an item from the cart
-func (uc *CartUseCase) RemoveItem(ctx context.Context, userID, productID string) (*entity.Cart, error) {
+// RemoveItem removes the item of a variant from the cart
+// A product ID refers to the default variant of the product
+func (uc *CartUseCase) RemoveItem(ctx context.Context, userID, variantID string) (*entity.Cart, error) {
 	cart, err := uc.GetCart(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := cart.RemoveItem(productID); err != nil {
+	if err := cart.RemoveItem(variantID); err != nil {
 		return nil, err
 	}
 
@@ -106,27 +138,115 @@ func (uc *CartUseCase) RemoveItem(ctx context.Context, userID, productID string)
 	return cart, nil
 }
 
-// UpdateItemQuantity updates the quantity of an item in the cart
-func (uc *CartUseCase) UpdateItemQuantity(ctx context.Context, userID, productID string, quantity int) (*entity.Cart, error) {
+// UpdateItemQuantity updates the quantity of the item of a variant in the cart
+// A product ID refers to the default variant of the product.
+// version is the cart version the change was made to, ErrVersionConflict is returned once it moved on.
+func (uc *CartUseCase) UpdateItemQuantity(ctx context.Context, userID, variantID string, version, quantity int) (*entity.Cart, error) {
 	cart, err := uc.GetCart(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkVersion(cart.Version, version); err != nil {
+		return nil, err
+	}
+
+	var item *entity.CartItem
+	for _, candidate := range cart.Items {
+		if candidate.VariantID == variantID {
+			item = candidate
+			break
+		}
+	}
+	if item == nil {
+		return nil, entity.ErrCartItemNotFound
+	}
+
 	// Get product to check stock
-	product, err := uc.productRepo.GetByID(ctx, productID)
+	product, err := uc.productRepo.GetByID(ctx, item.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := product.Variant(variantID)
 	if err != nil {
 		return nil, err
 	}
 
-	if !product.HasStock(quantity) {
+	// Items of archived products may stay in the cart, but not grow
+	if product.IsArchived() && quantity > item.Quantity {
+		return nil, entity.ErrProductArchived
+	}
+
+	if !product.CanFulfill(variant, quantity) {
 		return nil, entity.ErrInsufficientStock
 	}
 
-	if err := cart.UpdateItemQuantity(productID, quantity); err != nil {
+	if err := cart.UpdateItemQuantity(variantID, quantity); err != nil {
+		return nil, err
+	}
+
+	if err := uc.cartRepo.Update(ctx, cart); err != nil {
 		return nil, err
 	}
 
+	return cart, nil
+}
+
+// SetCurrency switches the cart to another currency and re-prices its items
+// The switch is refused when any item has no price in the new currency.
+// version is the cart version the change was made to, ErrVersionConflict is returned once it moved on.
+func (uc *CartUseCase) SetCurrency(ctx context.Context, userID string, version int, req *SetCurrencyRequest) (*entity.Cart, error) {
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if !entity.IsCurrencyCode(currency) {
+		return nil, entity.ErrInvalidCurrency
+	}
+
+	cart, err := uc.GetOrCreateCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(cart.Version, version); err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]string, len(cart.Items))
+	for i, item := range cart.Items {
+		productIDs[i] = item.ProductID
+	}
+
+	products, err := uc.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	productMap := make(map[string]*entity.Product)
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	for _, item := range cart.Items {
+		product, exists := productMap[item.ProductID]
+		if !exists {
+			return nil, fmt.Errorf("product not found: %s", item.ProductID)
+		}
+
+		variant, err := product.Variant(item.VariantID)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved, err := uc.prices.Resolve(ctx, product, variant, currency)
+		if err != nil {
+			return nil, err
+		}
+
+		item.Price = resolved.Price
+	}
+
+	cart.SetCurrency(currency)
+
 	if err := uc.cartRepo.Update(ctx, cart); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// CategoryUseCase handles product category business logic
+type CategoryUseCase struct {
+	categoryRepo repository.CategoryRepository
+}
+
+// NewCategoryUseCase creates a new CategoryUseCase
+func NewCategoryUseCase(categoryRepo repository.CategoryRepository) *CategoryUseCase {
+	return &CategoryUseCase{
+		categoryRepo: categoryRepo,
+	}
+}
+
+// CreateCategoryRequest represents the request to create a category
+type CreateCategoryRequest struct {
+	Name string `json:"name"`
+	// Slug defaults to one derived from the name
+	Slug     string  `json:"slug,omitempty"`
+	ParentID *string `json:"parent_id,omitempty"`
+	Position int     `json:"position"`
+	// Attributes are the attributes of the products in the category and the categories below it
+	Attributes entity.AttributeSchema `json:"attributes,omitempty"`
+}
+
+// UpdateCategoryRequest represents the request to update a category
+type UpdateCategoryRequest struct {
+	Name     *string `json:"name,omitempty"`
+	Slug     *string `json:"slug,omitempty"`
+	ParentID *string `json:"parent_id,omitempty"`
+	Position *int    `json:"position,omitempty"`
+	// Attributes replaces the attribute schema, an empty list removes it
+	Attributes *entity.AttributeSchema `json:"attributes,omitempty"`
+	// ClearParent moves the category to the top level
+	ClearParent bool `json:"clear_parent,omitempty"`
+}
+
+// GetCategoryTree retrieves all categories, nested under their parents
+func (uc *CategoryUseCase) GetCategoryTree(ctx context.Context) ([]*entity.Category, error) {
+	categories, err := uc.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := entity.BuildCategoryTree(categories)
+	if tree == nil {
+		tree = []*entity.Category{}
+	}
+
+	return tree, nil
+}
+
+// CreateCategory creates a new category
+func (uc *CategoryUseCase) CreateCategory(ctx context.Context, req *CreateCategoryRequest) (*entity.Category, error) {
+	slug := req.Slug
+	if slug == "" {
+		slug = slugify(req.Name)
+	}
+
+	category := entity.NewCategory(uuid.New().String(), strings.TrimSpace(req.Name), slug, req.ParentID, req.Position)
+	category.Attributes = req.Attributes
+
+	if err := uc.validate(ctx, category); err != nil {
+		return nil, err
+	}
+
+	if err := uc.categoryRepo.Create(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// UpdateCategory updates an existing category
+func (uc *CategoryUseCase) UpdateCategory(ctx context.Context, id string, req *UpdateCategoryRequest) (*entity.Category, error) {
+	category, err := uc.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		category.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Slug != nil {
+		category.Slug = *req.Slug
+	}
+	if req.ParentID != nil || req.ClearParent {
+		category.ParentID = req.ParentID
+	}
+	if req.Position != nil {
+		category.Position = *req.Position
+	}
+	if req.Attributes != nil {
+		category.Attributes = *req.Attributes
+	}
+
+	if err := uc.validate(ctx, category); err != nil {
+		return nil, err
+	}
+
+	// A category cannot be moved below itself or one of its descendants
+	if category.ParentID != nil {
+		descendants, err := uc.categoryRepo.GetDescendantIDs(ctx, category.ID)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(descendants, *category.ParentID) {
+			return nil, fmt.Errorf("%w: a category cannot be its own ancestor", entity.ErrInvalidCategory)
+		}
+	}
+
+	category.UpdatedAt = time.Now()
+
+	if err := uc.categoryRepo.Update(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// DeleteCategory deletes a category without children
+func (uc *CategoryUseCase) DeleteCategory(ctx context.Context, id string) error {
+	return uc.categoryRepo.Delete(ctx, id)
+}
+
+// validate checks the fields of a category and that its parent exists
+func (uc *CategoryUseCase) validate(ctx context.Context, category *entity.Category) error {
+	if category.Name == "" {
+		return fmt.Errorf("%w: name is required", entity.ErrInvalidCategory)
+	}
+
+	if !entity.IsValidSlug(category.Slug) {
+		return fmt.Errorf("%w: slug must be lower case letters and digits separated by hyphens", entity.ErrInvalidCategory)
+	}
+
+	if err := uc.validateAttributes(ctx, category); err != nil {
+		return err
+	}
+
+	if category.ParentID == nil {
+		return nil
+	}
+
+	if _, err := uc.categoryRepo.GetByID(ctx, *category.ParentID); err != nil {
+		if errors.Is(err, entity.ErrCategoryNotFound) {
+			return fmt.Errorf("%w: parent category not found", entity.ErrInvalidCategory)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// validateAttributes checks the attribute schema of a category
+// An attribute key has the same type in every category, so that products can be filtered by it
+func (uc *CategoryUseCase) validateAttributes(ctx context.Context, category *entity.Category) error {
+	if err := entity.ValidateAttributeSchema(category.Attributes); err != nil {
+		return err
+	}
+
+	if len(category.Attributes) == 0 {
+		return nil
+	}
+
+	categories, err := uc.categoryRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range categories {
+		if other.ID == category.ID {
+			continue
+		}
+		for _, definition := range category.Attributes {
+			if existing, ok := other.Attributes.Definition(definition.Key); ok && existing.Type != definition.Type {
+				return fmt.Errorf("%w: attribute %q is %s in category %s", entity.ErrInvalidCategory, definition.Key, existing.Type, other.Slug)
+			}
+		}
+	}
+
+	return nil
+}
+
+// slugify derives a slug from a name, keeping ASCII letters and digits
+// Names in other scripts need an explicit slug
+func slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		case r == '\'' || r == '’':
+			// Apostrophes join words, "men's" becomes "mens"
+		default:
+			hyphen = true
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,17 @@
+package usecase
+
+import "time"
+
+// Clock defines the interface for reading the current time
+// It is injected wherever time drives business rules, so schedules can be tested
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a Clock reading the system time
+type SystemClock struct{}
+
+// Now returns the current time in UTC
+func (SystemClock) Now() time.Time {
+	return time.Now().UTC()
+}
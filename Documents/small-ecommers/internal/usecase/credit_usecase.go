@@ -0,0 +1,251 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// CreditUseCase defines the business logic for gift cards and store credit
+type CreditUseCase struct {
+	giftCardRepo repository.GiftCardRepository
+	ledgerRepo   repository.LedgerRepository
+}
+
+// NewCreditUseCase creates a new CreditUseCase
+func NewCreditUseCase(giftCardRepo repository.GiftCardRepository, ledgerRepo repository.LedgerRepository) *CreditUseCase {
+	return &CreditUseCase{
+		giftCardRepo: giftCardRepo,
+		ledgerRepo:   ledgerRepo,
+	}
+}
+
+// IssueGiftCardRequest represents the request to issue a gift card
+type IssueGiftCardRequest struct {
+	Amount    entity.Money `json:"amount"`
+	ExpiresAt *time.Time   `json:"expires_at,omitempty"`
+	Note      *string      `json:"note,omitempty"`
+}
+
+// AdjustStoreCreditRequest represents the request to credit or debit a user's store credit
+type AdjustStoreCreditRequest struct {
+	// Amount is credited when positive and debited when negative
+	Amount entity.Money `json:"amount"`
+	Note   *string      `json:"note,omitempty"`
+}
+
+// GiftCardStatement is a gift card with every entry posted to it
+type GiftCardStatement struct {
+	Card    *entity.GiftCard      `json:"card"`
+	Entries []*entity.LedgerEntry `json:"entries"`
+}
+
+// StoreCreditStatement is the store credit of a user with every entry posted to it
+type StoreCreditStatement struct {
+	Balances []entity.Money        `json:"balances"`
+	Entries  []*entity.LedgerEntry `json:"entries"`
+}
+
+// IssueGiftCard issues a new gift card with a random code
+func (uc *CreditUseCase) IssueGiftCard(ctx context.Context, req *IssueGiftCardRequest) (*entity.GiftCard, error) {
+	if req.Amount.Currency == "" {
+		return nil, entity.ErrInvalidCurrency
+	}
+
+	if !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("gift card amount must be positive")
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("gift card expiry must be in the future")
+	}
+
+	code, err := entity.GenerateGiftCardCode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate gift card code: %w", err)
+	}
+
+	card := entity.NewGiftCard(uuid.New().String(), code, req.Amount, req.ExpiresAt)
+
+	issue := entity.NewLedgerEntry(uuid.New().String(), entity.LedgerAccountGiftCard, card.ID, req.Amount, entity.LedgerReasonIssue)
+	issue.Note = req.Note
+
+	if err := uc.giftCardRepo.Create(ctx, card, issue); err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+// GetGiftCard retrieves a gift card by its code
+func (uc *CreditUseCase) GetGiftCard(ctx context.Context, code string) (*entity.GiftCard, error) {
+	return uc.giftCardRepo.GetByCode(ctx, entity.NormalizeGiftCardCode(code))
+}
+
+// GetGiftCardStatement retrieves a gift card and its ledger entries
+func (uc *CreditUseCase) GetGiftCardStatement(ctx context.Context, code string) (*GiftCardStatement, error) {
+	card, err := uc.GetGiftCard(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := uc.ledgerRepo.GetByAccount(ctx, entity.LedgerAccountGiftCard, card.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GiftCardStatement{Card: card, Entries: entries}, nil
+}
+
+// GetStoreCredit retrieves the store credit balances and ledger entries of a user
+func (uc *CreditUseCase) GetStoreCredit(ctx context.Context, userID string) (*StoreCreditStatement, error) {
+	balances, err := uc.ledgerRepo.GetStoreCreditBalances(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := uc.ledgerRepo.GetByAccount(ctx, entity.LedgerAccountStoreCredit, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StoreCreditStatement{Balances: balances, Entries: entries}, nil
+}
+
+// AdjustStoreCredit posts a manual adjustment to a user's store credit
+func (uc *CreditUseCase) AdjustStoreCredit(ctx context.Context, userID string, req *AdjustStoreCreditRequest) (*entity.LedgerEntry, error) {
+	if req.Amount.Currency == "" {
+		return nil, entity.ErrInvalidCurrency
+	}
+
+	if req.Amount.IsZero() {
+		return nil, fmt.Errorf("adjustment amount must not be zero")
+	}
+
+	entry := entity.NewLedgerEntry(uuid.New().String(), entity.LedgerAccountStoreCredit, userID, req.Amount, entity.LedgerReasonAdjustment)
+	entry.Note = req.Note
+
+	if err := uc.ledgerRepo.Post(ctx, []*entity.LedgerEntry{entry}); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// redeem debits the gift cards and store credit chosen at checkout against an order
+// Gift cards are used first, in the order given, then store credit covers what is left.
+// Nothing is debited unless every balance can be.
+func (uc *CreditUseCase) redeem(ctx context.Context, order *entity.Order, req *CheckoutRequest) ([]*entity.OrderPayment, error) {
+	remaining := order.Total
+	used := make(map[string]bool)
+
+	var entries []*entity.LedgerEntry
+	var payments []*entity.OrderPayment
+
+	take := func(method entity.PaymentMethod, accountID string, balance entity.Money) {
+		amount := balance.Min(remaining)
+		if !amount.IsPositive() {
+			return
+		}
+
+		entry := entity.NewLedgerEntry(uuid.New().String(), entity.LedgerAccountType(method), accountID, amount.Neg(), entity.LedgerReasonRedeem)
+		entry.OrderID = &order.ID
+
+		entries = append(entries, entry)
+		payments = append(payments, &entity.OrderPayment{Method: method, AccountID: accountID, Amount: amount})
+		remaining = remaining.Sub(amount)
+	}
+
+	for _, code := range req.GiftCardCodes {
+		card, err := uc.GetGiftCard(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+
+		if used[card.ID] || !remaining.IsPositive() {
+			continue
+		}
+		used[card.ID] = true
+
+		if card.IsExpired(time.Now()) {
+			return nil, entity.ErrGiftCardExpired
+		}
+
+		if !card.Balance.SameCurrency(order.Total) {
+			return nil, entity.ErrCurrencyMismatch
+		}
+
+		take(entity.PaymentMethodGiftCard, card.ID, card.Balance)
+	}
+
+	if req.UseStoreCredit && remaining.IsPositive() {
+		balances, err := uc.ledgerRepo.GetStoreCreditBalances(ctx, order.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, balance := range balances {
+			if balance.SameCurrency(order.Total) {
+				take(entity.PaymentMethodStoreCredit, order.UserID, balance)
+			}
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	if err := uc.ledgerRepo.Post(ctx, entries); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// reverse credits back every balance redeemed for an order
+// Balances already reversed are skipped, so a failed cancellation can be retried safely.
+func (uc *CreditUseCase) reverse(ctx context.Context, order *entity.Order) error {
+	posted, err := uc.ledgerRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	reversed := make(map[string]bool)
+	for _, entry := range posted {
+		if entry.Reason == entity.LedgerReasonReversal {
+			reversed[string(entry.AccountType)+"/"+entry.AccountID] = true
+		}
+	}
+
+	var entries []*entity.LedgerEntry
+	for _, entry := range posted {
+		if entry.Reason != entity.LedgerReasonRedeem || reversed[string(entry.AccountType)+"/"+entry.AccountID] {
+			continue
+		}
+
+		reversal := entity.NewLedgerEntry(uuid.New().String(), entry.AccountType, entry.AccountID, entry.Amount.Neg(), entity.LedgerReasonReversal)
+		reversal.OrderID = &order.ID
+
+		entries = append(entries, reversal)
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return uc.ledgerRepo.Post(ctx, entries)
+}
+
+// refund credits a refunded amount of an order to the customer's store credit
+func (uc *CreditUseCase) refund(ctx context.Context, order *entity.Order, amount entity.Money, note *string) error {
+	entry := entity.NewLedgerEntry(uuid.New().String(), entity.LedgerAccountStoreCredit, order.UserID, amount, entity.LedgerReasonRefund)
+	entry.OrderID = &order.ID
+	entry.Note = note
+
+	return uc.ledgerRepo.Post(ctx, []*entity.LedgerEntry{entry})
+}
@@ -0,0 +1,28 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// encodeCursor returns the opaque form of a pagination cursor
+func encodeCursor(cursor interface{}) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads an opaque pagination cursor into cursor
+func decodeCursor(encoded string, cursor interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return entity.ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return entity.ErrInvalidCursor
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// eventQueueSize is how many events can wait for delivery before Publish blocks
+const eventQueueSize = 256
+
+// EventHandler reacts to an order event
+type EventHandler func(ctx context.Context, event *entity.OrderEvent) error
+
+// EventPublisher defines the interface for raising order events
+type EventPublisher interface {
+	Publish(ctx context.Context, event *entity.OrderEvent)
+}
+
+// EventBus delivers order events to their subscribers in the background
+// Events are delivered one at a time in the order they were published, so a slow
+// subscriber such as a mail server never holds up the request that raised the event.
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[entity.OrderEventType][]EventHandler
+	queue    chan *entity.OrderEvent
+}
+
+// NewEventBus creates a new EventBus
+func NewEventBus() *EventBus {
+	return &EventBus{
+		handlers: make(map[entity.OrderEventType][]EventHandler),
+		queue:    make(chan *entity.OrderEvent, eventQueueSize),
+	}
+}
+
+// Subscribe registers a handler for the given event types
+func (b *EventBus) Subscribe(handler EventHandler, types ...entity.OrderEventType) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, eventType := range types {
+		b.handlers[eventType] = append(b.handlers[eventType], handler)
+	}
+}
+
+// Publish queues an event for delivery
+// It only blocks when the queue is full, and gives up when the context is cancelled
+func (b *EventBus) Publish(ctx context.Context, event *entity.OrderEvent) {
+	select {
+	case b.queue <- event:
+	case <-ctx.Done():
+		log.Printf("Dropped %s event for order %s: %v", event.Type, event.Order.ID, ctx.Err())
+	}
+}
+
+// Run delivers queued events until the context is cancelled
+func (b *EventBus) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-b.queue:
+			b.deliver(ctx, event)
+		}
+	}
+}
+
+// deliver passes an event to each of its handlers
+// A failing handler is logged and does not stop the others
+func (b *EventBus) deliver(ctx context.Context, event *entity.OrderEvent) {
+	b.mu.RLock()
+	handlers := b.handlers[event.Type]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			log.Printf("Failed to handle %s event for order %s: %v", event.Type, event.Order.ID, err)
+		}
+	}
+}
+
+// KafkaProducer defines the interface for Kafka producer operations
+type KafkaProducer interface {
+	PublishOrderCreated(ctx context.Context, order map[string]interface{}) error
+}
+
+// KafkaOrderCreatedHandler returns an event handler forwarding created orders to Kafka
+func KafkaOrderCreatedHandler(producer KafkaProducer) EventHandler {
+	return func(ctx context.Context, event *entity.OrderEvent) error {
+		order := event.Order
+
+		return producer.PublishOrderCreated(ctx, map[string]interface{}{
+			"id":            order.ID,
+			"user_id":       order.UserID,
+			"total":         order.Total,
+			"breakdown":     order.Breakdown,
+			"exchange_rate": order.ExchangeRate,
+			"status":        order.Status,
+			"items":         order.Items,
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// ExchangeRateUseCase defines the business logic for exchange rate operations
+type ExchangeRateUseCase struct {
+	rateRepo     repository.ExchangeRateRepository
+	baseCurrency string
+}
+
+// NewExchangeRateUseCase creates a new ExchangeRateUseCase
+func NewExchangeRateUseCase(rateRepo repository.ExchangeRateRepository, baseCurrency string) *ExchangeRateUseCase {
+	return &ExchangeRateUseCase{
+		rateRepo:     rateRepo,
+		baseCurrency: baseCurrency,
+	}
+}
+
+// SetRateRequest represents the request to set an exchange rate
+type SetRateRequest struct {
+	Rate string `json:"rate"`
+}
+
+// SetRate sets the rate from the base currency into the quote currency
+func (uc *ExchangeRateUseCase) SetRate(ctx context.Context, quoteCurrency string, req *SetRateRequest) (*entity.ExchangeRate, error) {
+	rate, err := entity.NewExchangeRate(uc.baseCurrency, quoteCurrency, req.Rate)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.rateRepo.Upsert(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// ListRates retrieves all exchange rates
+func (uc *ExchangeRateUseCase) ListRates(ctx context.Context) ([]*entity.ExchangeRate, error) {
+	return uc.rateRepo.List(ctx)
+}
+
+// ImportRates imports rates from CSV with the columns quote_currency,rate
+// An optional leading base_currency column is accepted as long as it matches the base currency.
+// A header row is skipped. Either every row is imported or none is.
+func (uc *ExchangeRateUseCase) ImportRates(ctx context.Context, r io.Reader) ([]*entity.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rates []*entity.ExchangeRate
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if line == 1 && isRateHeader(record) {
+			continue
+		}
+
+		base := uc.baseCurrency
+		switch len(record) {
+		case 2:
+		case 3:
+			base = record[0]
+			record = record[1:]
+		default:
+			return nil, fmt.Errorf("line %d: expected 2 or 3 columns, got %d", line, len(record))
+		}
+
+		if !strings.EqualFold(strings.TrimSpace(base), uc.baseCurrency) {
+			return nil, fmt.Errorf("line %d: rates must be quoted against %s", line, uc.baseCurrency)
+		}
+
+		rate, err := entity.NewExchangeRate(uc.baseCurrency, record[0], record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no exchange rates found")
+	}
+
+	if err := uc.rateRepo.UpsertMany(ctx, rates); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// isRateHeader reports whether a CSV record is a header row
+func isRateHeader(record []string) bool {
+	for _, field := range record {
+		if strings.EqualFold(strings.TrimSpace(field), "rate") {
+			return true
+		}
+	}
+	return false
+}
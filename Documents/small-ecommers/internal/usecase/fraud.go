@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// FraudContext carries what fraud rules can look at when scoring an order
+type FraudContext struct {
+	Order *entity.Order
+	User  *entity.User
+}
+
+// FraudRule scores one signal of an order
+type FraudRule interface {
+	// Name identifies the rule in recorded hits
+	Name() string
+
+	// Evaluate returns a hit when the rule matches the order, nil otherwise
+	Evaluate(ctx context.Context, fc *FraudContext) (*entity.FraudRuleHit, error)
+}
+
+// FraudThresholds decide the outcome of a screening from its total score
+// A threshold of zero disables that outcome
+type FraudThresholds struct {
+	ReviewScore int
+	RejectScore int
+}
+
+// FraudScreener scores orders against fraud rules before they are paid
+type FraudScreener struct {
+	fraudRepo  repository.FraudRepository
+	userRepo   repository.UserRepository
+	clock      Clock
+	thresholds FraudThresholds
+	rules      []FraudRule
+}
+
+// NewFraudScreener creates a new FraudScreener
+// Every rule is evaluated and the scores of the rules that match are added up
+func NewFraudScreener(
+	fraudRepo repository.FraudRepository,
+	userRepo repository.UserRepository,
+	clock Clock,
+	thresholds FraudThresholds,
+	rules ...FraudRule,
+) *FraudScreener {
+	return &FraudScreener{
+		fraudRepo:  fraudRepo,
+		userRepo:   userRepo,
+		clock:      clock,
+		thresholds: thresholds,
+		rules:      rules,
+	}
+}
+
+// Screen evaluates the rules against an order and records the decision with its rule hits
+func (s *FraudScreener) Screen(ctx context.Context, order *entity.Order) (*entity.FraudScreening, error) {
+	user, err := s.userRepo.GetByID(ctx, order.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	fc := &FraudContext{
+		Order: order,
+		User:  user,
+	}
+
+	hits := []entity.FraudRuleHit{}
+	for _, rule := range s.rules {
+		hit, err := rule.Evaluate(ctx, fc)
+		if err != nil {
+			return nil, fmt.Errorf("fraud rule %s: %w", rule.Name(), err)
+		}
+		if hit != nil {
+			hit.Rule = rule.Name()
+			hits = append(hits, *hit)
+		}
+	}
+
+	screening := entity.NewFraudScreening(
+		uuid.New().String(),
+		order.ID,
+		hits,
+		s.thresholds.ReviewScore,
+		s.thresholds.RejectScore,
+		s.clock.Now(),
+	)
+	screening.ClientIP = order.ClientIP
+
+	if err := s.fraudRepo.Create(ctx, screening); err != nil {
+		return nil, err
+	}
+
+	return screening, nil
+}
+
+// GetScreening retrieves the most recent screening of an order
+func (s *FraudScreener) GetScreening(ctx context.Context, orderID string) (*entity.FraudScreening, error) {
+	return s.fraudRepo.GetLatestByOrderID(ctx, orderID)
+}
+
+// ListPendingReviews retrieves the screenings of orders held for review
+func (s *FraudScreener) ListPendingReviews(ctx context.Context) ([]*entity.FraudScreening, error) {
+	return s.fraudRepo.ListPendingReviews(ctx)
+}
+
+// review records an admin decision on the screening that held an order
+func (s *FraudScreener) review(ctx context.Context, orderID, reviewerID string, decision entity.FraudReviewDecision, note *string) error {
+	screening, err := s.fraudRepo.GetLatestByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	screening.Review(reviewerID, decision, note, s.clock.Now())
+
+	return s.fraudRepo.UpdateReview(ctx, screening)
+}
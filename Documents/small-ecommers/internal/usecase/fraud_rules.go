@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// UserVelocityRule matches users placing more orders than allowed within a window
+type UserVelocityRule struct {
+	Orders    repository.OrderRepository
+	Window    time.Duration
+	MaxOrders int
+	Score     int
+	Clock     Clock
+}
+
+// Name identifies the rule
+func (r *UserVelocityRule) Name() string {
+	return "user_velocity"
+}
+
+// Evaluate counts the orders of the user within the window, including this one
+func (r *UserVelocityRule) Evaluate(ctx context.Context, fc *FraudContext) (*entity.FraudRuleHit, error) {
+	count, err := r.Orders.CountByUserSince(ctx, fc.Order.UserID, r.Clock.Now().Add(-r.Window))
+	if err != nil {
+		return nil, err
+	}
+
+	if count <= r.MaxOrders {
+		return nil, nil
+	}
+
+	return &entity.FraudRuleHit{
+		Score:  r.Score,
+		Reason: fmt.Sprintf("%d orders by the user in the last %s", count, r.Window),
+	}, nil
+}
+
+// IPVelocityRule matches IP addresses placing more orders than allowed within a window
+type IPVelocityRule struct {
+	Orders    repository.OrderRepository
+	Window    time.Duration
+	MaxOrders int
+	Score     int
+	Clock     Clock
+}
+
+// Name identifies the rule
+func (r *IPVelocityRule) Name() string {
+	return "ip_velocity"
+}
+
+// Evaluate counts the orders from the order's IP address within the window, including this one
+func (r *IPVelocityRule) Evaluate(ctx context.Context, fc *FraudContext) (*entity.FraudRuleHit, error) {
+	if fc.Order.ClientIP == nil {
+		return nil, nil
+	}
+
+	count, err := r.Orders.CountByClientIPSince(ctx, *fc.Order.ClientIP, r.Clock.Now().Add(-r.Window))
+	if err != nil {
+		return nil, err
+	}
+
+	if count <= r.MaxOrders {
+		return nil, nil
+	}
+
+	return &entity.FraudRuleHit{
+		Score:  r.Score,
+		Reason: fmt.Sprintf("%d orders from %s in the last %s", count, *fc.Order.ClientIP, r.Window),
+	}, nil
+}
+
+// OrderValueOutlierRule matches orders far above what the user usually spends
+type OrderValueOutlierRule struct {
+	Orders repository.OrderRepository
+	// MinOrders is how many paid orders are needed before the user's average is trusted
+	MinOrders  int
+	Multiplier *big.Rat
+	Score      int
+}
+
+// Name identifies the rule
+func (r *OrderValueOutlierRule) Name() string {
+	return "order_value_outlier"
+}
+
+// Evaluate compares the order total with the average of the user's paid orders in its currency
+func (r *OrderValueOutlierRule) Evaluate(ctx context.Context, fc *FraudContext) (*entity.FraudRuleHit, error) {
+	count, total, err := r.Orders.GetPaidTotals(ctx, fc.Order.UserID, fc.Order.Total.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if count == 0 || count < r.MinOrders {
+		return nil, nil
+	}
+
+	average := total.MulRat(big.NewRat(1, int64(count)), entity.RoundHalfUp)
+	limit := average.MulRat(r.Multiplier, entity.RoundHalfUp)
+
+	if fc.Order.Total.Cmp(limit) <= 0 {
+		return nil, nil
+	}
+
+	return &entity.FraudRuleHit{
+		Score:  r.Score,
+		Reason: fmt.Sprintf("total %s is more than %s times the average of %s", fc.Order.Total, r.Multiplier.RatString(), average),
+	}, nil
+}
+
+// AddressMismatchRule matches orders billed in another country than they are shipped to
+type AddressMismatchRule struct {
+	Score int
+}
+
+// Name identifies the rule
+func (r *AddressMismatchRule) Name() string {
+	return "address_mismatch"
+}
+
+// Evaluate compares the billing and shipping countries
+func (r *AddressMismatchRule) Evaluate(ctx context.Context, fc *FraudContext) (*entity.FraudRuleHit, error) {
+	shipping, billing := fc.Order.ShippingAddress, fc.Order.BillingAddress
+	if shipping == nil || billing == nil || shipping.SameCountry(billing) {
+		return nil, nil
+	}
+
+	return &entity.FraudRuleHit{
+		Score:  r.Score,
+		Reason: fmt.Sprintf("billing country %s differs from shipping country %s", billing.Country, shipping.Country),
+	}, nil
+}
+
+// NewAccountHighValueRule matches high value orders placed by recently registered users
+type NewAccountHighValueRule struct {
+	MaxAccountAge time.Duration
+	// MinTotal is in the base currency, orders are compared at the rate locked at checkout
+	MinTotal entity.Money
+	Score    int
+	Clock    Clock
+}
+
+// Name identifies the rule
+func (r *NewAccountHighValueRule) Name() string {
+	return "new_account_high_value"
+}
+
+// Evaluate checks the account age and the order total
+func (r *NewAccountHighValueRule) Evaluate(ctx context.Context, fc *FraudContext) (*entity.FraudRuleHit, error) {
+	age := r.Clock.Now().Sub(fc.User.CreatedAt)
+	if age >= r.MaxAccountAge {
+		return nil, nil
+	}
+
+	total := fc.Order.BaseTotal()
+	if !total.SameCurrency(r.MinTotal) || total.Cmp(r.MinTotal) < 0 {
+		return nil, nil
+	}
+
+	return &entity.FraudRuleHit{
+		Score:  r.Score,
+		Reason: fmt.Sprintf("account created %s ago placed an order of %s", age.Round(time.Minute), total),
+	}, nil
+}
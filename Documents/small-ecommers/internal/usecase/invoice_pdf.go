@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"io"
+	"strconv"
+
+	"small-ecommers/pkg/pdf"
+)
+
+// Invoice layout, in points
+const (
+	invoiceMarginLeft   = 50.0
+	invoiceMarginRight  = pdf.A4Width - 50
+	invoiceMarginTop    = pdf.A4Height - 60
+	invoiceMarginBottom = 80.0
+	invoiceLineHeight   = 16.0
+	invoiceFontSize     = 10.0
+	invoiceNameLength   = 48
+)
+
+// Right edges of the item table columns
+var invoiceColumns = [...]float64{330, 400, 470, invoiceMarginRight}
+
+// renderInvoicePDF writes the invoice as a PDF document
+func renderInvoicePDF(w io.Writer, doc *invoiceDocument) error {
+	document := pdf.New(pdf.A4Width, pdf.A4Height)
+	page := document.AddPage()
+	order := doc.Order
+	invoice := doc.Invoice
+	y := invoiceMarginTop
+
+	// Header
+	page.Text(invoiceMarginLeft, y, pdf.HelveticaBold, 20, "INVOICE")
+	page.TextRight(invoiceMarginRight, y, pdf.HelveticaBold, invoiceFontSize, invoice.Number)
+	y -= invoiceLineHeight
+	page.TextRight(invoiceMarginRight, y, pdf.Helvetica, invoiceFontSize, "Date: "+invoice.IssuedAt.Format("2006-01-02"))
+	y -= invoiceLineHeight
+	page.TextRight(invoiceMarginRight, y, pdf.Helvetica, invoiceFontSize, "Order: "+order.ID)
+	y -= 2 * invoiceLineHeight
+
+	// Parties
+	top := y
+	page.Text(invoiceMarginLeft, y, pdf.HelveticaBold, invoiceFontSize, "From")
+	y -= invoiceLineHeight
+	for _, line := range []string{doc.Seller.Name, doc.Seller.Address} {
+		if line != "" {
+			page.Text(invoiceMarginLeft, y, pdf.Helvetica, invoiceFontSize, line)
+			y -= invoiceLineHeight
+		}
+	}
+
+	y = top
+	page.Text(300, y, pdf.HelveticaBold, invoiceFontSize, "Bill to")
+	y -= invoiceLineHeight
+	page.Text(300, y, pdf.Helvetica, invoiceFontSize, doc.Customer.Name)
+	y -= invoiceLineHeight
+	page.Text(300, y, pdf.Helvetica, invoiceFontSize, doc.Customer.Email)
+	y -= 3 * invoiceLineHeight
+
+	// Items
+	tableHeader := func() {
+		page.Text(invoiceMarginLeft, y, pdf.HelveticaBold, invoiceFontSize, "Item")
+		for i, title := range []string{"Qty", "Unit price", "Discount", "Amount"} {
+			page.TextRight(invoiceColumns[i], y, pdf.HelveticaBold, invoiceFontSize, title)
+		}
+		page.Line(invoiceMarginLeft, y-5, invoiceMarginRight, y-5, 0.5)
+		y -= invoiceLineHeight + 4
+	}
+	tableHeader()
+
+	for _, item := range order.Items {
+		if y < invoiceMarginBottom {
+			page = document.AddPage()
+			y = invoiceMarginTop
+			tableHeader()
+		}
+
+		name := doc.ProductNames[item.ProductID]
+		if name == "" {
+			name = item.ProductID
+		}
+		if runes := []rune(name); len(runes) > invoiceNameLength {
+			name = string(runes[:invoiceNameLength-3]) + "..."
+		}
+
+		total, err := item.Total()
+		if err != nil {
+			return err
+		}
+
+		page.Text(invoiceMarginLeft, y, pdf.Helvetica, invoiceFontSize, name)
+		for i, value := range []string{
+			strconv.Itoa(item.Quantity),
+			item.Price.Decimal(),
+			item.Discount.Neg().Decimal(),
+			total.Decimal(),
+		} {
+			page.TextRight(invoiceColumns[i], y, pdf.Helvetica, invoiceFontSize, value)
+		}
+		y -= invoiceLineHeight
+	}
+
+	// Totals
+	if y < invoiceMarginBottom+6*invoiceLineHeight {
+		page = document.AddPage()
+		y = invoiceMarginTop
+	}
+
+	page.Line(invoiceMarginLeft, y+invoiceLineHeight-5, invoiceMarginRight, y+invoiceLineHeight-5, 0.5)
+	y -= 4
+
+	breakdown := order.Breakdown
+	totals := []struct {
+		label string
+		value string
+	}{
+		{"Subtotal", breakdown.Subtotal.Decimal()},
+		{"Discounts", breakdown.DiscountTotal.Neg().Decimal()},
+		{"Tax", breakdown.Tax.Decimal()},
+		{"Shipping", breakdown.Shipping.Decimal()},
+	}
+	for _, total := range totals {
+		page.Text(invoiceColumns[1], y, pdf.Helvetica, invoiceFontSize, total.label)
+		page.TextRight(invoiceMarginRight, y, pdf.Helvetica, invoiceFontSize, total.value)
+		y -= invoiceLineHeight
+	}
+
+	page.Text(invoiceColumns[1], y, pdf.HelveticaBold, invoiceFontSize, "Total")
+	page.TextRight(invoiceMarginRight, y, pdf.HelveticaBold, invoiceFontSize, order.Total.String())
+	y -= 2 * invoiceLineHeight
+
+	if rate := order.ExchangeRate; rate != nil {
+		page.Text(invoiceMarginLeft, y, pdf.Helvetica, 8,
+			"Exchange rate: 1 "+rate.BaseCurrency+" = "+rate.Rate+" "+rate.QuoteCurrency+
+				" as of "+rate.UpdatedAt.Format("2006-01-02 15:04 MST"))
+	}
+
+	_, err := document.WriteTo(w)
+	return err
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// InvoiceSeller identifies the business issuing the invoices
+type InvoiceSeller struct {
+	Name    string
+	Address string
+}
+
+// InvoiceUseCase defines the business logic for invoice operations
+type InvoiceUseCase struct {
+	invoiceRepo repository.InvoiceRepository
+	orderRepo   repository.OrderRepository
+	productRepo repository.ProductRepository
+	userRepo    repository.UserRepository
+	blobs       BlobStore
+	seller      InvoiceSeller
+}
+
+// NewInvoiceUseCase creates a new InvoiceUseCase
+func NewInvoiceUseCase(
+	invoiceRepo repository.InvoiceRepository,
+	orderRepo repository.OrderRepository,
+	productRepo repository.ProductRepository,
+	userRepo repository.UserRepository,
+	blobs BlobStore,
+	seller InvoiceSeller,
+) *InvoiceUseCase {
+	return &InvoiceUseCase{
+		invoiceRepo: invoiceRepo,
+		orderRepo:   orderRepo,
+		productRepo: productRepo,
+		userRepo:    userRepo,
+		blobs:       blobs,
+		seller:      seller,
+	}
+}
+
+// IssueInvoice issues the invoice of a paid order
+// Issuing is idempotent, an order that already has an invoice gets the existing one
+func (uc *InvoiceUseCase) IssueInvoice(ctx context.Context, order *entity.Order) (*entity.Invoice, error) {
+	if !order.IsInvoiceable() {
+		return nil, fmt.Errorf("order is not paid")
+	}
+
+	existing, err := uc.invoiceRepo.GetByOrderID(ctx, order.ID)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, entity.ErrInvoiceNotFound) {
+		return nil, err
+	}
+
+	document, err := uc.loadInvoiceDocument(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := entity.NewInvoice(uuid.New().String(), order.ID)
+
+	err = uc.invoiceRepo.Create(ctx, invoice, func(invoice *entity.Invoice) error {
+		document.Invoice = invoice
+
+		var buf bytes.Buffer
+		if err := renderInvoicePDF(&buf, document); err != nil {
+			return fmt.Errorf("failed to render invoice: %w", err)
+		}
+
+		if err := uc.blobs.Put(ctx, invoice.StorageKey, "application/pdf", &buf); err != nil {
+			return fmt.Errorf("failed to store invoice: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// GetInvoice retrieves the invoice document of an order owned by the user
+// Paid orders without an invoice yet, e.g. paid before invoicing existed, are invoiced on demand
+func (uc *InvoiceUseCase) GetInvoice(ctx context.Context, orderID, userID string) (*entity.Invoice, io.ReadCloser, error) {
+	order, err := uc.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Do not reveal that other users' orders exist
+	if order.UserID != userID {
+		return nil, nil, entity.ErrOrderNotFound
+	}
+
+	invoice, err := uc.invoiceRepo.GetByOrderID(ctx, order.ID)
+	if errors.Is(err, entity.ErrInvoiceNotFound) && order.IsInvoiceable() {
+		invoice, err = uc.IssueInvoice(ctx, order)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := uc.blobs.Get(ctx, invoice.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return invoice, content, nil
+}
+
+// invoiceDocument holds everything printed on an invoice
+type invoiceDocument struct {
+	Invoice      *entity.Invoice
+	Seller       InvoiceSeller
+	Customer     *entity.User
+	Order        *entity.Order
+	ProductNames map[string]string
+}
+
+// loadInvoiceDocument gathers the customer and product details of an order
+func (uc *InvoiceUseCase) loadInvoiceDocument(ctx context.Context, order *entity.Order) (*invoiceDocument, error) {
+	customer, err := uc.userRepo.GetByID(ctx, order.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]string, len(order.Items))
+	for i, item := range order.Items {
+		productIDs[i] = item.ProductID
+	}
+
+	products, err := uc.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	productNames := make(map[string]string, len(products))
+	for _, product := range products {
+		productNames[product.ID] = product.Name
+	}
+
+	return &invoiceDocument{
+		Seller:       uc.seller,
+		Customer:     customer,
+		Order:        order,
+		ProductNames: productNames,
+	}, nil
+}
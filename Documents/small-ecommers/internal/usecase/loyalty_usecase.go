@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// LoyaltyRules configures how loyalty points are earned and spent
+type LoyaltyRules struct {
+	// EarnRate is the number of points earned per whole unit of the base currency spent
+	EarnRate *big.Rat
+	// PointValue is what one point is worth at checkout, in the base currency
+	PointValue entity.Money
+	// Validity is how long earned points can be spent before they lapse
+	Validity time.Duration
+}
+
+// LoyaltyUseCase defines the business logic for the loyalty points programme
+type LoyaltyUseCase struct {
+	loyaltyRepo repository.LoyaltyRepository
+	rules       LoyaltyRules
+	clock       Clock
+}
+
+// NewLoyaltyUseCase creates a new LoyaltyUseCase
+func NewLoyaltyUseCase(loyaltyRepo repository.LoyaltyRepository, rules LoyaltyRules, clock Clock) *LoyaltyUseCase {
+	return &LoyaltyUseCase{
+		loyaltyRepo: loyaltyRepo,
+		rules:       rules,
+		clock:       clock,
+	}
+}
+
+// GetBalance retrieves the points a user can spend
+// Points that lapsed since the last change are recorded as expired first
+func (uc *LoyaltyUseCase) GetBalance(ctx context.Context, userID string) (*entity.LoyaltyBalance, error) {
+	now := uc.clock.Now()
+
+	history, err := uc.loyaltyRepo.Apply(ctx, userID, func(history []*entity.LoyaltyEntry) ([]*entity.LoyaltyEntry, error) {
+		return uc.expire(userID, history, now), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	balance, _ := entity.SummarizeLoyalty(history, now)
+	return &balance, nil
+}
+
+// GetHistory retrieves the points history of a user, oldest first
+func (uc *LoyaltyUseCase) GetHistory(ctx context.Context, userID string) ([]*entity.LoyaltyEntry, error) {
+	now := uc.clock.Now()
+
+	return uc.loyaltyRepo.Apply(ctx, userID, func(history []*entity.LoyaltyEntry) ([]*entity.LoyaltyEntry, error) {
+		return uc.expire(userID, history, now), nil
+	})
+}
+
+// redemption prices the points a user wants to spend in the order currency
+// It fails early when the user does not have the points, the final check happens when they are spent
+func (uc *LoyaltyUseCase) redemption(ctx context.Context, userID string, points int64, currency string, rate *entity.ExchangeRate) (*PointsRedemption, error) {
+	if points <= 0 {
+		return nil, nil
+	}
+
+	history, err := uc.loyaltyRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if balance, _ := entity.SummarizeLoyalty(history, uc.clock.Now()); balance.Points < points {
+		return nil, entity.ErrInsufficientPoints
+	}
+
+	value := uc.rules.PointValue
+	if rate != nil {
+		value = entity.ConvertMoney(value, currency, rate.Rat(), entity.RoundHalfUp)
+	}
+
+	return &PointsRedemption{Points: points, PointValue: value}, nil
+}
+
+// redeem spends the points of an order's points discount
+func (uc *LoyaltyUseCase) redeem(ctx context.Context, order *entity.Order) error {
+	if order.PointsRedeemed <= 0 {
+		return nil
+	}
+
+	now := uc.clock.Now()
+
+	_, err := uc.loyaltyRepo.Apply(ctx, order.UserID, func(history []*entity.LoyaltyEntry) ([]*entity.LoyaltyEntry, error) {
+		entries := uc.expire(order.UserID, history, now)
+
+		if balance, _ := entity.SummarizeLoyalty(history, now); balance.Points < order.PointsRedeemed {
+			return nil, entity.ErrInsufficientPoints
+		}
+
+		return append(entries, uc.orderEntry(order, -order.PointsRedeemed, entity.LoyaltyReasonRedeem, now)), nil
+	})
+	return err
+}
+
+// earn credits the points of a paid order, once
+func (uc *LoyaltyUseCase) earn(ctx context.Context, order *entity.Order) error {
+	points := uc.earnedPoints(order)
+	if points <= 0 {
+		return nil
+	}
+
+	now := uc.clock.Now()
+
+	_, err := uc.loyaltyRepo.Apply(ctx, order.UserID, func(history []*entity.LoyaltyEntry) ([]*entity.LoyaltyEntry, error) {
+		if entity.SummarizeOrderPoints(history, order.ID).Earned > 0 {
+			return nil, nil
+		}
+
+		entry := uc.orderEntry(order, points, entity.LoyaltyReasonEarn, now)
+		expiresAt := now.Add(uc.rules.Validity)
+		entry.ExpiresAt = &expiresAt
+
+		return []*entity.LoyaltyEntry{entry}, nil
+	})
+	return err
+}
+
+// reverse takes back the points a cancelled order earned and gives back the points it spent
+func (uc *LoyaltyUseCase) reverse(ctx context.Context, order *entity.Order) error {
+	now := uc.clock.Now()
+
+	_, err := uc.loyaltyRepo.Apply(ctx, order.UserID, func(history []*entity.LoyaltyEntry) ([]*entity.LoyaltyEntry, error) {
+		points := entity.SummarizeOrderPoints(history, order.ID)
+
+		// Record lapsed points first, so the reversal is not taken from them
+		entries := uc.expire(order.UserID, history, now)
+
+		if earned := points.Earned - points.ReversedEarned; earned > 0 {
+			entries = append(entries, uc.orderEntry(order, -earned, entity.LoyaltyReasonReversal, now))
+		}
+
+		if redeemed := points.Redeemed - points.RestoredRedeemed; redeemed > 0 {
+			entry := uc.orderEntry(order, redeemed, entity.LoyaltyReasonReversal, now)
+			expiresAt := now.Add(uc.rules.Validity)
+			entry.ExpiresAt = &expiresAt
+
+			entries = append(entries, entry)
+		}
+
+		return entries, nil
+	})
+	return err
+}
+
+// refund takes back the share of the points an order earned that matches its refunded total
+func (uc *LoyaltyUseCase) refund(ctx context.Context, order *entity.Order) error {
+	if !order.Total.IsPositive() {
+		return nil
+	}
+
+	now := uc.clock.Now()
+
+	_, err := uc.loyaltyRepo.Apply(ctx, order.UserID, func(history []*entity.LoyaltyEntry) ([]*entity.LoyaltyEntry, error) {
+		points := entity.SummarizeOrderPoints(history, order.ID)
+		entries := uc.expire(order.UserID, history, now)
+
+		share := new(big.Rat).SetFrac64(order.RefundedTotal.Amount, order.Total.Amount)
+		share.Mul(share, new(big.Rat).SetInt64(points.Earned))
+
+		// Round the points taken back up so a full refund takes back every point
+		target := new(big.Int).Quo(share.Num(), share.Denom()).Int64()
+		if !share.IsInt() {
+			target++
+		}
+
+		if reversal := min(target, points.Earned) - points.ReversedEarned; reversal > 0 {
+			entries = append(entries, uc.orderEntry(order, -reversal, entity.LoyaltyReasonReversal, now))
+		}
+		return entries, nil
+	})
+	return err
+}
+
+// earnedPoints returns the points an order earns on what was spent on its products
+// Amounts in another currency are converted back to the base currency at the rate locked at checkout
+func (uc *LoyaltyUseCase) earnedPoints(order *entity.Order) int64 {
+	if uc.rules.EarnRate == nil || uc.rules.EarnRate.Sign() <= 0 {
+		return 0
+	}
+
+	spent, ok := new(big.Rat).SetString(order.Breakdown.Subtotal.Sub(order.Breakdown.DiscountTotal).Decimal())
+	if !ok || spent.Sign() <= 0 {
+		return 0
+	}
+
+	if order.ExchangeRate != nil {
+		spent.Quo(spent, order.ExchangeRate.Rat())
+	}
+
+	points := spent.Mul(spent, uc.rules.EarnRate)
+	return new(big.Int).Quo(points.Num(), points.Denom()).Int64()
+}
+
+// expire returns expire entries for the points of a history that have lapsed by now
+func (uc *LoyaltyUseCase) expire(userID string, history []*entity.LoyaltyEntry, now time.Time) []*entity.LoyaltyEntry {
+	if _, lapsed := entity.SummarizeLoyalty(history, now); lapsed > 0 {
+		return []*entity.LoyaltyEntry{
+			entity.NewLoyaltyEntry(uuid.New().String(), userID, -lapsed, entity.LoyaltyReasonExpire, now),
+		}
+	}
+	return nil
+}
+
+// orderEntry creates a points entry for an order
+func (uc *LoyaltyUseCase) orderEntry(order *entity.Order, points int64, reason entity.LoyaltyReason, now time.Time) *entity.LoyaltyEntry {
+	entry := entity.NewLoyaltyEntry(uuid.New().String(), order.UserID, points, reason, now)
+	entry.OrderID = &order.ID
+	return entry
+}
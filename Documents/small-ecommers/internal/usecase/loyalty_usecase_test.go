@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// memLoyaltyLedger is an in-memory LoyaltyRepository that keeps every entry
+type memLoyaltyLedger struct {
+	repository.LoyaltyRepository
+	history map[string][]*entity.LoyaltyEntry
+}
+
+func (r *memLoyaltyLedger) GetByUserID(ctx context.Context, userID string) ([]*entity.LoyaltyEntry, error) {
+	return r.history[userID], nil
+}
+
+func (r *memLoyaltyLedger) Apply(ctx context.Context, userID string, fn func(history []*entity.LoyaltyEntry) ([]*entity.LoyaltyEntry, error)) ([]*entity.LoyaltyEntry, error) {
+	entries, err := fn(r.history[userID])
+	if err != nil {
+		return nil, err
+	}
+	r.history[userID] = append(r.history[userID], entries...)
+	return r.history[userID], nil
+}
+
+// newLoyaltyFixture earns a point per dollar, valid for 30 days
+func newLoyaltyFixture(start time.Time) (*LoyaltyUseCase, *memLoyaltyLedger, *fakeClock) {
+	ledger := &memLoyaltyLedger{history: map[string][]*entity.LoyaltyEntry{}}
+	clock := &fakeClock{now: start}
+	rules := LoyaltyRules{
+		EarnRate:   big.NewRat(1, 1),
+		PointValue: entity.NewMoney(1, "USD"),
+		Validity:   30 * 24 * time.Hour,
+	}
+	return NewLoyaltyUseCase(ledger, rules, clock), ledger, clock
+}
+
+// loyaltyOrder is a USD order of user-1 for the given total in cents, with no discounts
+func loyaltyOrder(id string, total int64) *entity.Order {
+	zero := entity.Zero("USD")
+	return entity.NewOrder(id, "user-1", nil, entity.OrderBreakdown{
+		Subtotal:          entity.NewMoney(total, "USD"),
+		LineDiscountTotal: zero,
+		OrderDiscount:     zero,
+		PointsDiscount:    zero,
+		DiscountTotal:     zero,
+		Tax:               zero,
+		Shipping:          zero,
+		Total:             entity.NewMoney(total, "USD"),
+	})
+}
+
+// mustBalance fails the test unless user-1 has want points
+func mustBalance(t *testing.T, uc *LoyaltyUseCase, want int64) {
+	t.Helper()
+	balance, err := uc.GetBalance(context.Background(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Points != want {
+		t.Fatalf("balance is %d points, want %d", balance.Points, want)
+	}
+}
+
+func TestLoyaltyEarnCreditsPaidOrderOnce(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	uc, ledger, _ := newLoyaltyFixture(start)
+	ctx := context.Background()
+
+	order := loyaltyOrder("order-1", 12345)
+	for i := 0; i < 2; i++ {
+		if err := uc.earn(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mustBalance(t, uc, 123)
+
+	history := ledger.history["user-1"]
+	if len(history) != 1 {
+		t.Fatalf("%d entries recorded, want 1", len(history))
+	}
+	if want := start.Add(30 * 24 * time.Hour); history[0].ExpiresAt == nil || !history[0].ExpiresAt.Equal(want) {
+		t.Errorf("points expire at %v, want %v", history[0].ExpiresAt, want)
+	}
+}
+
+func TestLoyaltyRedeemSpendsPoints(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	uc, _, _ := newLoyaltyFixture(start)
+	ctx := context.Background()
+
+	if err := uc.earn(ctx, loyaltyOrder("order-1", 10000)); err != nil {
+		t.Fatal(err)
+	}
+
+	order := loyaltyOrder("order-2", 5000)
+	order.PointsRedeemed = 30
+	if err := uc.redeem(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	mustBalance(t, uc, 70)
+
+	order = loyaltyOrder("order-3", 5000)
+	order.PointsRedeemed = 71
+	if err := uc.redeem(ctx, order); !errors.Is(err, entity.ErrInsufficientPoints) {
+		t.Fatalf("got %v, want ErrInsufficientPoints", err)
+	}
+	mustBalance(t, uc, 70)
+}
+
+func TestLoyaltyReverseUndoesCancelledOrder(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	uc, _, _ := newLoyaltyFixture(start)
+	ctx := context.Background()
+
+	if err := uc.earn(ctx, loyaltyOrder("order-1", 10000)); err != nil {
+		t.Fatal(err)
+	}
+
+	order := loyaltyOrder("order-2", 5000)
+	order.PointsRedeemed = 30
+	if err := uc.redeem(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.earn(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	mustBalance(t, uc, 120)
+
+	// The 50 points earned are taken back and the 30 spent given back, only once
+	for i := 0; i < 2; i++ {
+		if err := uc.reverse(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+		mustBalance(t, uc, 100)
+	}
+}
+
+func TestLoyaltyRefundTakesBackShareOfEarnedPoints(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	uc, _, _ := newLoyaltyFixture(start)
+	ctx := context.Background()
+
+	order := loyaltyOrder("order-1", 10000)
+	if err := uc.earn(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		refunded int64
+		want     int64
+	}{
+		{2500, 75},
+		// Refunding the same amount again takes nothing more
+		{2500, 75},
+		// A third of the order rounds the points taken back up
+		{3333, 66},
+		{10000, 0},
+	}
+
+	for _, tt := range tests {
+		order.RefundedTotal = entity.NewMoney(tt.refunded, "USD")
+		if err := uc.refund(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+		mustBalance(t, uc, tt.want)
+	}
+}
+
+func TestLoyaltyPointsExpire(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	uc, ledger, clock := newLoyaltyFixture(start)
+	ctx := context.Background()
+
+	if err := uc.earn(ctx, loyaltyOrder("order-1", 10000)); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.now = start.Add(30 * 24 * time.Hour)
+	mustBalance(t, uc, 0)
+
+	history := ledger.history["user-1"]
+	if last := history[len(history)-1]; last.Reason != entity.LoyaltyReasonExpire || last.Points != -100 {
+		t.Fatalf("last entry is %s of %d points, want expire of -100", last.Reason, last.Points)
+	}
+
+	// Reading the balance again does not expire the points twice
+	mustBalance(t, uc, 0)
+	if len(ledger.history["user-1"]) != len(history) {
+		t.Errorf("%d entries recorded, want %d", len(ledger.history["user-1"]), len(history))
+	}
+}
+
+func TestLoyaltyReversalAfterExpiryIsNotTakenFromLapsedPoints(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	undo := map[string]func(uc *LoyaltyUseCase, order *entity.Order) error{
+		"reverse": func(uc *LoyaltyUseCase, order *entity.Order) error {
+			return uc.reverse(context.Background(), order)
+		},
+		"refund": func(uc *LoyaltyUseCase, order *entity.Order) error {
+			order.RefundedTotal = order.Total
+			return uc.refund(context.Background(), order)
+		},
+	}
+
+	for name, fn := range undo {
+		uc, _, clock := newLoyaltyFixture(start)
+
+		if err := uc.earn(context.Background(), loyaltyOrder("order-1", 10000)); err != nil {
+			t.Fatal(err)
+		}
+
+		clock.now = start.Add(20 * 24 * time.Hour)
+		order := loyaltyOrder("order-2", 5000)
+		if err := uc.earn(context.Background(), order); err != nil {
+			t.Fatal(err)
+		}
+
+		// The first 100 points have lapsed, but nothing recorded it yet
+		clock.now = start.Add(31 * 24 * time.Hour)
+		if err := fn(uc, order); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		balance, err := uc.GetBalance(context.Background(), "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance.Points != 0 {
+			t.Errorf("%s: balance is %d points, want 0", name, balance.Points)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package usecase
+
+import "context"
+
+// Mailer defines the interface for sending email
+type Mailer interface {
+	Send(ctx context.Context, msg *EmailMessage) error
+}
+
+// EmailMessage represents a plain text email to a single recipient
+type EmailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+//go:embed templates/email/*.tmpl
+var emailTemplateFiles embed.FS
+
+// orderEmailTemplates maps the order events customers are emailed about to their template files
+var orderEmailTemplates = map[entity.OrderEventType]string{
+	entity.OrderEventCreated:   "order_created.tmpl",
+	entity.OrderEventPaid:      "order_paid.tmpl",
+	entity.OrderEventShipped:   "order_shipped.tmpl",
+	entity.OrderEventCancelled: "order_cancelled.tmpl",
+	entity.OrderEventRefunded:  "order_refunded.tmpl",
+}
+
+// orderEmailData is what the order email templates are rendered with
+type orderEmailData struct {
+	StoreName string
+	Customer  *entity.User
+	Order     *entity.Order
+	Amount    *entity.Money
+}
+
+// NotificationUseCase emails customers when their orders change
+type NotificationUseCase struct {
+	userRepo  repository.UserRepository
+	mailer    Mailer
+	storeName string
+	templates map[entity.OrderEventType]*template.Template
+}
+
+// NewNotificationUseCase creates a new NotificationUseCase
+// It fails when one of the embedded email templates does not parse
+func NewNotificationUseCase(userRepo repository.UserRepository, mailer Mailer, storeName string) (*NotificationUseCase, error) {
+	templates := make(map[entity.OrderEventType]*template.Template, len(orderEmailTemplates))
+
+	for eventType, file := range orderEmailTemplates {
+		tmpl, err := template.New(file).Option("missingkey=error").ParseFS(emailTemplateFiles, "templates/email/layout.tmpl", "templates/email/"+file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s: %w", file, err)
+		}
+		templates[eventType] = tmpl
+	}
+
+	return &NotificationUseCase{
+		userRepo:  userRepo,
+		mailer:    mailer,
+		storeName: storeName,
+		templates: templates,
+	}, nil
+}
+
+// Events returns the order event types customers are emailed about
+func (uc *NotificationUseCase) Events() []entity.OrderEventType {
+	types := make([]entity.OrderEventType, 0, len(uc.templates))
+	for eventType := range uc.templates {
+		types = append(types, eventType)
+	}
+	return types
+}
+
+// HandleOrderEvent emails the customer of an order about an event
+func (uc *NotificationUseCase) HandleOrderEvent(ctx context.Context, event *entity.OrderEvent) error {
+	tmpl, ok := uc.templates[event.Type]
+	if !ok {
+		return nil
+	}
+
+	customer, err := uc.userRepo.GetByID(ctx, event.Order.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	data := &orderEmailData{
+		StoreName: uc.storeName,
+		Customer:  customer,
+		Order:     event.Order,
+		Amount:    event.Amount,
+	}
+
+	subject, err := renderEmailPart(tmpl, "subject", data)
+	if err != nil {
+		return err
+	}
+
+	body, err := renderEmailPart(tmpl, "body", data)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.mailer.Send(ctx, &EmailMessage{To: customer.Email, Subject: subject, Body: body}); err != nil {
+		return fmt.Errorf("failed to send %s email: %w", event.Type, err)
+	}
+
+	return nil
+}
+
+// renderEmailPart executes one of the named templates of an email
+func renderEmailPart(tmpl *template.Template, name string, data *orderEmailData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to render email %s: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// exportBatchSize is how many orders are read from the database at a time during an export
+const exportBatchSize = 500
+
+// errExportIncomplete is written at the end of an export that failed after it started
+var errExportIncomplete = errors.New("export incomplete: failed to read orders")
+
+// ExportFormat is a file format orders can be exported to
+type ExportFormat string
+
+const (
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatNDJSON ExportFormat = "ndjson"
+)
+
+// ContentType returns the media type of the format
+func (f ExportFormat) ContentType() string {
+	if f == ExportFormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// orderCSVHeader are the columns of a CSV export, one row per order item
+var orderCSVHeader = []string{
+	"order_id", "created_at", "status", "user_id", "currency",
+	"subtotal", "discount_total", "tax", "shipping", "total", "refunded_total",
+	"item_id", "product_id", "variant_id", "quantity", "unit_price", "item_discount", "item_status",
+}
+
+// OrderExportUseCase defines the business logic for exporting orders to files
+type OrderExportUseCase struct {
+	orderRepo repository.OrderRepository
+}
+
+// NewOrderExportUseCase creates a new OrderExportUseCase
+func NewOrderExportUseCase(orderRepo repository.OrderRepository) *OrderExportUseCase {
+	return &OrderExportUseCase{
+		orderRepo: orderRepo,
+	}
+}
+
+// ExportOrders validates an export of the orders matching the request and returns the function writing it
+// The export needs a date range. Orders are written oldest first and read a batch at a time,
+// so exports of any size use little memory. Sorting and paging fields of the request are ignored.
+// The first batch is read before returning, so a failing query is reported before anything is written;
+// a batch failing later ends the file with an error row or line.
+func (uc *OrderExportUseCase) ExportOrders(ctx context.Context, req *OrderSearchRequest, format ExportFormat) (func(w io.Writer) error, error) {
+	filter, err := orderFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.From == nil || req.To == nil {
+		return nil, fmt.Errorf("%w: an export needs both from and to", entity.ErrInvalidOrderQuery)
+	}
+
+	var newWriter func(w io.Writer) orderWriter
+	switch format {
+	case ExportFormatCSV:
+		newWriter = newCSVOrderWriter
+	case ExportFormatNDJSON:
+		newWriter = newNDJSONOrderWriter
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", entity.ErrInvalidOrderQuery, format)
+	}
+
+	search := &repository.OrderSearch{
+		Filter:       *filter,
+		Sort:         repository.OrderSortCreatedAt,
+		Limit:        exportBatchSize,
+		IncludeItems: true,
+	}
+
+	first, err := uc.orderRepo.Search(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(w io.Writer) error {
+		out := newWriter(w)
+		orders := first
+
+		for {
+			for _, order := range orders {
+				if err := out.WriteOrder(order); err != nil {
+					return fmt.Errorf("failed to write order %s: %w", order.ID, err)
+				}
+			}
+
+			if len(orders) < exportBatchSize {
+				return out.Flush()
+			}
+
+			last := orders[len(orders)-1]
+			search.After = &repository.OrderCursor{CreatedAt: last.CreatedAt, Total: last.Total, ID: last.ID}
+
+			if orders, err = uc.orderRepo.Search(ctx, search); err != nil {
+				// The file is already partly sent, its last row tells the reader it is incomplete
+				if trailerErr := out.WriteError(errExportIncomplete); trailerErr == nil {
+					out.Flush()
+				}
+				return err
+			}
+		}
+	}, nil
+}
+
+// orderWriter writes exported orders in a file format
+type orderWriter interface {
+	WriteOrder(order *entity.Order) error
+	// WriteError ends a file that could not be completed with the reason
+	WriteError(err error) error
+	Flush() error
+}
+
+// csvOrderWriter writes a CSV row per order item, repeating the order columns
+// An order without items gets a single row with empty item columns
+type csvOrderWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+// newCSVOrderWriter creates an orderWriter writing CSV
+func newCSVOrderWriter(w io.Writer) orderWriter {
+	return &csvOrderWriter{w: csv.NewWriter(w)}
+}
+
+// WriteOrder writes the rows of an order, after the header on the first call
+func (cw *csvOrderWriter) WriteOrder(order *entity.Order) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+
+	columns := []string{
+		order.ID,
+		order.CreatedAt.UTC().Format(time.RFC3339),
+		string(order.Status),
+		order.UserID,
+		order.Total.Currency,
+		order.Breakdown.Subtotal.Decimal(),
+		order.Breakdown.DiscountTotal.Decimal(),
+		order.Breakdown.Tax.Decimal(),
+		order.Breakdown.Shipping.Decimal(),
+		order.Total.Decimal(),
+		order.RefundedTotal.Decimal(),
+	}
+
+	if len(order.Items) == 0 {
+		return cw.w.Write(append(columns, "", "", "", "", "", "", ""))
+	}
+
+	for _, item := range order.Items {
+		row := append(columns[:len(columns):len(columns)],
+			item.ID,
+			item.ProductID,
+			item.VariantID,
+			strconv.Itoa(item.Quantity),
+			item.Price.Decimal(),
+			item.Discount.Decimal(),
+			string(item.Status),
+		)
+		if err := cw.w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteError writes a row with "error" as order_id and the reason in the next column
+func (cw *csvOrderWriter) WriteError(err error) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	row := make([]string, len(orderCSVHeader))
+	row[0], row[1] = "error", err.Error()
+	return cw.w.Write(row)
+}
+
+// Flush writes any buffered rows, and the header of an empty export
+func (cw *csvOrderWriter) Flush() error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// writeHeader writes the header row, once
+func (cw *csvOrderWriter) writeHeader() error {
+	if cw.headerWritten {
+		return nil
+	}
+	cw.headerWritten = true
+	return cw.w.Write(orderCSVHeader)
+}
+
+// ndjsonOrderWriter writes each order, with its items, as a JSON object on its own line
+type ndjsonOrderWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+// newNDJSONOrderWriter creates an orderWriter writing JSON Lines
+func newNDJSONOrderWriter(w io.Writer) orderWriter {
+	buf := bufio.NewWriter(w)
+	return &ndjsonOrderWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+// WriteOrder writes an order as one line
+func (jw *ndjsonOrderWriter) WriteOrder(order *entity.Order) error {
+	return jw.enc.Encode(order)
+}
+
+// WriteError writes a line holding only an error field with the reason
+func (jw *ndjsonOrderWriter) WriteError(err error) error {
+	return jw.enc.Encode(map[string]string{"error": err.Error()})
+}
+
+// Flush writes any buffered lines
+func (jw *ndjsonOrderWriter) Flush() error {
+	return jw.buf.Flush()
+}
@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// Order search page sizes
+const (
+	defaultOrderPageSize = 50
+	maxOrderPageSize     = 200
+)
+
+// OrderSearchRequest represents the filters of an admin order search
+type OrderSearchRequest struct {
+	Statuses  []entity.OrderStatus
+	UserID    string
+	ProductID string
+	// From is inclusive and To exclusive
+	From *time.Time
+	To   *time.Time
+	// Currency is required with MinTotal or MaxTotal, which are decimal strings in it
+	Currency string
+	MinTotal string
+	MaxTotal string
+	// Sort is created_at or total, prefixed with - for descending, and defaults to -created_at
+	Sort string
+	// Cursor is the next_cursor of the previous page
+	Cursor string
+	Limit  int
+	// IncludeItems loads the items and balance payments of each order
+	IncludeItems bool
+}
+
+// OrderHistoryRequest represents a page of a customer's order history, newest first
+type OrderHistoryRequest struct {
+	Statuses []entity.OrderStatus
+	// Cursor is the next_cursor of the previous page
+	Cursor       string
+	Limit        int
+	IncludeItems bool
+}
+
+// OrderPage is a page of order search results
+type OrderPage struct {
+	Orders []*entity.Order `json:"orders"`
+	// NextCursor fetches the next page, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// orderCursor is the content of an encoded cursor
+// The sort is kept so a cursor cannot be reused with a different sort
+type orderCursor struct {
+	Sort      string    `json:"s"`
+	CreatedAt time.Time `json:"c"`
+	Total     string    `json:"t"`
+	Currency  string    `json:"cu"`
+	ID        string    `json:"id"`
+}
+
+// SearchOrders retrieves a page of the orders matching the request
+func (uc *OrderUseCase) SearchOrders(ctx context.Context, req *OrderSearchRequest) (*OrderPage, error) {
+	filter, err := orderFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	sort := req.Sort
+	if sort == "" {
+		sort = "-" + string(repository.OrderSortCreatedAt)
+	}
+
+	search := &repository.OrderSearch{
+		Filter:       *filter,
+		IncludeItems: req.IncludeItems,
+	}
+
+	field := sort
+	if field[0] == '-' {
+		search.Descending = true
+		field = field[1:]
+	}
+
+	switch repository.OrderSortField(field) {
+	case repository.OrderSortCreatedAt, repository.OrderSortTotal:
+		search.Sort = repository.OrderSortField(field)
+	default:
+		return nil, fmt.Errorf("%w: unknown sort %q", entity.ErrInvalidOrderQuery, req.Sort)
+	}
+
+	switch {
+	case req.Limit == 0:
+		search.Limit = defaultOrderPageSize
+	case req.Limit < 0 || req.Limit > maxOrderPageSize:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", entity.ErrInvalidOrderQuery, maxOrderPageSize)
+	default:
+		search.Limit = req.Limit
+	}
+
+	if req.Cursor != "" {
+		if search.After, err = decodeOrderCursor(req.Cursor, sort); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch one order more than the page holds to know whether there is a next page
+	pageSize := search.Limit
+	search.Limit++
+
+	orders, err := uc.orderRepo.Search(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &OrderPage{Orders: orders}
+	if page.Orders == nil {
+		page.Orders = []*entity.Order{}
+	}
+
+	if len(orders) > pageSize {
+		page.Orders = orders[:pageSize]
+		page.NextCursor = encodeOrderCursor(page.Orders[pageSize-1], sort)
+	}
+
+	return page, nil
+}
+
+// GetUserOrders retrieves a page of a user's orders, newest first
+func (uc *OrderUseCase) GetUserOrders(ctx context.Context, userID string, req *OrderHistoryRequest) (*OrderPage, error) {
+	return uc.SearchOrders(ctx, &OrderSearchRequest{
+		Statuses:     req.Statuses,
+		UserID:       userID,
+		Cursor:       req.Cursor,
+		Limit:        req.Limit,
+		IncludeItems: req.IncludeItems,
+	})
+}
+
+// CountOrders counts the orders matching the filters of the request
+// Sorting and paging fields are ignored
+func (uc *OrderUseCase) CountOrders(ctx context.Context, req *OrderSearchRequest) (int, error) {
+	filter, err := orderFilter(req)
+	if err != nil {
+		return 0, err
+	}
+
+	return uc.orderRepo.Count(ctx, filter)
+}
+
+// orderFilter validates the filters of a search request
+func orderFilter(req *OrderSearchRequest) (*repository.OrderFilter, error) {
+	for _, status := range req.Statuses {
+		if !status.IsValid() {
+			return nil, fmt.Errorf("%w: unknown status %q", entity.ErrInvalidOrderQuery, status)
+		}
+	}
+
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return nil, fmt.Errorf("%w: from must be before to", entity.ErrInvalidOrderQuery)
+	}
+
+	filter := &repository.OrderFilter{
+		Statuses:    req.Statuses,
+		UserID:      req.UserID,
+		ProductID:   req.ProductID,
+		CreatedFrom: req.From,
+		CreatedTo:   req.To,
+		Currency:    req.Currency,
+	}
+
+	if (req.MinTotal != "" || req.MaxTotal != "") && req.Currency == "" {
+		return nil, fmt.Errorf("%w: currency is required with a total range", entity.ErrInvalidOrderQuery)
+	}
+
+	for _, bound := range []struct {
+		value string
+		dest  **entity.Money
+	}{
+		{req.MinTotal, &filter.MinTotal},
+		{req.MaxTotal, &filter.MaxTotal},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		total, err := entity.ParseMoney(bound.value, req.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid total %q", entity.ErrInvalidOrderQuery, bound.value)
+		}
+		*bound.dest = &total
+	}
+
+	if filter.MinTotal != nil && filter.MaxTotal != nil && filter.MinTotal.Cmp(*filter.MaxTotal) > 0 {
+		return nil, fmt.Errorf("%w: min_total must not exceed max_total", entity.ErrInvalidOrderQuery)
+	}
+
+	return filter, nil
+}
+
+// encodeOrderCursor returns the cursor of the page that follows an order
+func encodeOrderCursor(order *entity.Order, sort string) string {
+	return encodeCursor(&orderCursor{
+		Sort:      sort,
+		CreatedAt: order.CreatedAt,
+		Total:     order.Total.Decimal(),
+		Currency:  order.Total.Currency,
+		ID:        order.ID,
+	})
+}
+
+// decodeOrderCursor reads a cursor, which must have been issued for the same sort
+func decodeOrderCursor(encoded, sort string) (*repository.OrderCursor, error) {
+	var cursor orderCursor
+	if err := decodeCursor(encoded, &cursor); err != nil {
+		return nil, err
+	}
+
+	if cursor.ID == "" || cursor.Sort != sort {
+		return nil, entity.ErrInvalidCursor
+	}
+
+	total, err := entity.ParseMoney(cursor.Total, cursor.Currency)
+	if err != nil {
+		return nil, entity.ErrInvalidCursor
+	}
+
+	return &repository.OrderCursor{
+		CreatedAt: cursor.CreatedAt,
+		Total:     total,
+		ID:        cursor.ID,
+	}, nil
+}
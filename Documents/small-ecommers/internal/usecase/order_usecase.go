@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -12,15 +13,18 @@ import (
 
 // OrderUseCase defines the business logic for order operations
 type OrderUseCase struct {
-	orderRepo     repository.OrderRepository
-	cartRepo      repository.CartRepository
-	productRepo   repository.ProductRepository
-	kafkaProducer KafkaProducer
-}
-
-// KafkaProducer defines the interface for Kafka producer operations
-type KafkaProducer interface {
-	PublishOrderCreated(ctx context.Context, order map[string]interface{}) error
+	orderRepo   repository.OrderRepository
+	cartRepo    repository.CartRepository
+	productRepo repository.ProductRepository
+	carts       *CartUseCase
+	prices      *PriceResolver
+	pricing     *PricingPipeline
+	invoices    *InvoiceUseCase
+	credits     *CreditUseCase
+	loyalty     *LoyaltyUseCase
+	fraud       *FraudScreener
+	payments    PaymentGateway
+	events      EventPublisher
 }
 
 // NewOrderUseCase creates a new OrderUseCase
@@ -28,13 +32,29 @@ func NewOrderUseCase(
 	orderRepo repository.OrderRepository,
 	cartRepo repository.CartRepository,
 	productRepo repository.ProductRepository,
-	kafkaProducer KafkaProducer,
+	carts *CartUseCase,
+	prices *PriceResolver,
+	pricing *PricingPipeline,
+	invoices *InvoiceUseCase,
+	credits *CreditUseCase,
+	loyalty *LoyaltyUseCase,
+	fraud *FraudScreener,
+	payments PaymentGateway,
+	events EventPublisher,
 ) *OrderUseCase {
 	return &OrderUseCase{
-		orderRepo:     orderRepo,
-		cartRepo:      cartRepo,
-		productRepo:   productRepo,
-		kafkaProducer: kafkaProducer,
+		orderRepo:   orderRepo,
+		cartRepo:    cartRepo,
+		productRepo: productRepo,
+		carts:       carts,
+		prices:      prices,
+		pricing:     pricing,
+		invoices:    invoices,
+		credits:     credits,
+		loyalty:     loyalty,
+		fraud:       fraud,
+		payments:    payments,
+		events:      events,
 	}
 }
 
@@ -46,11 +66,35 @@ type CreateOrderRequest struct {
 // CreateOrderItemRequest represents an item in the create order request
 type CreateOrderItemRequest struct {
 	ProductID string `json:"product_id"`
+	// VariantID defaults to the default variant of the product
+	VariantID string `json:"variant_id,omitempty"`
 	Quantity  int    `json:"quantity"`
 }
 
+// CheckoutRequest represents the addresses, points and balances of an order created from the cart
+type CheckoutRequest struct {
+	ShippingAddress *entity.Address `json:"shipping_address,omitempty"`
+	// BillingAddress defaults to the shipping address
+	BillingAddress *entity.Address `json:"billing_address,omitempty"`
+	// ClientIP is the address the request came from, set by the handler
+	ClientIP string `json:"-"`
+	// RedeemPoints are loyalty points to spend as a discount, capped at what the order is worth
+	RedeemPoints   int64    `json:"redeem_points,omitempty"`
+	GiftCardCodes  []string `json:"gift_card_codes,omitempty"`
+	UseStoreCredit bool     `json:"use_store_credit,omitempty"`
+}
+
+// RefundRequest represents the request to refund an order to store credit
+type RefundRequest struct {
+	// Amount defaults to everything not refunded yet
+	Amount *entity.Money `json:"amount,omitempty"`
+	Note   *string       `json:"note,omitempty"`
+}
+
 // CreateOrder creates a new order from cart items
-func (uc *OrderUseCase) CreateOrder(ctx context.Context, userID string) (*entity.Order, error) {
+// Gift cards and store credit in the request are applied before the rest is left to the gateway,
+// and an order they cover in full is paid straight away
+func (uc *OrderUseCase) CreateOrder(ctx context.Context, userID string, req *CheckoutRequest) (*entity.Order, error) {
 	// Get user's cart
 	cart, err := uc.cartRepo.GetByUserID(ctx, userID)
 	if err != nil {
@@ -61,9 +105,90 @@ func (uc *OrderUseCase) CreateOrder(ctx context.Context, userID string) (*entity
 		return nil, fmt.Errorf("cart is empty")
 	}
 
-	// Get product IDs
-	productIDs := make([]string, len(cart.Items))
+	items := make([]CreateOrderItemRequest, len(cart.Items))
 	for i, item := range cart.Items {
+		items[i] = CreateOrderItemRequest{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		}
+	}
+
+	order, err := uc.placeOrder(ctx, userID, cart.Currency, items, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req != nil && (len(req.GiftCardCodes) > 0 || req.UseStoreCredit) {
+		payments, err := uc.credits.redeem(ctx, order, req)
+		if err != nil {
+			// Release the stock of the order the balances could not pay for, the cart is kept
+			if _, cancelErr := uc.cancel(ctx, order.ID); cancelErr != nil {
+				fmt.Printf("Failed to cancel order %s: %v\n", order.ID, cancelErr)
+			}
+			return nil, err
+		}
+		order.Payments = payments
+	}
+
+	// Clear cart
+	cart.Clear()
+	if err := uc.cartRepo.Update(ctx, cart); err != nil {
+		// Log error but don't fail the order creation
+		fmt.Printf("Failed to clear cart: %v\n", err)
+	}
+
+	uc.publish(ctx, entity.OrderEventCreated, order)
+
+	if len(order.Payments) > 0 && order.AmountDue().IsZero() {
+		return uc.PayOrder(ctx, userID, order.ID)
+	}
+
+	return order, nil
+}
+
+// CreateOrderFromItems creates a new order for the given items without touching the cart
+// It is used for orders placed on the user's behalf, such as subscription deliveries
+func (uc *OrderUseCase) CreateOrderFromItems(ctx context.Context, userID, currency string, req *CreateOrderRequest) (*entity.Order, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("order has no items")
+	}
+
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity for product: %s", item.ProductID)
+		}
+	}
+
+	order, err := uc.placeOrder(ctx, userID, currency, req.Items, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.publish(ctx, entity.OrderEventCreated, order)
+
+	return order, nil
+}
+
+// placeOrder prices, reserves stock for and saves an order in the given currency
+// The checkout request is optional, it carries the addresses and the loyalty points to spend
+func (uc *OrderUseCase) placeOrder(ctx context.Context, userID, currency string, items []CreateOrderItemRequest, checkout *CheckoutRequest) (*entity.Order, error) {
+	if checkout == nil {
+		checkout = &CheckoutRequest{}
+	}
+
+	for _, address := range []*entity.Address{checkout.ShippingAddress, checkout.BillingAddress} {
+		if address == nil {
+			continue
+		}
+		if err := address.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Get product IDs
+	productIDs := make([]string, len(items))
+	for i, item := range items {
 		productIDs[i] = item.ProductID
 	}
 
@@ -79,98 +204,289 @@ func (uc *OrderUseCase) CreateOrder(ctx context.Context, userID string) (*entity
 		productMap[product.ID] = product
 	}
 
-	// Create order items
-	orderItems := make([]*entity.OrderItem, len(cart.Items))
-	total := 0.0
+	// Lock the exchange rates for the whole checkout
+	prices := uc.prices.Snapshot()
+
+	var lockedRate *entity.ExchangeRate
+	if currency != prices.BaseCurrency() {
+		lockedRate, err = prices.Rate(ctx, prices.BaseCurrency(), currency)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	for i, cartItem := range cart.Items {
-		product, exists := productMap[cartItem.ProductID]
+	// Build pricing lines at current prices in the order currency
+	lines := make([]*PricingLine, len(items))
+
+	for i, item := range items {
+		product, exists := productMap[item.ProductID]
 		if !exists {
-			return nil, fmt.Errorf("product not found: %s", cartItem.ProductID)
+			return nil, fmt.Errorf("product not found: %s", item.ProductID)
+		}
+
+		if product.IsArchived() {
+			return nil, fmt.Errorf("%w: %s", entity.ErrProductArchived, product.Name)
 		}
 
-		// Check stock
-		if !product.HasStock(cartItem.Quantity) {
+		variant, err := productVariant(product, item.VariantID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check stock, allowing backorders where the product permits them
+		if !product.CanFulfill(variant, item.Quantity) {
 			return nil, fmt.Errorf("insufficient stock for product: %s", product.Name)
 		}
 
+		resolved, err := prices.Resolve(ctx, product, variant, currency)
+		if err != nil {
+			return nil, err
+		}
+
+		lines[i] = &PricingLine{
+			ProductID: item.ProductID,
+			VariantID: variant.ID,
+			Quantity:  item.Quantity,
+			UnitPrice: resolved.Price,
+		}
+	}
+
+	redemption, err := uc.loyalty.redemption(ctx, userID, checkout.RedeemPoints, currency, lockedRate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate subtotal, discounts, tax and shipping
+	priced, err := uc.pricing.Price(ctx, userID, lines, redemption, lockedRate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create order items
+	orderItems := make([]*entity.OrderItem, len(priced.Lines))
+	for i, line := range priced.Lines {
 		orderItems[i] = entity.NewOrderItem(
 			uuid.New().String(),
-			cartItem.ProductID,
-			cartItem.Quantity,
-			cartItem.Price,
+			line.ProductID,
+			line.VariantID,
+			line.Quantity,
+			line.UnitPrice,
 		)
+		orderItems[i].Discount = line.Discount
 
-		total += cartItem.Price * float64(cartItem.Quantity)
+		// Take what stock covers and backorder the rest
+		product := productMap[line.ProductID]
+		variant, _ := product.Variant(line.VariantID)
+		backordered, err := product.Allocate(variant, line.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("insufficient stock for product: %s", product.Name)
+		}
+		if backordered > 0 {
+			orderItems[i].Backorder(backordered, product.AvailableAt)
+		}
 	}
 
 	// Create order
-	order := entity.NewOrder(uuid.New().String(), userID, orderItems, total)
+	order := entity.NewOrder(uuid.New().String(), userID, orderItems, priced.Breakdown)
+	order.ExchangeRate = lockedRate
+	order.PointsRedeemed = priced.PointsRedeemed
+	order.ShippingAddress = checkout.ShippingAddress
+	order.BillingAddress = checkout.BillingAddress
+	if order.BillingAddress == nil {
+		order.BillingAddress = checkout.ShippingAddress
+	}
+	if checkout.ClientIP != "" {
+		order.ClientIP = &checkout.ClientIP
+	}
 
 	// Save order
 	if err := uc.orderRepo.Create(ctx, order); err != nil {
 		return nil, err
 	}
 
-	// Publish order created event to Kafka
-	orderEvent := map[string]interface{}{
-		"id":      order.ID,
-		"user_id": order.UserID,
-		"total":   order.Total,
-		"status":  order.Status,
-		"items":   order.Items,
+	if err := uc.loyalty.redeem(ctx, order); err != nil {
+		// Release the stock of the order the points could not pay for
+		if _, cancelErr := uc.cancel(ctx, order.ID); cancelErr != nil {
+			fmt.Printf("Failed to cancel order %s: %v\n", order.ID, cancelErr)
+		}
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetOrder retrieves an order of a user by ID
+func (uc *OrderUseCase) GetOrder(ctx context.Context, userID, id string) (*entity.Order, error) {
+	return uc.userOrder(ctx, userID, id)
+}
+
+// userOrder retrieves an order by ID, reporting other users' orders as not found
+func (uc *OrderUseCase) userOrder(ctx context.Context, userID, id string) (*entity.Order, error) {
+	order, err := uc.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Do not reveal that other users' orders exist
+	if order.UserID != userID {
+		return nil, entity.ErrOrderNotFound
+	}
+
+	return order, nil
+}
+
+// UpdateOrderStatus updates the status of an order
+// Orders are paid and held only by PayOrder, which screens them for fraud and charges them,
+// and released from a hold only by a review
+// version is the order version the change was made to, ErrVersionConflict is returned once it moved on
+func (uc *OrderUseCase) UpdateOrderStatus(ctx context.Context, id string, version int, status entity.OrderStatus) (*entity.Order, error) {
+	order, err := uc.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(order.Version, version); err != nil {
+		return nil, err
 	}
 
-	if uc.kafkaProducer != nil {
-		if err := uc.kafkaProducer.PublishOrderCreated(ctx, orderEvent); err != nil {
-			// Log error but don't fail the order creation
-			fmt.Printf("Failed to publish order event: %v\n", err)
+	switch status {
+	case entity.OrderStatusPaid, entity.OrderStatusOnHold:
+		return nil, fmt.Errorf("%w: orders are %s only through payment and fraud review", entity.ErrInvalidOrder, status)
+	case entity.OrderStatusCancelled:
+		// Cancelling gives back what the order was paid with and its stock, as CancelOrder does
+		if err := uc.cancelOrder(ctx, order); err != nil {
+			return nil, err
+		}
+		uc.publish(ctx, entity.OrderEventCancelled, order)
+		return order, nil
+	case entity.OrderStatusShipped:
+		// Orders with backordered items wait until all their stock has arrived
+		if err := order.MarkAsShipped(); err != nil {
+			return nil, err
+		}
+	default:
+		if err := order.UpdateStatus(status); err != nil {
+			return nil, err
 		}
 	}
 
-	// Clear cart
-	cart.Clear()
-	if err := uc.cartRepo.Update(ctx, cart); err != nil {
-		// Log error but don't fail the order creation
-		fmt.Printf("Failed to clear cart: %v\n", err)
+	if err := uc.orderRepo.UpdateStatus(ctx, order); err != nil {
+		return nil, err
+	}
+
+	if status == entity.OrderStatusShipped {
+		uc.publish(ctx, entity.OrderEventShipped, order)
 	}
 
 	return order, nil
 }
 
-// GetOrder retrieves an order by ID
-func (uc *OrderUseCase) GetOrder(ctx context.Context, id string) (*entity.Order, error) {
-	return uc.orderRepo.GetByID(ctx, id)
+// ReviewOrderRequest represents an admin decision on an order held by fraud screening
+type ReviewOrderRequest struct {
+	Note *string `json:"note,omitempty"`
 }
 
-// GetUserOrders retrieves all orders for a user
-func (uc *OrderUseCase) GetUserOrders(ctx context.Context, userID string) ([]*entity.Order, error) {
-	return uc.orderRepo.GetByUserID(ctx, userID)
+// PayOrder screens an order for fraud, then charges what gift cards and store credit did not cover
+// and marks the order as paid. Suspicious orders are put on hold for review instead of being charged,
+// and orders the screening rejects are cancelled. Other users' orders are reported as not found.
+func (uc *OrderUseCase) PayOrder(ctx context.Context, userID, id string) (*entity.Order, error) {
+	order, err := uc.userOrder(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !order.CanBePaid() {
+		return nil, entity.ErrInvalidOrder
+	}
+
+	screening, err := uc.fraud.Screen(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	switch screening.Outcome {
+	case entity.FraudOutcomeReject:
+		if _, err := uc.CancelOrder(ctx, order.UserID, order.ID); err != nil {
+			fmt.Printf("Failed to cancel rejected order %s: %v\n", order.ID, err)
+		}
+		return nil, entity.ErrOrderRejected
+
+	case entity.FraudOutcomeReview:
+		if err := order.Hold(); err != nil {
+			return nil, err
+		}
+		if err := uc.orderRepo.Update(ctx, order); err != nil {
+			return nil, err
+		}
+		return order, nil
+	}
+
+	return uc.completePayment(ctx, order)
 }
 
-// UpdateOrderStatus updates the status of an order
-func (uc *OrderUseCase) UpdateOrderStatus(ctx context.Context, id string, status entity.OrderStatus) (*entity.Order, error) {
+// ApproveOrder releases an order held by fraud screening and completes its payment
+func (uc *OrderUseCase) ApproveOrder(ctx context.Context, id, reviewerID string, req *ReviewOrderRequest) (*entity.Order, error) {
 	order, err := uc.orderRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	order.UpdateStatus(status)
+	if err := order.ReleaseHold(); err != nil {
+		return nil, err
+	}
 
-	if err := uc.orderRepo.Update(ctx, order); err != nil {
+	order, err = uc.completePayment(ctx, order)
+	if err != nil {
 		return nil, err
 	}
 
+	if err := uc.fraud.review(ctx, order.ID, reviewerID, entity.FraudReviewApproved, req.Note); err != nil {
+		// Log error but don't fail the approval
+		fmt.Printf("Failed to record review of order %s: %v\n", order.ID, err)
+	}
+
 	return order, nil
 }
 
-// PayOrder marks an order as paid
-func (uc *OrderUseCase) PayOrder(ctx context.Context, id string) (*entity.Order, error) {
+// RejectOrder cancels an order held by fraud screening
+func (uc *OrderUseCase) RejectOrder(ctx context.Context, id, reviewerID string, req *ReviewOrderRequest) (*entity.Order, error) {
 	order, err := uc.orderRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if order.Status != entity.OrderStatusOnHold {
+		return nil, entity.ErrInvalidOrder
+	}
+
+	order, err = uc.CancelOrder(ctx, order.UserID, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.fraud.review(ctx, order.ID, reviewerID, entity.FraudReviewRejected, req.Note); err != nil {
+		// Log error but don't fail the rejection
+		fmt.Printf("Failed to record review of order %s: %v\n", order.ID, err)
+	}
+
+	return order, nil
+}
+
+// completePayment charges what gift cards and store credit did not cover and marks the order as paid
+func (uc *OrderUseCase) completePayment(ctx context.Context, order *entity.Order) (*entity.Order, error) {
+	if due := order.AmountDue(); due.IsPositive() && uc.payments != nil {
+		result, err := uc.payments.Charge(ctx, &ChargeRequest{
+			OrderID:        order.ID,
+			UserID:         order.UserID,
+			Amount:         due,
+			IdempotencyKey: "order-" + order.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to charge order: %w", err)
+		}
+		order.PaymentReference = &result.Reference
+	}
+
 	if err := order.MarkAsPaid(); err != nil {
 		return nil, err
 	}
@@ -179,23 +495,185 @@ func (uc *OrderUseCase) PayOrder(ctx context.Context, id string) (*entity.Order,
 		return nil, err
 	}
 
+	uc.issueInvoice(ctx, order)
+	uc.earnPoints(ctx, order)
+	uc.publish(ctx, entity.OrderEventPaid, order)
+
+	return order, nil
+}
+
+// CancelOrder cancels an order of a user and lets the customer know
+// Other users' orders are reported as not found
+func (uc *OrderUseCase) CancelOrder(ctx context.Context, userID, id string) (*entity.Order, error) {
+	order, err := uc.userOrder(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.cancelOrder(ctx, order); err != nil {
+		return nil, err
+	}
+
+	uc.publish(ctx, entity.OrderEventCancelled, order)
+
 	return order, nil
 }
 
-// CancelOrder cancels an order
-func (uc *OrderUseCase) CancelOrder(ctx context.Context, id string) (*entity.Order, error) {
+// cancel cancels an order, giving back what it was paid with and releasing its stock
+// It raises no event, so it also rolls back checkouts the customer never saw succeed
+func (uc *OrderUseCase) cancel(ctx context.Context, id string) (*entity.Order, error) {
 	order, err := uc.orderRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := uc.cancelOrder(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// cancelOrder cancels a loaded order, failing with ErrVersionConflict if it changed since it was read
+// A paid order has the part charged through the payment gateway refunded
+func (uc *OrderUseCase) cancelOrder(ctx context.Context, order *entity.Order) error {
+	charged := order.Status == entity.OrderStatusPaid && order.PaymentReference != nil
+
 	if err := order.Cancel(); err != nil {
+		return err
+	}
+
+	// Refund the charge first, a retry after a failed cancellation refunds it only once
+	if charged {
+		if err := uc.refundCharge(ctx, order); err != nil {
+			return err
+		}
+	}
+
+	// Give back the gift cards and store credit the order was paid with
+	if err := uc.credits.reverse(ctx, order); err != nil {
+		return err
+	}
+
+	// Take back the points the order earned and give back the points it spent
+	if err := uc.loyalty.reverse(ctx, order); err != nil {
+		return err
+	}
+
+	if err := uc.orderRepo.Update(ctx, order); err != nil {
+		return err
+	}
+
+	// Return reserved stock, which may fill other customers' backorders
+	for _, item := range order.Items {
+		if _, err := uc.productRepo.ReleaseStock(ctx, item.VariantID, item.AllocatedQuantity(), item.BackorderedQuantity); err != nil {
+			// Log error but don't fail the cancellation
+			fmt.Printf("Failed to release stock of product %s: %v\n", item.ProductID, err)
+		}
+	}
+
+	return nil
+}
+
+// refundCharge gives back what the payment gateway collected for an order
+func (uc *OrderUseCase) refundCharge(ctx context.Context, order *entity.Order) error {
+	amount := order.AmountDue()
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	if uc.payments == nil {
+		return fmt.Errorf("failed to refund order %s: no payment gateway", order.ID)
+	}
+
+	err := uc.payments.Refund(ctx, &ChargeRefundRequest{
+		OrderID:        order.ID,
+		Reference:      *order.PaymentReference,
+		Amount:         amount,
+		IdempotencyKey: "order-refund-" + order.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	return nil
+}
+
+// RefundOrder refunds a paid order, in full or in part, to the customer's store credit
+func (uc *OrderUseCase) RefundOrder(ctx context.Context, id string, req *RefundRequest) (*entity.Order, error) {
+	order, err := uc.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := order.RefundableAmount()
+	if req.Amount != nil {
+		if !req.Amount.SameCurrency(order.Total) {
+			return nil, entity.ErrCurrencyMismatch
+		}
+		amount = *req.Amount
+	}
+
+	refundedTotal, status := order.RefundedTotal, order.Status
+
+	if err := order.Refund(amount); err != nil {
 		return nil, err
 	}
 
+	// Record the refund on the order first so a retry cannot credit it twice
 	if err := uc.orderRepo.Update(ctx, order); err != nil {
 		return nil, err
 	}
 
+	if err := uc.credits.refund(ctx, order, amount, req.Note); err != nil {
+		order.RefundedTotal, order.Status = refundedTotal, status
+		if restoreErr := uc.orderRepo.Update(ctx, order); restoreErr != nil {
+			fmt.Printf("Failed to restore order %s after refund failure: %v\n", order.ID, restoreErr)
+		}
+		return nil, err
+	}
+
+	if err := uc.loyalty.refund(ctx, order); err != nil {
+		// Log error but don't fail the refund
+		fmt.Printf("Failed to reverse loyalty points of order %s: %v\n", order.ID, err)
+	}
+
+	event := entity.NewOrderEvent(entity.OrderEventRefunded, order, time.Now())
+	event.Amount = &amount
+	uc.raise(ctx, event)
+
 	return order, nil
 }
+
+// issueInvoice issues the invoice of an order that has just been paid
+// A failure does not undo the payment, the invoice is issued again when it is first requested
+func (uc *OrderUseCase) issueInvoice(ctx context.Context, order *entity.Order) {
+	if uc.invoices == nil {
+		return
+	}
+
+	if _, err := uc.invoices.IssueInvoice(ctx, order); err != nil {
+		// Log error but don't fail the payment
+		fmt.Printf("Failed to issue invoice for order %s: %v\n", order.ID, err)
+	}
+}
+
+// earnPoints credits the loyalty points of an order that has just been paid
+func (uc *OrderUseCase) earnPoints(ctx context.Context, order *entity.Order) {
+	if err := uc.loyalty.earn(ctx, order); err != nil {
+		// Log error but don't fail the payment
+		fmt.Printf("Failed to credit loyalty points for order %s: %v\n", order.ID, err)
+	}
+}
+
+// publish raises an event about an order
+func (uc *OrderUseCase) publish(ctx context.Context, eventType entity.OrderEventType, order *entity.Order) {
+	uc.raise(ctx, entity.NewOrderEvent(eventType, order, time.Now()))
+}
+
+// raise hands an event to the publisher, if there is one
+func (uc *OrderUseCase) raise(ctx context.Context, event *entity.OrderEvent) {
+	if uc.events != nil {
+		uc.events.Publish(ctx, event)
+	}
+}
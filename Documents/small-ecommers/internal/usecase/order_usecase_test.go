@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// placeLargeOrder places and pays an order for two 1kg bags through the fixture's order usecase
+func placeLargeOrder(t *testing.T, f *schedulerFixture) *entity.Order {
+	t.Helper()
+	ctx := context.Background()
+
+	order, err := f.scheduler.orders.CreateOrderFromItems(ctx, "user-1", "USD", &CreateOrderRequest{
+		Items: []CreateOrderItemRequest{{ProductID: "coffee", VariantID: "coffee-1kg", Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if order, err = f.scheduler.orders.PayOrder(ctx, "user-1", order.ID); err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func TestCancelPaidOrderRefundsCharge(t *testing.T) {
+	f := newSchedulerFixture(time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC))
+	order := placeLargeOrder(t, f)
+
+	cancelled, err := f.scheduler.orders.CancelOrder(context.Background(), "user-1", order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cancelled.Status != entity.OrderStatusCancelled {
+		t.Errorf("status %s, want cancelled", cancelled.Status)
+	}
+	if len(f.gateway.refunds) != 1 {
+		t.Fatalf("%d refunds, want 1", len(f.gateway.refunds))
+	}
+	refund := f.gateway.refunds[0]
+	if refund.Amount != entity.NewMoney(6000, "USD") || refund.Reference != *order.PaymentReference {
+		t.Errorf("refunded %s of charge %s, want 60.00 USD of %s", refund.Amount, refund.Reference, *order.PaymentReference)
+	}
+	if f.large.Stock != 10 {
+		t.Errorf("1kg stock %d, want 10", f.large.Stock)
+	}
+}
+
+func TestCancelUnpaidOrderRefundsNothing(t *testing.T) {
+	f := newSchedulerFixture(time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC))
+
+	order, err := f.scheduler.orders.CreateOrderFromItems(context.Background(), "user-1", "USD", &CreateOrderRequest{
+		Items: []CreateOrderItemRequest{{ProductID: "coffee", Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.scheduler.orders.CancelOrder(context.Background(), "user-1", order.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.gateway.refunds) != 0 {
+		t.Errorf("%d refunds for an order that was never charged", len(f.gateway.refunds))
+	}
+}
+
+func TestOrdersOfOtherUsersAreNotFound(t *testing.T) {
+	f := newSchedulerFixture(time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+	orders := f.scheduler.orders
+
+	order, err := orders.CreateOrderFromItems(ctx, "user-1", "USD", &CreateOrderRequest{
+		Items: []CreateOrderItemRequest{{ProductID: "coffee", VariantID: "coffee-1kg", Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := orders.GetOrder(ctx, "user-2", order.ID); !errors.Is(err, entity.ErrOrderNotFound) {
+		t.Errorf("get: got %v, want ErrOrderNotFound", err)
+	}
+	if _, err := orders.PayOrder(ctx, "user-2", order.ID); !errors.Is(err, entity.ErrOrderNotFound) {
+		t.Errorf("pay: got %v, want ErrOrderNotFound", err)
+	}
+	if _, err := orders.CancelOrder(ctx, "user-2", order.ID); !errors.Is(err, entity.ErrOrderNotFound) {
+		t.Errorf("cancel: got %v, want ErrOrderNotFound", err)
+	}
+
+	if order.Status != entity.OrderStatusPending || len(f.gateway.charges) != 0 || f.large.Stock != 9 {
+		t.Errorf("order %s, %d charges, stock %d after other users' attempts", order.Status, len(f.gateway.charges), f.large.Stock)
+	}
+}
@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"context"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// PaymentGateway defines the interface for charging customers
+type PaymentGateway interface {
+	// Charge collects the amount from the customer
+	// A declined payment returns an error wrapping entity.ErrPaymentDeclined
+	Charge(ctx context.Context, req *ChargeRequest) (*ChargeResult, error)
+
+	// Refund gives back the amount of an earlier charge to the customer
+	Refund(ctx context.Context, req *ChargeRefundRequest) error
+}
+
+// ChargeRequest represents a request to charge a customer for an order
+type ChargeRequest struct {
+	OrderID string
+	UserID  string
+	Amount  entity.Money
+	// IdempotencyKey makes retried charges for the same order collect only once
+	IdempotencyKey string
+}
+
+// ChargeResult represents a successful charge
+type ChargeResult struct {
+	Reference string
+}
+
+// ChargeRefundRequest represents a request to give back a charge for an order
+type ChargeRefundRequest struct {
+	OrderID string
+	// Reference identifies the charge being refunded
+	Reference string
+	Amount    entity.Money
+	// IdempotencyKey makes retried refunds for the same order pay out only once
+	IdempotencyKey string
+}
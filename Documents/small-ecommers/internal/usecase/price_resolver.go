@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// PriceResolver determines what a product costs in a given currency
+type PriceResolver struct {
+	rateRepo     repository.ExchangeRateRepository
+	baseCurrency string
+}
+
+// NewPriceResolver creates a new PriceResolver
+// Exchange rates are expected to be quoted against baseCurrency
+func NewPriceResolver(rateRepo repository.ExchangeRateRepository, baseCurrency string) *PriceResolver {
+	return &PriceResolver{
+		rateRepo:     rateRepo,
+		baseCurrency: baseCurrency,
+	}
+}
+
+// ResolvedPrice is the price of a product in a requested currency
+type ResolvedPrice struct {
+	Price entity.Money
+	// Rate is the exchange rate used to convert the base price, nil for explicit prices
+	Rate *entity.ExchangeRate
+}
+
+// BaseCurrency returns the currency exchange rates are quoted against
+func (r *PriceResolver) BaseCurrency() string {
+	return r.baseCurrency
+}
+
+// Resolve returns the price of a product variant in the currency, a nil variant meaning the product price
+// An explicit price in the currency wins, otherwise the base price is converted
+func (r *PriceResolver) Resolve(ctx context.Context, product *entity.Product, variant *entity.Variant, currency string) (*ResolvedPrice, error) {
+	if price, ok := product.VariantPriceIn(variant, currency); ok {
+		return &ResolvedPrice{Price: price}, nil
+	}
+
+	base := product.VariantBasePrice(variant)
+
+	rate, err := r.Rate(ctx, base.Currency, currency)
+	if err != nil {
+		if errors.Is(err, entity.ErrExchangeRateNotFound) {
+			return nil, entity.ErrPriceNotAvailable
+		}
+		return nil, err
+	}
+
+	return &ResolvedPrice{
+		Price: entity.ConvertMoney(base, currency, rate.Rat(), entity.RoundHalfUp),
+		Rate:  rate,
+	}, nil
+}
+
+// Rate returns the rate converting from one currency into another
+// Pairs are looked up directly, inverted, or crossed through the base currency
+func (r *PriceResolver) Rate(ctx context.Context, from, to string) (*entity.ExchangeRate, error) {
+	if from == to {
+		return &entity.ExchangeRate{BaseCurrency: from, QuoteCurrency: to, Rate: "1", UpdatedAt: time.Now()}, nil
+	}
+
+	rate, err := r.rateRepo.Get(ctx, from, to)
+	if err == nil {
+		return rate, nil
+	}
+	if !errors.Is(err, entity.ErrExchangeRateNotFound) {
+		return nil, err
+	}
+
+	if inverse, err := r.rateRepo.Get(ctx, to, from); err == nil {
+		return derivedRate(from, to, new(big.Rat).Inv(inverse.Rat()), inverse.UpdatedAt), nil
+	} else if !errors.Is(err, entity.ErrExchangeRateNotFound) {
+		return nil, err
+	}
+
+	if from == r.baseCurrency || to == r.baseCurrency {
+		return nil, entity.ErrExchangeRateNotFound
+	}
+
+	fromBase, err := r.Rate(ctx, r.baseCurrency, from)
+	if err != nil {
+		return nil, err
+	}
+	toBase, err := r.Rate(ctx, r.baseCurrency, to)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAt := fromBase.UpdatedAt
+	if toBase.UpdatedAt.Before(updatedAt) {
+		updatedAt = toBase.UpdatedAt
+	}
+
+	return derivedRate(from, to, new(big.Rat).Quo(toBase.Rat(), fromBase.Rat()), updatedAt), nil
+}
+
+// Snapshot returns a resolver that remembers every rate it looks up
+// Use it for the duration of a checkout so all lines are priced at the same rates
+func (r *PriceResolver) Snapshot() *PriceResolver {
+	return &PriceResolver{
+		rateRepo: &snapshotRateRepository{
+			ExchangeRateRepository: r.rateRepo,
+			rates:                  make(map[string]*entity.ExchangeRate),
+		},
+		baseCurrency: r.baseCurrency,
+	}
+}
+
+// snapshotRateRepository memoises rate lookups of an underlying repository
+type snapshotRateRepository struct {
+	repository.ExchangeRateRepository
+	rates map[string]*entity.ExchangeRate
+}
+
+// Get retrieves the rate for a currency pair, reading it from the store only once
+func (s *snapshotRateRepository) Get(ctx context.Context, baseCurrency, quoteCurrency string) (*entity.ExchangeRate, error) {
+	key := baseCurrency + "/" + quoteCurrency
+	if rate, ok := s.rates[key]; ok {
+		if rate == nil {
+			return nil, entity.ErrExchangeRateNotFound
+		}
+		return rate, nil
+	}
+
+	rate, err := s.ExchangeRateRepository.Get(ctx, baseCurrency, quoteCurrency)
+	if err != nil && !errors.Is(err, entity.ErrExchangeRateNotFound) {
+		return nil, err
+	}
+
+	s.rates[key] = rate
+	return rate, err
+}
+
+// derivedRate builds an exchange rate that was computed rather than stored
+func derivedRate(from, to string, rate *big.Rat, updatedAt time.Time) *entity.ExchangeRate {
+	return &entity.ExchangeRate{
+		BaseCurrency:  from,
+		QuoteCurrency: to,
+		Rate:          rate.FloatString(8),
+		UpdatedAt:     updatedAt,
+	}
+}
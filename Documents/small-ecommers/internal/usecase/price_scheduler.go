@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// PriceScheduler starts and ends scheduled prices when they are due
+type PriceScheduler struct {
+	priceRepo   repository.PriceRepository
+	productRepo repository.ProductRepository
+	clock       Clock
+	batchSize   int
+}
+
+// NewPriceScheduler creates a new PriceScheduler
+// Each run handles at most batchSize scheduled prices
+func NewPriceScheduler(
+	priceRepo repository.PriceRepository,
+	productRepo repository.ProductRepository,
+	clock Clock,
+	batchSize int,
+) *PriceScheduler {
+	return &PriceScheduler{
+		priceRepo:   priceRepo,
+		productRepo: productRepo,
+		clock:       clock,
+		batchSize:   batchSize,
+	}
+}
+
+// Run applies due scheduled prices every interval until the context is cancelled
+func (s *PriceScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if processed, err := s.RunDue(ctx); err != nil {
+			log.Printf("Price scheduler failed: %v", err)
+		} else if processed > 0 {
+			log.Printf("Price scheduler processed %d scheduled prices", processed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue starts and ends all scheduled prices that are due now
+// Returns the number of scheduled prices that were processed
+func (s *PriceScheduler) RunDue(ctx context.Context) (int, error) {
+	processed := 0
+
+	for {
+		now := s.clock.Now()
+
+		due, err := s.priceRepo.ListDue(ctx, now, s.batchSize)
+		if err != nil {
+			return processed, err
+		}
+
+		applied := 0
+		for _, scheduled := range due {
+			ok, err := s.apply(ctx, scheduled, now)
+			if err != nil {
+				return processed, err
+			}
+			if ok {
+				applied++
+			}
+		}
+		processed += applied
+
+		// Stop once the backlog is drained, or when nothing could be applied, the rest is retried next run
+		if len(due) < s.batchSize || applied == 0 {
+			return processed, nil
+		}
+	}
+}
+
+// apply starts or ends a due scheduled price
+// It reports false when the scheduled price or the product price changed meanwhile
+func (s *PriceScheduler) apply(ctx context.Context, scheduled *entity.ScheduledPrice, now time.Time) (bool, error) {
+	product, err := s.productRepo.GetByID(ctx, scheduled.ProductID)
+	if err != nil {
+		return false, err
+	}
+
+	current := product.Price
+	from := scheduled.Status
+	var price *entity.Money
+
+	switch {
+	case from == entity.ScheduledPriceStatusActive:
+		price = scheduled.Finish(current, entity.ScheduledPriceStatusCompleted, now)
+	case scheduled.EndsAt != nil && !scheduled.EndsAt.After(now):
+		// The whole period passed while the scheduler was not running
+		scheduled.Finish(current, entity.ScheduledPriceStatusCompleted, now)
+	case !scheduled.Price.SameCurrency(current):
+		log.Printf("Cancelled scheduled price %s, product %s is now priced in %s", scheduled.ID, product.ID, current.Currency)
+		scheduled.Finish(current, entity.ScheduledPriceStatusCancelled, now)
+	default:
+		scheduled.Start(current, now)
+		price = &scheduled.Price
+	}
+
+	return applyScheduledPrice(ctx, s.priceRepo, scheduled, from, current, price, scheduled.CreatedBy)
+}
@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// PricingLine represents a single order line flowing through the pricing pipeline
+type PricingLine struct {
+	ProductID string
+	VariantID string
+	Quantity  int
+	UnitPrice entity.Money
+	Discount  entity.Money
+}
+
+// Subtotal returns the line amount before discounts
+func (l *PricingLine) Subtotal() (entity.Money, error) {
+	return l.UnitPrice.Mul(l.Quantity)
+}
+
+// PointsRedemption represents loyalty points a customer wants to spend on an order
+type PointsRedemption struct {
+	Points int64
+	// PointValue is what one point is worth in the order currency
+	PointValue entity.Money
+}
+
+// PricingContext carries the intermediate state of a price calculation
+type PricingContext struct {
+	UserID     string
+	Currency   string
+	Lines      []*PricingLine
+	Redemption *PointsRedemption
+	// Rate is the exchange rate locked for the order, from the base currency to Currency, nil for base currency orders
+	Rate      *entity.ExchangeRate
+	Breakdown entity.OrderBreakdown
+	// PointsRedeemed are the points actually spent on Breakdown.PointsDiscount
+	PointsRedeemed int64
+}
+
+// DiscountedSubtotal returns the subtotal after line, order and points discounts
+func (pc *PricingContext) DiscountedSubtotal() entity.Money {
+	return pc.Breakdown.Subtotal.Sub(pc.lineDiscounts()).Sub(pc.Breakdown.OrderDiscount).Sub(pc.Breakdown.PointsDiscount)
+}
+
+// Convert returns an amount configured in the base currency in the order currency, at the locked rate
+// Orders in a currency without a locked rate cannot take it, ErrExchangeRateNotFound is returned
+func (pc *PricingContext) Convert(m entity.Money) (entity.Money, error) {
+	if m.SameCurrency(entity.Zero(pc.Currency)) {
+		return entity.NewMoney(m.Amount, pc.Currency), nil
+	}
+	if pc.Rate == nil || pc.Rate.BaseCurrency != m.Currency || pc.Rate.QuoteCurrency != pc.Currency {
+		return entity.Money{}, fmt.Errorf("%w: %s to %s", entity.ErrExchangeRateNotFound, m.Currency, pc.Currency)
+	}
+	return entity.ConvertMoney(m, pc.Currency, pc.Rate.Rat(), entity.RoundHalfUp), nil
+}
+
+// lineDiscounts returns the discounts applied to lines so far
+func (pc *PricingContext) lineDiscounts() entity.Money {
+	total := entity.Zero(pc.Currency)
+	for _, line := range pc.Lines {
+		total = total.Add(line.Discount)
+	}
+	return total
+}
+
+// PricingStage is a single step of the pricing pipeline
+type PricingStage interface {
+	Apply(ctx context.Context, pc *PricingContext) error
+}
+
+// PricingPipeline runs pricing stages in order to produce an itemised breakdown
+type PricingPipeline struct {
+	stages []PricingStage
+}
+
+// NewPricingPipeline creates a new PricingPipeline
+// Stages are applied in the given order after the subtotal has been computed
+func NewPricingPipeline(stages ...PricingStage) *PricingPipeline {
+	return &PricingPipeline{
+		stages: stages,
+	}
+}
+
+// Price calculates the subtotal, discounts, tax, shipping and grand total for the lines
+// All lines must be priced in the same currency. The redemption may be nil, and so may the rate
+// when the lines are in the base currency.
+func (p *PricingPipeline) Price(ctx context.Context, userID string, lines []*PricingLine, redemption *PointsRedemption, rate *entity.ExchangeRate) (*PricingContext, error) {
+	currency := ""
+	if len(lines) > 0 {
+		currency = lines[0].UnitPrice.Currency
+	}
+
+	pc := &PricingContext{
+		UserID:     userID,
+		Currency:   currency,
+		Lines:      lines,
+		Redemption: redemption,
+		Rate:       rate,
+	}
+
+	zero := entity.Zero(currency)
+	pc.Breakdown = entity.OrderBreakdown{
+		Subtotal:       zero,
+		OrderDiscount:  zero,
+		PointsDiscount: zero,
+		Tax:            zero,
+		Shipping:       zero,
+	}
+
+	for _, line := range lines {
+		if line.UnitPrice.Currency != currency {
+			return nil, entity.ErrCurrencyMismatch
+		}
+		line.Discount = zero
+
+		subtotal, err := line.Subtotal()
+		if err != nil {
+			return nil, err
+		}
+		pc.Breakdown.Subtotal = pc.Breakdown.Subtotal.Add(subtotal)
+	}
+
+	for _, stage := range p.stages {
+		if err := stage.Apply(ctx, pc); err != nil {
+			return nil, err
+		}
+	}
+
+	b := &pc.Breakdown
+	b.LineDiscountTotal = pc.lineDiscounts()
+	b.DiscountTotal = b.LineDiscountTotal.Add(b.OrderDiscount).Add(b.PointsDiscount)
+	b.Total = b.Subtotal.Sub(b.DiscountTotal).Add(b.Tax).Add(b.Shipping)
+
+	return pc, nil
+}
+
+// BulkLineDiscount takes a percentage off any line whose quantity reaches a minimum
+type BulkLineDiscount struct {
+	MinQuantity int
+	Percent     *big.Rat
+}
+
+// Apply applies the bulk discount to qualifying lines
+func (d *BulkLineDiscount) Apply(ctx context.Context, pc *PricingContext) error {
+	for _, line := range pc.Lines {
+		if line.Quantity < d.MinQuantity {
+			continue
+		}
+		subtotal, err := line.Subtotal()
+		if err != nil {
+			return err
+		}
+		line.Discount = line.Discount.Add(subtotal.Percent(d.Percent, entity.RoundHalfUp))
+	}
+	return nil
+}
+
+// OrderThresholdDiscount takes a percentage off orders whose discounted subtotal reaches a minimum
+// The minimum is converted into the order currency at the locked rate
+type OrderThresholdDiscount struct {
+	MinSubtotal entity.Money
+	Percent     *big.Rat
+}
+
+// Apply applies the order-level discount when the threshold is met
+func (d *OrderThresholdDiscount) Apply(ctx context.Context, pc *PricingContext) error {
+	minSubtotal, err := pc.Convert(d.MinSubtotal)
+	if err != nil {
+		return err
+	}
+
+	base := pc.DiscountedSubtotal()
+	if base.Cmp(minSubtotal) < 0 {
+		return nil
+	}
+	pc.Breakdown.OrderDiscount = pc.Breakdown.OrderDiscount.Add(base.Percent(d.Percent, entity.RoundHalfUp))
+	return nil
+}
+
+// PointsDiscount spends the loyalty points of the redemption as a discount
+// No more points are spent than the discounted subtotal is worth, so the discount never makes it negative
+type PointsDiscount struct{}
+
+// Apply converts the redeemed points into the points discount
+func (d *PointsDiscount) Apply(ctx context.Context, pc *PricingContext) error {
+	redemption := pc.Redemption
+	if redemption == nil || redemption.Points <= 0 || !redemption.PointValue.IsPositive() {
+		return nil
+	}
+
+	base := pc.DiscountedSubtotal()
+	if !base.SameCurrency(redemption.PointValue) || !base.IsPositive() {
+		return nil
+	}
+
+	points := min(redemption.Points, base.Amount/redemption.PointValue.Amount)
+
+	discount, err := redemption.PointValue.Mul(int(points))
+	if err != nil {
+		return err
+	}
+
+	pc.PointsRedeemed = points
+	pc.Breakdown.PointsDiscount = discount
+	return nil
+}
+
+// FlatTax charges tax at a fixed rate on the discounted subtotal
+type FlatTax struct {
+	Rate     *big.Rat
+	Rounding entity.RoundingMode
+}
+
+// Apply computes the tax amount
+func (t *FlatTax) Apply(ctx context.Context, pc *PricingContext) error {
+	base := pc.DiscountedSubtotal()
+	if t.Rate == nil || !base.IsPositive() {
+		return nil
+	}
+	pc.Breakdown.Tax = base.Percent(t.Rate, t.Rounding)
+	return nil
+}
+
+// FlatShipping charges a fixed shipping fee, waived above a threshold
+// Both are converted into the order currency at the locked rate
+type FlatShipping struct {
+	Fee           entity.Money
+	FreeThreshold entity.Money
+}
+
+// Apply computes the shipping amount
+func (s *FlatShipping) Apply(ctx context.Context, pc *PricingContext) error {
+	fee, err := pc.Convert(s.Fee)
+	if err != nil {
+		return err
+	}
+	freeThreshold, err := pc.Convert(s.FreeThreshold)
+	if err != nil {
+		return err
+	}
+
+	base := pc.DiscountedSubtotal()
+	if freeThreshold.IsPositive() && base.Cmp(freeThreshold) >= 0 {
+		pc.Breakdown.Shipping = entity.Zero(pc.Currency)
+		return nil
+	}
+	pc.Breakdown.Shipping = fee
+	return nil
+}
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// validateAttributes checks the attributes of a product against the schema of its categories
+func (uc *ProductUseCase) validateAttributes(ctx context.Context, product *entity.Product) error {
+	if len(product.Attributes) == 0 && len(product.CategoryIDs) == 0 {
+		return nil
+	}
+
+	categories, err := uc.categoryRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	schema, err := attributeSchema(categories, product.CategoryIDs)
+	if err != nil {
+		return err
+	}
+
+	return schema.Validate(product.Attributes)
+}
+
+// attributeSchema merges the attribute schemas of the given categories and of the categories above them
+func attributeSchema(categories []*entity.Category, categoryIDs []string) (entity.AttributeSchema, error) {
+	byID := make(map[string]*entity.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	var schemas []entity.AttributeSchema
+	seen := make(map[string]bool)
+
+	for _, id := range categoryIDs {
+		// Walk up to the top level, a category shared by several paths is only taken once
+		for category := byID[id]; category != nil && !seen[category.ID]; {
+			seen[category.ID] = true
+			schemas = append(schemas, category.Attributes)
+
+			if category.ParentID == nil {
+				break
+			}
+			category = byID[*category.ParentID]
+		}
+	}
+
+	return entity.MergeAttributeSchemas(schemas...)
+}
+
+// attributeFilter parses the attribute filters of a listing, which map attribute keys to values
+// Every category defines an attribute key with the same type, which decides how its value is read
+func (uc *ProductUseCase) attributeFilter(ctx context.Context, filters map[string]string) (entity.ProductAttributes, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	categories, err := uc.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := make(entity.ProductAttributes, len(filters))
+
+	for key, text := range filters {
+		var definition *entity.AttributeDefinition
+		for _, category := range categories {
+			if found, ok := category.Attributes.Definition(key); ok {
+				definition = &found
+				// Enum values differ between categories, any of them may be asked for
+				if found.Type == entity.AttributeTypeEnum {
+					definition.Type = entity.AttributeTypeText
+				}
+				break
+			}
+		}
+
+		if definition == nil {
+			return nil, fmt.Errorf("%w: unknown attribute %q", entity.ErrInvalidProductQuery, key)
+		}
+
+		value, err := definition.ParseValue(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", entity.ErrInvalidProductQuery, err)
+		}
+		attributes[key] = value
+	}
+
+	return attributes, nil
+}
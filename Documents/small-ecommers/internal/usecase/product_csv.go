@@ -0,0 +1,869 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// importChunkSize is how many products are written per transaction when an import is not atomic
+const importChunkSize = 200
+
+// importLookupSize is how many products are read from the database at a time during an import
+const importLookupSize = 1000
+
+// productCSVHeader are the columns of a product CSV, one row per variant
+// The product columns are read from the row of the default variant, whose sku equals product_sku,
+// and repeated on the other rows by an export
+var productCSVHeader = []string{
+	"product_sku", "sku", "name", "description", "status", "price", "currency", "prices", "categories",
+	"attributes", "options", "stock_policy", "backorder_limit", "available_at", "variant_options", "variant_price", "stock",
+}
+
+// ProductCSVUseCase defines the business logic for importing and exporting products as CSV
+type ProductCSVUseCase struct {
+	productRepo     repository.ProductRepository
+	categoryRepo    repository.CategoryRepository
+	defaultCurrency string
+}
+
+// NewProductCSVUseCase creates a new ProductCSVUseCase
+// Imported prices without a currency are assumed to be in defaultCurrency
+func NewProductCSVUseCase(
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	defaultCurrency string,
+) *ProductCSVUseCase {
+	return &ProductCSVUseCase{
+		productRepo:     productRepo,
+		categoryRepo:    categoryRepo,
+		defaultCurrency: defaultCurrency,
+	}
+}
+
+// ProductImportRequest represents a CSV file of products to import
+type ProductImportRequest struct {
+	Data io.Reader
+	// Atomic imports nothing unless every row is valid, otherwise valid products are imported in chunks
+	Atomic bool
+	// DryRun validates the file and reports what would be imported without writing anything
+	DryRun bool
+	// ActorID is recorded in the price history of products whose price changes, empty for system imports
+	ActorID string
+}
+
+// ProductImportError is a problem with a row of an import
+type ProductImportError struct {
+	Line   int    `json:"line"`
+	SKU    string `json:"sku,omitempty"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+// ProductImportReport is the outcome of an import
+// A product is imported with all of its rows or not at all, Skipped counts the rows left out
+type ProductImportReport struct {
+	Rows     int                  `json:"rows"`
+	Imported int                  `json:"imported"`
+	Skipped  int                  `json:"skipped"`
+	Created  int                  `json:"created"`
+	Updated  int                  `json:"updated"`
+	DryRun   bool                 `json:"dry_run"`
+	Errors   []ProductImportError `json:"errors"`
+}
+
+// importRow is a data row of an import, by column name
+// Columns missing from the file are absent, so the values they hold are kept
+type importRow struct {
+	line   int
+	values map[string]string
+}
+
+// get returns the value of a column and whether the file has that column
+func (r *importRow) get(column string) (string, bool) {
+	value, ok := r.values[column]
+	return value, ok
+}
+
+// importGroup is the rows of one product and what they are imported as
+type importGroup struct {
+	productSKU string
+	rows       []*importRow
+	imported   *repository.ProductImport
+	errors     []ProductImportError
+}
+
+// fail records a problem with a row of the group
+func (g *importGroup) fail(row *importRow, column string, err error) {
+	g.errors = append(g.errors, ProductImportError{
+		Line:   row.line,
+		SKU:    row.values["sku"],
+		Column: column,
+		Error:  err.Error(),
+	})
+}
+
+// ImportProducts creates and updates products from a CSV file, matching them by SKU
+// Only the product_sku and sku columns are required. Columns left out of the file keep
+// their current values, or defaults for new products, while empty cells clear optional values.
+// Variants not in the file are left untouched. Rows are validated before anything is written
+// and every problem found is reported with its line.
+func (uc *ProductCSVUseCase) ImportProducts(ctx context.Context, req *ProductImportRequest) (*ProductImportReport, error) {
+	groups, rows, err := readProductCSV(req.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ProductImportReport{Rows: rows, DryRun: req.DryRun, Errors: []ProductImportError{}}
+
+	if err := uc.prepareImport(ctx, groups, req.ActorID); err != nil {
+		return nil, err
+	}
+
+	var valid []*importGroup
+	for _, group := range groups {
+		if len(group.errors) > 0 {
+			report.Errors = append(report.Errors, group.errors...)
+			report.Skipped += len(group.rows)
+			continue
+		}
+		valid = append(valid, group)
+	}
+	sortImportErrors(report.Errors)
+
+	if req.Atomic && len(report.Errors) > 0 {
+		report.Skipped = rows
+		return report, nil
+	}
+
+	if req.DryRun {
+		for _, group := range valid {
+			report.count(group)
+		}
+		return report, nil
+	}
+
+	chunkSize := importChunkSize
+	if req.Atomic {
+		chunkSize = max(len(valid), 1)
+	}
+
+	for chunk := range slices.Chunk(valid, chunkSize) {
+		imports := make([]*repository.ProductImport, len(chunk))
+		for i, group := range chunk {
+			imports[i] = group.imported
+		}
+
+		err := uc.productRepo.Import(ctx, imports)
+		if err == nil {
+			for _, group := range chunk {
+				report.count(group)
+			}
+			continue
+		}
+
+		if req.Atomic || ctx.Err() != nil {
+			return nil, err
+		}
+
+		// A chunk fails as a whole, the products in it are reported and the import goes on
+		if !errors.Is(err, entity.ErrSKUExists) {
+			log.Printf("Failed to import products: %v", err)
+			err = errors.New("failed to save product")
+		}
+		for _, group := range chunk {
+			group.fail(group.rows[0], "", err)
+			report.Errors = append(report.Errors, group.errors...)
+			report.Skipped += len(group.rows)
+		}
+	}
+
+	sortImportErrors(report.Errors)
+
+	return report, nil
+}
+
+// sortImportErrors orders import errors by line, the rows of a product may be spread over the file
+func sortImportErrors(errs []ProductImportError) {
+	slices.SortStableFunc(errs, func(a, b ProductImportError) int { return a.Line - b.Line })
+}
+
+// count adds an imported product to the report
+func (r *ProductImportReport) count(group *importGroup) {
+	r.Imported += len(group.rows)
+	if group.imported.New {
+		r.Created++
+	} else {
+		r.Updated++
+	}
+}
+
+// readProductCSV reads the rows of a product CSV and groups them by product_sku, in file order
+// It also returns the number of data rows. Only an unreadable file or header is an error,
+// problems with single rows are recorded in their group.
+func readProductCSV(data io.Reader) ([]*importGroup, int, error) {
+	reader := csv.NewReader(data)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, 0, fmt.Errorf("%w: the file is empty", entity.ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", entity.ErrInvalidImport, err)
+	}
+
+	columns := make([]string, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(productCSVHeader, name) {
+			return nil, 0, fmt.Errorf("%w: unknown column %q", entity.ErrInvalidImport, name)
+		}
+		if slices.Contains(columns, name) {
+			return nil, 0, fmt.Errorf("%w: duplicate column %q", entity.ErrInvalidImport, name)
+		}
+		columns[i] = name
+	}
+	if !slices.Contains(columns, "product_sku") || !slices.Contains(columns, "sku") {
+		return nil, 0, fmt.Errorf("%w: the product_sku and sku columns are required", entity.ErrInvalidImport)
+	}
+
+	var groups []*importGroup
+	byProductSKU := make(map[string]*importGroup)
+	skuLines := make(map[string]int)
+	rows := 0
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", entity.ErrInvalidImport, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows++
+
+		row := &importRow{line: line, values: make(map[string]string, len(columns))}
+		for i, column := range columns {
+			if i < len(record) {
+				row.values[column] = strings.TrimSpace(record[i])
+			}
+		}
+
+		productSKU := row.values["product_sku"]
+		group, ok := byProductSKU[productSKU]
+		if !ok {
+			group = &importGroup{productSKU: productSKU}
+			byProductSKU[productSKU] = group
+			groups = append(groups, group)
+		}
+		group.rows = append(group.rows, row)
+
+		sku := row.values["sku"]
+		switch {
+		case len(record) != len(columns):
+			group.fail(row, "", fmt.Errorf("expected %d columns, got %d", len(columns), len(record)))
+		case !entity.IsValidSKU(productSKU):
+			group.fail(row, "product_sku", errors.New("SKU must be 1 to 64 characters without spaces"))
+		case !entity.IsValidSKU(sku):
+			group.fail(row, "sku", errors.New("SKU must be 1 to 64 characters without spaces"))
+		case skuLines[sku] != 0:
+			group.fail(row, "sku", fmt.Errorf("SKU already appears on line %d", skuLines[sku]))
+		default:
+			skuLines[sku] = line
+		}
+	}
+
+	return groups, rows, nil
+}
+
+// prepareImport looks up the products and categories the rows refer to and builds
+// what each valid group is imported as
+func (uc *ProductCSVUseCase) prepareImport(ctx context.Context, groups []*importGroup, actorID string) error {
+	var skus []string
+	for _, group := range groups {
+		skus = append(skus, group.productSKU)
+		for _, row := range group.rows {
+			skus = append(skus, row.values["sku"])
+		}
+	}
+
+	variants, err := uc.productRepo.GetVariantsBySKUs(ctx, slices.Compact(slices.Sorted(slices.Values(skus))))
+	if err != nil {
+		return err
+	}
+
+	bySKU := make(map[string]*entity.Variant, len(variants))
+	var productIDs []string
+	for _, variant := range variants {
+		bySKU[variant.SKU] = variant
+		if variant.IsDefault {
+			productIDs = append(productIDs, variant.ProductID)
+		}
+	}
+
+	products := make(map[string]*entity.Product, len(productIDs))
+	for ids := range slices.Chunk(productIDs, importLookupSize) {
+		found, err := uc.productRepo.GetByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, product := range found {
+			products[product.ID] = product
+		}
+	}
+
+	categories, err := uc.categoryRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	slugs := make(map[string]string, len(categories))
+	for _, category := range categories {
+		slugs[category.Slug] = category.ID
+	}
+
+	for _, group := range groups {
+		if len(group.errors) > 0 {
+			continue
+		}
+		uc.prepareGroup(group, bySKU, products, categories, slugs, actorID)
+	}
+
+	return nil
+}
+
+// prepareGroup builds the product and variants of a group, recording every problem found
+func (uc *ProductCSVUseCase) prepareGroup(
+	group *importGroup,
+	bySKU map[string]*entity.Variant,
+	products map[string]*entity.Product,
+	categories []*entity.Category,
+	slugs map[string]string,
+	actorID string,
+) {
+	first := group.rows[0]
+
+	var defaultRow *importRow
+	for _, row := range group.rows {
+		if row.values["sku"] == group.productSKU {
+			defaultRow = row
+		}
+	}
+
+	imported := &repository.ProductImport{Stock: make(map[string]int)}
+
+	if existing, ok := bySKU[group.productSKU]; ok {
+		if !existing.IsDefault {
+			group.fail(first, "product_sku", fmt.Errorf("%s is the SKU of a variant, not of a product", group.productSKU))
+			return
+		}
+		if imported.Product, ok = products[existing.ProductID]; !ok {
+			group.fail(first, "product_sku", entity.ErrProductNotFound)
+			return
+		}
+	} else {
+		if defaultRow == nil {
+			group.fail(first, "product_sku", errors.New("a new product needs a row with sku equal to product_sku"))
+			return
+		}
+		imported.New = true
+		imported.Product = entity.NewProduct(uuid.New().String(), "", nil, entity.Money{}, 0, group.productSKU)
+	}
+
+	product := imported.Product
+	oldPrice := product.Price
+
+	// Product columns come first, the variants are checked against the resulting options and currency
+	productChanged := false
+	if defaultRow != nil {
+		productChanged = uc.applyProductColumns(group, defaultRow, product, imported.New, slugs)
+	}
+
+	// Attributes are read with the types the product's categories give them
+	if schema, err := attributeSchema(categories, product.CategoryIDs); err != nil {
+		group.fail(first, "categories", err)
+	} else {
+		if defaultRow != nil {
+			if value, ok := defaultRow.get("attributes"); ok {
+				if product.Attributes, err = parseAttributes(value, schema); err != nil {
+					group.fail(defaultRow, "attributes", err)
+				}
+			}
+		}
+		if err := schema.Validate(product.Attributes); err != nil {
+			group.fail(first, "attributes", err)
+		}
+	}
+
+	imported.Variants = make([]*entity.Variant, len(group.rows))
+	for i, row := range group.rows {
+		sku := row.values["sku"]
+
+		var variant *entity.Variant
+		if existing, ok := bySKU[sku]; ok {
+			if existing.ProductID != product.ID {
+				group.fail(row, "sku", fmt.Errorf("%w: %s belongs to another product", entity.ErrSKUExists, sku))
+				continue
+			}
+			variant, _ = product.Variant(existing.ID)
+		} else if sku == group.productSKU {
+			variant = product.DefaultVariant()
+		}
+		if variant == nil {
+			variant = entity.NewVariant(uuid.New().String(), product.ID, sku, nil, nil, 0)
+			product.Variants = append(product.Variants, variant)
+		}
+		imported.Variants[i] = variant
+
+		applyVariantColumns(group, row, product, variant, imported)
+	}
+
+	if len(group.errors) > 0 {
+		return
+	}
+
+	for i, variant := range imported.Variants {
+		if err := product.ValidateVariant(variant); err != nil {
+			group.fail(group.rows[i], "", err)
+		}
+	}
+
+	// Changed options or a changed currency may invalidate the variants left out of the file
+	if productChanged {
+		for _, variant := range product.Variants {
+			if slices.Contains(imported.Variants, variant) {
+				continue
+			}
+			if err := product.ValidateVariant(variant); err != nil {
+				group.fail(defaultRow, "options", fmt.Errorf("variant %s: %w", variant.SKU, err))
+			}
+		}
+	}
+
+	if len(group.errors) > 0 {
+		return
+	}
+
+	if !imported.New && entity.PriceChanged(oldPrice, product.Price) {
+		imported.PriceChange = entity.NewPriceChange(uuid.New().String(), product.ID, oldPrice, product.Price, entity.PriceChangeSourceImport, actorID, time.Now())
+	}
+
+	group.imported = imported
+}
+
+// applyProductColumns sets the product fields from the row of its default variant
+// It reports whether the options or the currency may have changed
+func (uc *ProductCSVUseCase) applyProductColumns(group *importGroup, row *importRow, product *entity.Product, isNew bool, slugs map[string]string) bool {
+	changed := false
+
+	if name, ok := row.get("name"); ok || isNew {
+		if name == "" {
+			group.fail(row, "name", errors.New("name is required"))
+		}
+		product.Name = name
+	}
+
+	if description, ok := row.get("description"); ok {
+		product.Description = nil
+		if description != "" {
+			product.Description = &description
+		}
+	}
+
+	if status, ok := row.get("status"); ok {
+		switch entity.ProductStatus(status) {
+		case "", entity.ProductStatusActive:
+			if product.IsArchived() {
+				product.Restore()
+			}
+		case entity.ProductStatusArchived:
+			product.Archive(time.Now())
+		default:
+			group.fail(row, "status", errors.New("status must be active or archived"))
+		}
+	}
+
+	currency, hasCurrency := row.get("currency")
+	if currency == "" {
+		currency = product.Price.Currency
+		if isNew || currency == "" {
+			currency = uc.defaultCurrency
+		}
+	}
+	currency = strings.ToUpper(currency)
+
+	price, hasPrice := row.get("price")
+	switch {
+	case !hasPrice && !isNew && hasCurrency && currency != product.Price.Currency:
+		group.fail(row, "currency", errors.New("a new currency needs a price"))
+	case !hasPrice && !isNew:
+	case price == "":
+		group.fail(row, "price", errors.New("price is required"))
+	case !entity.IsCurrencyCode(currency):
+		group.fail(row, "currency", entity.ErrInvalidCurrency)
+	default:
+		amount, err := entity.ParseMoney(price, currency)
+		if err == nil && !amount.IsPositive() {
+			err = entity.ErrInvalidAmount
+		}
+		if err != nil {
+			group.fail(row, "price", err)
+		} else {
+			changed = !amount.SameCurrency(product.Price)
+			product.Price = amount
+		}
+	}
+
+	if value, ok := row.get("prices"); ok {
+		prices, err := parsePriceList(value)
+		if err == nil {
+			prices, err = validatePrices(product.Price, prices)
+		}
+		if err != nil {
+			group.fail(row, "prices", err)
+		} else {
+			product.Prices = prices
+		}
+	} else if _, err := validatePrices(product.Price, product.Prices); err != nil {
+		group.fail(row, "prices", err)
+	}
+
+	if value, ok := row.get("categories"); ok {
+		var ids []string
+		for _, slug := range splitList(value, ";") {
+			id, ok := slugs[slug]
+			if !ok {
+				group.fail(row, "categories", fmt.Errorf("%w: unknown category %q", entity.ErrInvalidCategory, slug))
+				continue
+			}
+			ids = append(ids, id)
+		}
+		product.CategoryIDs = slices.Compact(slices.Sorted(slices.Values(ids)))
+	}
+
+	if value, ok := row.get("options"); ok {
+		options, err := parseOptions(value)
+		if err == nil {
+			err = entity.ValidateOptions(options)
+		}
+		if err != nil {
+			group.fail(row, "options", err)
+		} else {
+			product.Options = options
+			changed = true
+		}
+	}
+
+	if value, ok := row.get("stock_policy"); ok {
+		product.StockPolicy = entity.StockPolicy(value)
+		if value == "" {
+			product.StockPolicy = entity.StockPolicyDeny
+		}
+	}
+
+	if value, ok := row.get("backorder_limit"); ok {
+		product.BackorderLimit = nil
+		if value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil {
+				group.fail(row, "backorder_limit", entity.ErrInvalidStockPolicy)
+			}
+			product.BackorderLimit = &limit
+		}
+	}
+
+	if err := validateStockPolicy(product); err != nil {
+		group.fail(row, "stock_policy", err)
+	}
+
+	if value, ok := row.get("available_at"); ok {
+		product.AvailableAt = nil
+		if value != "" {
+			availableAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				group.fail(row, "available_at", errors.New("expected an RFC 3339 time"))
+			}
+			product.AvailableAt = &availableAt
+		}
+	}
+
+	return changed
+}
+
+// applyVariantColumns sets the variant fields and the stock from a row
+func applyVariantColumns(group *importGroup, row *importRow, product *entity.Product, variant *entity.Variant, imported *repository.ProductImport) {
+	if value, ok := row.get("variant_options"); ok {
+		options, err := parseOptionValues(value)
+		if err != nil {
+			group.fail(row, "variant_options", err)
+		}
+		variant.Options = options
+	}
+
+	if value, ok := row.get("variant_price"); ok {
+		variant.Price = nil
+		if value != "" {
+			price, err := entity.ParseMoney(value, product.Price.Currency)
+			if err != nil {
+				group.fail(row, "variant_price", err)
+			}
+			variant.Price = &price
+		}
+	}
+
+	if value, ok := row.get("stock"); ok && value != "" {
+		stock, err := strconv.Atoi(value)
+		if err != nil || stock < 0 {
+			group.fail(row, "stock", errors.New("stock must be a whole number of at least zero"))
+		}
+		imported.Stock[variant.ID] = stock
+	}
+}
+
+// ExportProducts returns the function writing the products in the format read by ImportProducts
+// An empty status exports every product. Products are written oldest first and read a batch at a time.
+func (uc *ProductCSVUseCase) ExportProducts(ctx context.Context, status entity.ProductStatus) (func(w io.Writer) error, error) {
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("%w: status must be active or archived", entity.ErrInvalidProductQuery)
+	}
+
+	return func(w io.Writer) error {
+		categories, err := uc.categoryRepo.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		slugs := make(map[string]string, len(categories))
+		for _, category := range categories {
+			slugs[category.ID] = category.Slug
+		}
+
+		out := csv.NewWriter(w)
+		if err := out.Write(productCSVHeader); err != nil {
+			return err
+		}
+
+		search := &repository.ProductSearch{
+			Filter: repository.ProductFilter{Status: status},
+			Sort:   repository.ProductSortCreatedAt,
+			Limit:  exportBatchSize,
+		}
+
+		for {
+			products, err := uc.productRepo.Search(ctx, search)
+			if err != nil {
+				return err
+			}
+
+			for _, product := range products {
+				for _, row := range productCSVRows(product, slugs) {
+					if err := out.Write(row); err != nil {
+						return fmt.Errorf("failed to write product %s: %w", product.ID, err)
+					}
+				}
+			}
+
+			if len(products) < exportBatchSize {
+				out.Flush()
+				return out.Error()
+			}
+
+			last := products[len(products)-1]
+			search.After = &repository.ProductCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+	}, nil
+}
+
+// productCSVRows returns the rows of a product, one per variant with the default variant first
+func productCSVRows(product *entity.Product, slugs map[string]string) [][]string {
+	defaultVariant := product.DefaultVariant()
+	if defaultVariant == nil {
+		return nil
+	}
+
+	var description, backorderLimit, availableAt string
+	if product.Description != nil {
+		description = *product.Description
+	}
+	if product.BackorderLimit != nil {
+		backorderLimit = strconv.Itoa(*product.BackorderLimit)
+	}
+	if product.AvailableAt != nil {
+		availableAt = product.AvailableAt.UTC().Format(time.RFC3339)
+	}
+
+	prices := make([]string, len(product.Prices))
+	for i, price := range product.Prices {
+		prices[i] = price.Currency + "=" + price.Decimal()
+	}
+
+	var categories []string
+	for _, id := range product.CategoryIDs {
+		if slug, ok := slugs[id]; ok {
+			categories = append(categories, slug)
+		}
+	}
+	slices.Sort(categories)
+
+	attributes := make([]string, 0, len(product.Attributes))
+	for key, value := range product.Attributes {
+		attributes = append(attributes, key+"="+entity.FormatAttributeValue(value))
+	}
+	slices.Sort(attributes)
+
+	options := make([]string, len(product.Options))
+	for i, option := range product.Options {
+		options[i] = option.Name + "=" + strings.Join(option.Values, "|")
+	}
+
+	columns := []string{
+		defaultVariant.SKU,
+		"",
+		product.Name,
+		description,
+		string(product.Status),
+		product.Price.Decimal(),
+		product.Price.Currency,
+		strings.Join(prices, ";"),
+		strings.Join(categories, ";"),
+		strings.Join(attributes, ";"),
+		strings.Join(options, ";"),
+		string(product.StockPolicy),
+		backorderLimit,
+		availableAt,
+	}
+
+	rows := make([][]string, 0, len(product.Variants))
+	for _, variant := range product.Variants {
+		var variantOptions []string
+		for _, option := range product.Options {
+			if value, ok := variant.Options[option.Name]; ok {
+				variantOptions = append(variantOptions, option.Name+"="+value)
+			}
+		}
+
+		var variantPrice string
+		if variant.Price != nil {
+			variantPrice = variant.Price.Decimal()
+		}
+
+		row := slices.Clone(columns)
+		row[1] = variant.SKU
+		row = append(row, strings.Join(variantOptions, ";"), variantPrice, strconv.Itoa(variant.Stock))
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// parsePriceList reads prices written as EUR=9.99;GBP=8.50
+func parsePriceList(value string) ([]entity.Money, error) {
+	var prices []entity.Money
+	for _, part := range splitList(value, ";") {
+		currency, amount, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: expected CURRENCY=amount, got %q", entity.ErrInvalidAmount, part)
+		}
+		price, err := entity.ParseMoney(amount, currency)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
+
+// parseAttributes reads product attributes written as material=cotton;weight=1.5
+func parseAttributes(value string, schema entity.AttributeSchema) (entity.ProductAttributes, error) {
+	parts := splitList(value, ";")
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	attributes := make(entity.ProductAttributes, len(parts))
+	for _, part := range parts {
+		key, text, ok := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w: expected key=value, got %q", entity.ErrInvalidAttribute, part)
+		}
+		if _, ok := attributes[key]; ok {
+			return nil, fmt.Errorf("%w: %q is set twice", entity.ErrInvalidAttribute, key)
+		}
+
+		definition, ok := schema.Definition(key)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not an attribute of the product's categories", entity.ErrInvalidAttribute, key)
+		}
+		parsed, err := definition.ParseValue(strings.TrimSpace(text))
+		if err != nil {
+			return nil, err
+		}
+		attributes[key] = parsed
+	}
+	return attributes, nil
+}
+
+// parseOptions reads product options written as size=S|M|L;color=red|blue
+func parseOptions(value string) (entity.ProductOptions, error) {
+	var options entity.ProductOptions
+	for _, part := range splitList(value, ";") {
+		name, values, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: expected name=value|value, got %q", entity.ErrInvalidVariant, part)
+		}
+		options = append(options, entity.ProductOption{
+			Name:   strings.TrimSpace(name),
+			Values: splitList(values, "|"),
+		})
+	}
+	return options, nil
+}
+
+// parseOptionValues reads the option values of a variant written as size=S;color=red
+func parseOptionValues(value string) (entity.OptionValues, error) {
+	parts := splitList(value, ";")
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	values := make(entity.OptionValues, len(parts))
+	for _, part := range parts {
+		name, optionValue, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%w: expected name=value, got %q", entity.ErrInvalidVariant, part)
+		}
+		if _, ok := values[name]; ok {
+			return nil, fmt.Errorf("%w: option %q is set twice", entity.ErrInvalidVariant, name)
+		}
+		values[name] = strings.TrimSpace(optionValue)
+	}
+	return values, nil
+}
+
+// splitList splits a list cell on sep, dropping blank items
+func splitList(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/pkg/thumbnail"
+)
+
+// ImageSettings configures how product images are stored and served
+type ImageSettings struct {
+	// BaseURL is prepended to storage keys to build the image URLs
+	BaseURL string
+	// ThumbnailSize is the largest width and height of a thumbnail, in pixels
+	ThumbnailSize int
+	// MaxBytes is the largest accepted upload
+	MaxBytes int64
+	// MaxPixels bounds width times height, so a small file cannot decode into a huge image
+	MaxPixels int
+}
+
+// imageFormats are the accepted image formats, by the name image.Decode reports
+var imageFormats = map[string]struct {
+	contentType string
+	extension   string
+}{
+	"jpeg": {"image/jpeg", "jpg"},
+	"png":  {"image/png", "png"},
+	"gif":  {"image/gif", "gif"},
+}
+
+// UploadImageRequest represents an image uploaded for a product
+type UploadImageRequest struct {
+	Data    io.Reader
+	AltText string
+	// Position inserts the image before the image at that position, nil appends it
+	Position *int
+}
+
+// UpdateImageRequest represents the request to update a product image
+type UpdateImageRequest struct {
+	AltText *string `json:"alt_text,omitempty"`
+	// Position moves the image, the images in between shift by one
+	Position *int `json:"position,omitempty"`
+}
+
+// UploadImage stores an image of a product together with its thumbnail
+// JPEG, PNG and GIF images are accepted, a GIF is stored as uploaded with a still PNG thumbnail
+func (uc *ProductUseCase) UploadImage(ctx context.Context, productID string, req *UploadImageRequest) (*entity.ProductImage, error) {
+	if !entity.IsValidAltText(req.AltText) {
+		return nil, fmt.Errorf("%w: alt text must be at most %d characters", entity.ErrInvalidImage, entity.MaxAltTextLength)
+	}
+	if req.Position != nil && *req.Position < 0 {
+		return nil, fmt.Errorf("%w: position cannot be negative", entity.ErrInvalidImage)
+	}
+
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read one byte more than allowed to tell a file at the limit from a larger one
+	data, err := io.ReadAll(io.LimitReader(req.Data, uc.images.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > uc.images.MaxBytes {
+		return nil, fmt.Errorf("%w: images must be at most %d bytes", entity.ErrImageTooLarge, uc.images.MaxBytes)
+	}
+
+	// Check the dimensions before decoding the pixels
+	config, formatName, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a JPEG, PNG or GIF image", entity.ErrInvalidImage)
+	}
+	format, ok := imageFormats[formatName]
+	if !ok {
+		return nil, fmt.Errorf("%w: not a JPEG, PNG or GIF image", entity.ErrInvalidImage)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("%w: image is empty", entity.ErrInvalidImage)
+	}
+	if config.Width > uc.images.MaxPixels/config.Height {
+		return nil, fmt.Errorf("%w: images must be at most %d pixels", entity.ErrImageTooLarge, uc.images.MaxPixels)
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entity.ErrInvalidImage, err)
+	}
+
+	thumb := thumbnail.Fit(decoded, uc.images.ThumbnailSize, uc.images.ThumbnailSize)
+	thumbType, thumbExtension, thumbData, err := encodeThumbnail(thumb, formatName)
+	if err != nil {
+		return nil, err
+	}
+
+	img := entity.NewProductImage(uuid.New().String(), product.ID, req.AltText, format.contentType, config.Width, config.Height, int64(len(data)))
+	img.StorageKey = fmt.Sprintf("products/%s/%s.%s", product.ID, img.ID, format.extension)
+	img.ThumbnailKey = fmt.Sprintf("products/%s/%s_thumb.%s", product.ID, img.ID, thumbExtension)
+	img.URL = uc.imageURL(img.StorageKey)
+	img.ThumbnailURL = uc.imageURL(img.ThumbnailKey)
+	img.ThumbnailWidth = thumb.Rect.Dx()
+	img.ThumbnailHeight = thumb.Rect.Dy()
+	img.Position = len(product.Images)
+
+	if err := uc.media.Put(ctx, img.StorageKey, format.contentType, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if err := uc.media.Put(ctx, img.ThumbnailKey, thumbType, bytes.NewReader(thumbData)); err != nil {
+		uc.deleteImageFiles(ctx, img)
+		return nil, err
+	}
+
+	if err := uc.productRepo.CreateImage(ctx, img); err != nil {
+		uc.deleteImageFiles(ctx, img)
+		return nil, err
+	}
+
+	if req.Position != nil && *req.Position < img.Position {
+		product.Images = append(product.Images, img)
+		if err := uc.moveImage(ctx, product, img, *req.Position); err != nil {
+			return nil, err
+		}
+	}
+
+	return img, nil
+}
+
+// UpdateImage updates the alt text or the position of a product image
+// version is the image version the change was made to, ErrVersionConflict is returned once it moved on
+func (uc *ProductUseCase) UpdateImage(ctx context.Context, productID, imageID string, version int, req *UpdateImageRequest) (*entity.ProductImage, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := product.Image(imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(img.Version, version); err != nil {
+		return nil, err
+	}
+
+	if req.AltText != nil {
+		if !entity.IsValidAltText(*req.AltText) {
+			return nil, fmt.Errorf("%w: alt text must be at most %d characters", entity.ErrInvalidImage, entity.MaxAltTextLength)
+		}
+		img.AltText = *req.AltText
+	}
+
+	// Saved even without a new alt text, so that a move is checked against the version as well
+	if err := uc.productRepo.UpdateImage(ctx, img); err != nil {
+		return nil, err
+	}
+
+	if req.Position != nil {
+		if err := uc.moveImage(ctx, product, img, *req.Position); err != nil {
+			return nil, err
+		}
+	}
+
+	return img, nil
+}
+
+// DeleteImage deletes a product image and its files
+func (uc *ProductUseCase) DeleteImage(ctx context.Context, productID, imageID string) error {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	img, err := product.Image(imageID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.productRepo.DeleteImage(ctx, img.ID); err != nil {
+		return err
+	}
+
+	uc.deleteImageFiles(ctx, img)
+
+	// Close the gap left in the positions
+	remaining := slices.DeleteFunc(product.Images, func(other *entity.ProductImage) bool { return other.ID == img.ID })
+	return uc.productRepo.SetImagePositions(ctx, product.ID, imageIDs(remaining))
+}
+
+// OpenImageFile opens a stored image file by its storage key and returns its content type
+// Only the files of product images are served, other keys are reported as not found
+func (uc *ProductUseCase) OpenImageFile(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	contentType := ""
+	for _, format := range imageFormats {
+		if path.Ext(key) == "."+format.extension {
+			contentType = format.contentType
+		}
+	}
+	if contentType == "" || !strings.HasPrefix(path.Clean("/"+key), "/products/") {
+		return nil, "", entity.ErrBlobNotFound
+	}
+
+	content, err := uc.media.Get(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return content, contentType, nil
+}
+
+// moveImage moves an image of a product to a position and renumbers the images
+// Positions beyond the last image move it to the end
+func (uc *ProductUseCase) moveImage(ctx context.Context, product *entity.Product, img *entity.ProductImage, position int) error {
+	if position < 0 {
+		return fmt.Errorf("%w: position cannot be negative", entity.ErrInvalidImage)
+	}
+
+	images := slices.DeleteFunc(slices.Clone(product.Images), func(other *entity.ProductImage) bool { return other.ID == img.ID })
+	position = min(position, len(images))
+	images = slices.Insert(images, position, img)
+
+	if err := uc.productRepo.SetImagePositions(ctx, product.ID, imageIDs(images)); err != nil {
+		return err
+	}
+
+	for i, other := range images {
+		if other.Position != i {
+			other.Position = i
+			other.Version++
+		}
+	}
+	product.Images = images
+
+	return nil
+}
+
+// deleteImageFiles removes the original and the thumbnail of an image
+// Failures are logged, a leftover file is harmless once nothing refers to it
+func (uc *ProductUseCase) deleteImageFiles(ctx context.Context, img *entity.ProductImage) {
+	for _, key := range []string{img.StorageKey, img.ThumbnailKey} {
+		if err := uc.media.Delete(ctx, key); err != nil {
+			fmt.Printf("Failed to delete image file %s: %v\n", key, err)
+		}
+	}
+}
+
+// imageURL returns the URL an image stored under key is served at
+func (uc *ProductUseCase) imageURL(key string) string {
+	return strings.TrimSuffix(uc.images.BaseURL, "/") + "/" + key
+}
+
+// encodeThumbnail encodes a thumbnail, as JPEG for JPEG originals and as PNG otherwise to keep transparency
+func encodeThumbnail(thumb image.Image, formatName string) (contentType, extension string, data []byte, err error) {
+	var buf bytes.Buffer
+
+	if formatName == "jpeg" {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+		contentType, extension = "image/jpeg", "jpg"
+	} else {
+		err = png.Encode(&buf, thumb)
+		contentType, extension = "image/png", "png"
+	}
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	return contentType, extension, buf.Bytes(), nil
+}
+
+// imageIDs returns the IDs of images in order
+func imageIDs(images []*entity.ProductImage) []string {
+	ids := make([]string, len(images))
+	for i, img := range images {
+		ids[i] = img.ID
+	}
+	return ids
+}
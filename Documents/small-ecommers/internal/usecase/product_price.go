@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// Price history page sizes
+const (
+	defaultPriceHistoryPageSize = 20
+	maxPriceHistoryPageSize     = 100
+)
+
+// SchedulePriceRequest represents the request to schedule a price of a product
+type SchedulePriceRequest struct {
+	// Price is in the product currency, which is assumed when none is given
+	Price    entity.Money `json:"price"`
+	StartsAt time.Time    `json:"starts_at"`
+	// EndsAt ends a temporary price such as a sale, the price it replaced then applies again
+	EndsAt *time.Time `json:"ends_at,omitempty"`
+}
+
+// PriceHistoryRequest represents a page of the price history of a product
+type PriceHistoryRequest struct {
+	// Cursor is the next_cursor of the previous page
+	Cursor string
+	Limit  int
+}
+
+// PriceHistoryPage is a page of the price history of a product, newest change first
+type PriceHistoryPage struct {
+	Changes []*entity.PriceChange `json:"changes"`
+	// NextCursor fetches the next page, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// priceChangeCursor is the content of an encoded price history cursor
+type priceChangeCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"id"`
+}
+
+// GetPriceHistory retrieves a page of the price changes of a product, newest first
+func (uc *ProductUseCase) GetPriceHistory(ctx context.Context, productID string, req *PriceHistoryRequest) (*PriceHistoryPage, error) {
+	limit := req.Limit
+	switch {
+	case limit == 0:
+		limit = defaultPriceHistoryPageSize
+	case limit < 0 || limit > maxPriceHistoryPageSize:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", entity.ErrInvalidProductQuery, maxPriceHistoryPageSize)
+	}
+
+	var after *repository.PriceChangeCursor
+	if req.Cursor != "" {
+		var cursor priceChangeCursor
+		if err := decodeCursor(req.Cursor, &cursor); err != nil {
+			return nil, err
+		}
+		after = &repository.PriceChangeCursor{CreatedAt: cursor.CreatedAt, ID: cursor.ID}
+	}
+
+	if _, err := uc.productRepo.GetByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	// Fetch one change more than the page holds to know whether there is a next page
+	changes, err := uc.priceRepo.ListChanges(ctx, productID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PriceHistoryPage{Changes: changes}
+	if page.Changes == nil {
+		page.Changes = []*entity.PriceChange{}
+	}
+
+	if len(changes) > limit {
+		page.Changes = changes[:limit]
+		last := page.Changes[limit-1]
+		page.NextCursor = encodeCursor(priceChangeCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	return page, nil
+}
+
+// SchedulePrice schedules a future price of a product, applied by the PriceScheduler
+// Scheduled prices of a product may not overlap, one without an end only takes the moment it starts
+func (uc *ProductUseCase) SchedulePrice(ctx context.Context, productID, actorID string, req *SchedulePriceRequest) (*entity.ScheduledPrice, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := req.Price.WithDefaultCurrency(product.Price.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if !price.SameCurrency(product.Price) || !price.IsPositive() {
+		return nil, fmt.Errorf("%w: price must be positive and in %s", entity.ErrInvalidScheduledPrice, product.Price.Currency)
+	}
+
+	if req.StartsAt.IsZero() {
+		return nil, fmt.Errorf("%w: starts_at is required", entity.ErrInvalidScheduledPrice)
+	}
+	if req.EndsAt != nil && (!req.EndsAt.After(req.StartsAt) || !req.EndsAt.After(time.Now())) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at and in the future", entity.ErrInvalidScheduledPrice)
+	}
+
+	scheduled := entity.NewScheduledPrice(uuid.New().String(), product.ID, price, req.StartsAt.UTC(), nil, actorID)
+	if req.EndsAt != nil {
+		endsAt := req.EndsAt.UTC()
+		scheduled.EndsAt = &endsAt
+	}
+
+	existing, err := uc.priceRepo.ListSchedules(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, other := range existing {
+		if other.IsOpen() && scheduled.Overlaps(other) {
+			return nil, fmt.Errorf("%w: overlaps scheduled price %s", entity.ErrInvalidScheduledPrice, other.ID)
+		}
+	}
+
+	if err := uc.priceRepo.CreateSchedule(ctx, scheduled); err != nil {
+		return nil, err
+	}
+
+	return scheduled, nil
+}
+
+// ListScheduledPrices retrieves the scheduled prices of a product, by start
+func (uc *ProductUseCase) ListScheduledPrices(ctx context.Context, productID string) ([]*entity.ScheduledPrice, error) {
+	if _, err := uc.productRepo.GetByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	schedules, err := uc.priceRepo.ListSchedules(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedules == nil {
+		schedules = []*entity.ScheduledPrice{}
+	}
+
+	return schedules, nil
+}
+
+// CancelScheduledPrice cancels a scheduled price that has not ended yet
+// Cancelling a started price ends it at once, restoring the price it replaced
+func (uc *ProductUseCase) CancelScheduledPrice(ctx context.Context, productID, scheduleID, actorID string) (*entity.ScheduledPrice, error) {
+	scheduled, err := uc.priceRepo.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheduled.ProductID != productID {
+		return nil, entity.ErrScheduledPriceNotFound
+	}
+
+	if !scheduled.IsOpen() {
+		return nil, fmt.Errorf("%w: the scheduled price is %s", entity.ErrInvalidScheduledPrice, scheduled.Status)
+	}
+
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := scheduled.Status
+	restore := scheduled.Finish(product.Price, entity.ScheduledPriceStatusCancelled, time.Now())
+
+	applied, err := applyScheduledPrice(ctx, uc.priceRepo, scheduled, from, product.Price, restore, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !applied {
+		return nil, entity.ErrScheduledPriceChanged
+	}
+
+	return scheduled, nil
+}
+
+// applyScheduledPrice saves a scheduled price moved on from status from
+// The product price changes from current to price, when price is set and differs.
+// It reports false when the scheduled price or the product price changed meanwhile.
+func applyScheduledPrice(
+	ctx context.Context,
+	priceRepo repository.PriceRepository,
+	scheduled *entity.ScheduledPrice,
+	from entity.ScheduledPriceStatus,
+	current entity.Money,
+	price *entity.Money,
+	actorID string,
+) (bool, error) {
+	var change *entity.PriceChange
+	if price != nil && entity.PriceChanged(current, *price) {
+		change = entity.NewPriceChange(uuid.New().String(), scheduled.ProductID, current, *price, entity.PriceChangeSourceSchedule, actorID, scheduled.UpdatedAt)
+		change.ScheduledPriceID = &scheduled.ID
+	}
+
+	return priceRepo.Apply(ctx, scheduled, from, change)
+}
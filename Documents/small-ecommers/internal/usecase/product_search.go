@@ -0,0 +1,241 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// Product listing page sizes
+const (
+	defaultProductPageSize = 20
+	maxProductPageSize     = 100
+)
+
+// productSorts maps the sorts accepted by product listings to their field and direction
+var productSorts = map[string]struct {
+	field      repository.ProductSortField
+	descending bool
+}{
+	"newest": {repository.ProductSortCreatedAt, true},
+	"price":  {repository.ProductSortPrice, false},
+	"-price": {repository.ProductSortPrice, true},
+	"name":   {repository.ProductSortName, false},
+	"-name":  {repository.ProductSortName, true},
+}
+
+// ProductListRequest represents the filters of a product listing
+type ProductListRequest struct {
+	// Currency of MinPrice and MaxPrice, which are decimal strings, defaults to the default currency
+	Currency   string
+	MinPrice   string
+	MaxPrice   string
+	InStock    bool
+	NamePrefix string
+	// Category is the ID or slug of a category, matching products in it or any category below it
+	Category string
+	// Attributes map attribute keys to the value products must have, such as material=cotton
+	Attributes map[string]string
+	// Status defaults to active, archived products are only listed when asked for
+	Status entity.ProductStatus
+	// Sort is newest, price, -price, name or -name, and defaults to newest
+	Sort string
+	// Cursor is the next_cursor of the previous page
+	Cursor string
+	Limit  int
+}
+
+// ProductPage is a page of a product listing
+type ProductPage struct {
+	Products []*entity.Product `json:"products"`
+	// NextCursor fetches the next page, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Total is the number of products matching the filters, across all pages
+	Total int `json:"total"`
+}
+
+// productCursor is the content of an encoded product cursor
+type productCursor struct {
+	Sort      string    `json:"s"`
+	CreatedAt time.Time `json:"c"`
+	Price     string    `json:"p"`
+	Currency  string    `json:"cu"`
+	Name      string    `json:"n"`
+	ID        string    `json:"id"`
+}
+
+// ListProducts retrieves a page of the products matching the request
+func (uc *ProductUseCase) ListProducts(ctx context.Context, req *ProductListRequest) (*ProductPage, error) {
+	filter, err := uc.productFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Category != "" {
+		if filter.CategoryIDs, err = uc.categoryTree(ctx, req.Category); err != nil {
+			return nil, err
+		}
+	}
+
+	if filter.Attributes, err = uc.attributeFilter(ctx, req.Attributes); err != nil {
+		return nil, err
+	}
+
+	sort := req.Sort
+	if sort == "" {
+		sort = "newest"
+	}
+
+	order, ok := productSorts[sort]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort %q", entity.ErrInvalidProductQuery, req.Sort)
+	}
+
+	search := &repository.ProductSearch{
+		Filter:     *filter,
+		Sort:       order.field,
+		Descending: order.descending,
+	}
+
+	switch {
+	case req.Limit == 0:
+		search.Limit = defaultProductPageSize
+	case req.Limit < 0 || req.Limit > maxProductPageSize:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", entity.ErrInvalidProductQuery, maxProductPageSize)
+	default:
+		search.Limit = req.Limit
+	}
+
+	if req.Cursor != "" {
+		if search.After, err = decodeProductCursor(req.Cursor, sort); err != nil {
+			return nil, err
+		}
+	}
+
+	total, err := uc.productRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one product more than the page holds to know whether there is a next page
+	pageSize := search.Limit
+	search.Limit++
+
+	products, err := uc.productRepo.Search(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ProductPage{Products: products, Total: total}
+	if page.Products == nil {
+		page.Products = []*entity.Product{}
+	}
+
+	if len(products) > pageSize {
+		page.Products = products[:pageSize]
+		page.NextCursor = encodeProductCursor(page.Products[pageSize-1], sort)
+	}
+
+	return page, nil
+}
+
+// productFilter validates the filters of a listing request
+func (uc *ProductUseCase) productFilter(req *ProductListRequest) (*repository.ProductFilter, error) {
+	filter := &repository.ProductFilter{
+		InStock:    req.InStock,
+		NamePrefix: strings.TrimSpace(req.NamePrefix),
+		Status:     req.Status,
+	}
+
+	if filter.Status == "" {
+		filter.Status = entity.ProductStatusActive
+	}
+	if !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %q", entity.ErrInvalidProductQuery, req.Status)
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = uc.defaultCurrency
+	}
+
+	for _, bound := range []struct {
+		value string
+		dest  **entity.Money
+	}{
+		{req.MinPrice, &filter.MinPrice},
+		{req.MaxPrice, &filter.MaxPrice},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		price, err := entity.ParseMoney(bound.value, currency)
+		if err != nil || price.IsNegative() {
+			return nil, fmt.Errorf("%w: invalid price %q", entity.ErrInvalidProductQuery, bound.value)
+		}
+		*bound.dest = &price
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && filter.MinPrice.Cmp(*filter.MaxPrice) > 0 {
+		return nil, fmt.Errorf("%w: min_price must not exceed max_price", entity.ErrInvalidProductQuery)
+	}
+
+	return filter, nil
+}
+
+// encodeProductCursor returns the cursor of the page that follows a product
+func encodeProductCursor(product *entity.Product, sort string) string {
+	return encodeCursor(&productCursor{
+		Sort:      sort,
+		CreatedAt: product.CreatedAt,
+		Price:     product.Price.Decimal(),
+		Currency:  product.Price.Currency,
+		Name:      product.Name,
+		ID:        product.ID,
+	})
+}
+
+// decodeProductCursor reads a cursor, which must have been issued for the same sort
+func decodeProductCursor(encoded, sort string) (*repository.ProductCursor, error) {
+	var cursor productCursor
+	if err := decodeCursor(encoded, &cursor); err != nil {
+		return nil, err
+	}
+
+	if cursor.ID == "" || cursor.Sort != sort {
+		return nil, entity.ErrInvalidCursor
+	}
+
+	price, err := entity.ParseMoney(cursor.Price, cursor.Currency)
+	if err != nil {
+		return nil, entity.ErrInvalidCursor
+	}
+
+	return &repository.ProductCursor{
+		CreatedAt: cursor.CreatedAt,
+		Price:     price,
+		Name:      cursor.Name,
+		ID:        cursor.ID,
+	}, nil
+}
+
+// categoryTree resolves a category ID or slug to the IDs of the category and its descendants
+func (uc *ProductUseCase) categoryTree(ctx context.Context, ref string) ([]string, error) {
+	category, err := uc.categoryRepo.GetByID(ctx, ref)
+	if errors.Is(err, entity.ErrCategoryNotFound) {
+		category, err = uc.categoryRepo.GetBySlug(ctx, ref)
+	}
+	if errors.Is(err, entity.ErrCategoryNotFound) {
+		return nil, fmt.Errorf("%w: unknown category %q", entity.ErrInvalidProductQuery, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.categoryRepo.GetDescendantIDs(ctx, category.ID)
+}
@@ -0,0 +1,35 @@
+package usecase
+
+import "context"
+
+// ProductSearch defines the interface for full-text product search engines
+type ProductSearch interface {
+	// Search returns the products matching a text query, best match first
+	Search(ctx context.Context, query *ProductSearchQuery) (*ProductSearchResult, error)
+}
+
+// ProductSearchQuery represents a page of a text search
+type ProductSearchQuery struct {
+	Text   string
+	Limit  int
+	Offset int
+}
+
+// ProductSearchHit is a product matching a text search
+// Highlight is the name and Snippet an excerpt of the description, both HTML escaped
+// with the matched terms wrapped in <mark> tags
+type ProductSearchHit struct {
+	ProductID string
+	Rank      float64
+	Highlight string
+	Snippet   string
+}
+
+// ProductSearchResult is a page of search hits
+type ProductSearchResult struct {
+	Hits []*ProductSearchHit
+	// Total is the number of matching products across all pages
+	Total int
+	// Fuzzy is set when no product matched the query and the hits have names similar to it
+	Fuzzy bool
+}
@@ -2,6 +2,9 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -11,42 +14,132 @@ import (
 
 // ProductUseCase defines the business logic for product operations
 type ProductUseCase struct {
-	productRepo repository.ProductRepository
+	productRepo     repository.ProductRepository
+	categoryRepo    repository.CategoryRepository
+	priceRepo       repository.PriceRepository
+	media           BlobStore
+	images          ImageSettings
+	defaultCurrency string
 }
 
 // NewProductUseCase creates a new ProductUseCase
-func NewProductUseCase(productRepo repository.ProductRepository) *ProductUseCase {
+// Product images are stored in media, prices submitted without a currency are assumed to be in defaultCurrency
+func NewProductUseCase(
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	priceRepo repository.PriceRepository,
+	media BlobStore,
+	images ImageSettings,
+	defaultCurrency string,
+) *ProductUseCase {
 	return &ProductUseCase{
-		productRepo: productRepo,
+		productRepo:     productRepo,
+		categoryRepo:    categoryRepo,
+		priceRepo:       priceRepo,
+		media:           media,
+		images:          images,
+		defaultCurrency: defaultCurrency,
 	}
 }
 
 // CreateProductRequest represents the request to create a product
 type CreateProductRequest struct {
-	Name        string  `json:"name"`
-	Description *string `json:"description,omitempty"`
-	Price       float64 `json:"price"`
-	Stock       int     `json:"stock"`
+	Name        string         `json:"name"`
+	Description *string        `json:"description,omitempty"`
+	Price       entity.Money   `json:"price"`
+	Prices      []entity.Money `json:"prices,omitempty"`
+	CategoryIDs []string       `json:"category_ids,omitempty"`
+	// SKU of the default variant, defaults to the product ID
+	SKU string `json:"sku,omitempty"`
+	// Options are the dimensions variants of the product differ in
+	Options entity.ProductOptions `json:"options,omitempty"`
+	// Attributes are values of the attributes defined by the categories
+	Attributes entity.ProductAttributes `json:"attributes,omitempty"`
+	// Stock of the default variant
+	Stock int `json:"stock"`
+	// StockPolicy defaults to deny, ordering beyond stock is not allowed
+	StockPolicy    entity.StockPolicy `json:"stock_policy,omitempty"`
+	BackorderLimit *int               `json:"backorder_limit,omitempty"`
+	AvailableAt    *time.Time         `json:"available_at,omitempty"`
 }
 
 // UpdateProductRequest represents the request to update a product
 type UpdateProductRequest struct {
-	Name        *string  `json:"name,omitempty"`
-	Description *string  `json:"description,omitempty"`
-	Price       *float64 `json:"price,omitempty"`
-	Stock       *int     `json:"stock,omitempty"`
+	Name        *string         `json:"name,omitempty"`
+	Description *string         `json:"description,omitempty"`
+	Price       *entity.Money   `json:"price,omitempty"`
+	Prices      *[]entity.Money `json:"prices,omitempty"`
+	// CategoryIDs replaces the category assignments, an empty list removes them all
+	CategoryIDs *[]string `json:"category_ids,omitempty"`
+	// Options replaces the options, every variant must remain valid
+	Options *entity.ProductOptions `json:"options,omitempty"`
+	// Attributes sets the given attribute values, a null value removes the attribute
+	Attributes entity.ProductAttributes `json:"attributes,omitempty"`
+	// Stock of the default variant, raised above zero is handed to waiting backorders first
+	Stock          *int                `json:"stock,omitempty"`
+	StockPolicy    *entity.StockPolicy `json:"stock_policy,omitempty"`
+	BackorderLimit *int                `json:"backorder_limit,omitempty"`
+	AvailableAt    *time.Time          `json:"available_at,omitempty"`
+	// ClearBackorderLimit removes the backorder limit
+	ClearBackorderLimit bool `json:"clear_backorder_limit,omitempty"`
+	// ClearAvailableAt removes the expected availability date
+	ClearAvailableAt bool `json:"clear_available_at,omitempty"`
 }
 
 // CreateProduct creates a new product
 func (uc *ProductUseCase) CreateProduct(ctx context.Context, req *CreateProductRequest) (*entity.Product, error) {
+	price, err := req.Price.WithDefaultCurrency(uc.defaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
+	sku := req.SKU
+	if sku == "" {
+		sku = id
+	}
+
 	product := entity.NewProduct(
-		uuid.New().String(),
+		id,
 		req.Name,
 		req.Description,
-		req.Price,
+		price,
 		req.Stock,
+		sku,
 	)
 
+	if err := entity.ValidateOptions(req.Options); err != nil {
+		return nil, err
+	}
+	product.Options = req.Options
+
+	if err := product.ValidateVariant(product.DefaultVariant()); err != nil {
+		return nil, err
+	}
+
+	if product.Prices, err = validatePrices(product.Price, req.Prices); err != nil {
+		return nil, err
+	}
+
+	if product.CategoryIDs, err = uc.validateCategories(ctx, req.CategoryIDs); err != nil {
+		return nil, err
+	}
+
+	product.Attributes = req.Attributes
+	if err := uc.validateAttributes(ctx, product); err != nil {
+		return nil, err
+	}
+
+	if req.StockPolicy != "" {
+		product.StockPolicy = req.StockPolicy
+	}
+	product.BackorderLimit = req.BackorderLimit
+	product.AvailableAt = req.AvailableAt
+
+	if err := validateStockPolicy(product); err != nil {
+		return nil, err
+	}
+
 	if err := uc.productRepo.Create(ctx, product); err != nil {
 		return nil, err
 	}
@@ -59,18 +152,21 @@ func (uc *ProductUseCase) GetProduct(ctx context.Context, id string) (*entity.Pr
 	return uc.productRepo.GetByID(ctx, id)
 }
 
-// ListProducts retrieves all products
-func (uc *ProductUseCase) ListProducts(ctx context.Context) ([]*entity.Product, error) {
-	return uc.productRepo.List(ctx)
-}
-
 // UpdateProduct updates an existing product
-func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id string, req *UpdateProductRequest) (*entity.Product, error) {
+// A change of the price is recorded in the price history with actorID.
+// version is the product version the change was made to, ErrVersionConflict is returned once it moved on.
+func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id, actorID string, version int, req *UpdateProductRequest) (*entity.Product, error) {
 	product, err := uc.productRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkVersion(product.Version, version); err != nil {
+		return nil, err
+	}
+
+	oldPrice := product.Price
+
 	// Update fields if provided
 	if req.Name != nil {
 		product.Name = *req.Name
@@ -79,22 +175,324 @@ func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id string, req *Upd
 		product.Description = req.Description
 	}
 	if req.Price != nil {
-		product.Price = *req.Price
+		price, err := req.Price.WithDefaultCurrency(product.Price.Currency)
+		if err != nil {
+			return nil, err
+		}
+		product.Price = price
+	}
+	if req.Prices != nil {
+		product.Prices = *req.Prices
+	}
+	if product.Prices, err = validatePrices(product.Price, product.Prices); err != nil {
+		return nil, err
+	}
+	if req.CategoryIDs != nil {
+		if product.CategoryIDs, err = uc.validateCategories(ctx, *req.CategoryIDs); err != nil {
+			return nil, err
+		}
+	}
+	if req.Options != nil {
+		if err := entity.ValidateOptions(*req.Options); err != nil {
+			return nil, err
+		}
+		product.Options = *req.Options
+	}
+	// Options and the product currency constrain the variants
+	if req.Options != nil || req.Price != nil {
+		for _, variant := range product.Variants {
+			if err := product.ValidateVariant(variant); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if req.StockPolicy != nil {
+		product.StockPolicy = *req.StockPolicy
+	}
+	if req.BackorderLimit != nil || req.ClearBackorderLimit {
+		product.BackorderLimit = req.BackorderLimit
+	}
+	if req.AvailableAt != nil || req.ClearAvailableAt {
+		product.AvailableAt = req.AvailableAt
+	}
+	if err := validateStockPolicy(product); err != nil {
+		return nil, err
+	}
+	if req.Stock != nil && *req.Stock < 0 {
+		return nil, entity.ErrInsufficientStock
+	}
+
+	for key, value := range req.Attributes {
+		if product.Attributes == nil {
+			product.Attributes = make(entity.ProductAttributes)
+		}
+		if value == nil {
+			delete(product.Attributes, key)
+			continue
+		}
+		product.Attributes[key] = value
+	}
+	// Categories may have changed too, so the attributes are checked on every update
+	if err := uc.validateAttributes(ctx, product); err != nil {
+		return nil, err
+	}
+
+	var priceChange *entity.PriceChange
+	if entity.PriceChanged(oldPrice, product.Price) {
+		priceChange = entity.NewPriceChange(uuid.New().String(), product.ID, oldPrice, product.Price, entity.PriceChangeSourceManual, actorID, time.Now())
+	}
+
+	if err := uc.productRepo.Update(ctx, product, priceChange); err != nil {
+		return nil, err
 	}
+
+	// Stock goes through UpdateStock so that incoming units fill backorders
 	if req.Stock != nil {
-		product.Stock = *req.Stock
+		if _, err := uc.productRepo.UpdateStock(ctx, product.DefaultVariant().ID, *req.Stock); err != nil {
+			return nil, err
+		}
+
+		return uc.productRepo.GetByID(ctx, id)
+	}
+
+	return product, nil
+}
+
+// ArchiveProduct takes a product out of the catalog
+// Archived products are hidden from listings and search and can no longer be bought,
+// but stay available to the orders and carts referring to them
+func (uc *ProductUseCase) ArchiveProduct(ctx context.Context, id string) (*entity.Product, error) {
+	product, err := uc.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := uc.productRepo.Update(ctx, product); err != nil {
+	if product.IsArchived() {
+		return product, nil
+	}
+
+	product.Archive(time.Now())
+
+	if err := uc.productRepo.UpdateStatus(ctx, product); err != nil {
 		return nil, err
 	}
 
 	return product, nil
 }
 
-// DeleteProduct deletes a product by ID
-func (uc *ProductUseCase) DeleteProduct(ctx context.Context, id string) error {
-	return uc.productRepo.Delete(ctx, id)
+// RestoreProduct puts an archived product back in the catalog
+func (uc *ProductUseCase) RestoreProduct(ctx context.Context, id string) (*entity.Product, error) {
+	product, err := uc.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !product.IsArchived() {
+		return product, nil
+	}
+
+	product.Restore()
+
+	if err := uc.productRepo.UpdateStatus(ctx, product); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// CreateVariantRequest represents the request to add a variant to a product
+type CreateVariantRequest struct {
+	SKU     string              `json:"sku"`
+	Options entity.OptionValues `json:"options"`
+	// Price overrides the product price, in the product currency
+	Price *entity.Money `json:"price,omitempty"`
+	Stock int           `json:"stock"`
+}
+
+// UpdateVariantRequest represents the request to update a variant
+type UpdateVariantRequest struct {
+	SKU     *string              `json:"sku,omitempty"`
+	Options *entity.OptionValues `json:"options,omitempty"`
+	Price   *entity.Money        `json:"price,omitempty"`
+	// ClearPrice removes the price override, the product price applies again
+	ClearPrice bool `json:"clear_price,omitempty"`
+	// Stock raised above zero is handed to waiting backorders first
+	Stock *int `json:"stock,omitempty"`
+}
+
+// CreateVariant adds a variant to a product
+func (uc *ProductUseCase) CreateVariant(ctx context.Context, productID string, req *CreateVariantRequest) (*entity.Variant, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Stock < 0 {
+		return nil, entity.ErrInsufficientStock
+	}
+
+	price, err := variantPrice(product, req.Price)
+	if err != nil {
+		return nil, err
+	}
+
+	variant := entity.NewVariant(uuid.New().String(), product.ID, req.SKU, req.Options, price, req.Stock)
+
+	if err := product.ValidateVariant(variant); err != nil {
+		return nil, err
+	}
+
+	if err := uc.productRepo.CreateVariant(ctx, variant); err != nil {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+// UpdateVariant updates a variant of a product
+// version is the variant version the change was made to, ErrVersionConflict is returned once it moved on
+func (uc *ProductUseCase) UpdateVariant(ctx context.Context, productID, variantID string, version int, req *UpdateVariantRequest) (*entity.Variant, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := product.Variant(variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(variant.Version, version); err != nil {
+		return nil, err
+	}
+
+	if req.SKU != nil {
+		variant.SKU = *req.SKU
+	}
+	if req.Options != nil {
+		variant.Options = *req.Options
+	}
+	if req.Price != nil || req.ClearPrice {
+		if variant.Price, err = variantPrice(product, req.Price); err != nil {
+			return nil, err
+		}
+	}
+	if err := product.ValidateVariant(variant); err != nil {
+		return nil, err
+	}
+	if req.Stock != nil && *req.Stock < 0 {
+		return nil, entity.ErrInsufficientStock
+	}
+
+	if err := uc.productRepo.UpdateVariant(ctx, variant); err != nil {
+		return nil, err
+	}
+
+	// Stock goes through UpdateStock so that incoming units fill backorders
+	if req.Stock != nil {
+		if _, err := uc.productRepo.UpdateStock(ctx, variant.ID, *req.Stock); err != nil {
+			return nil, err
+		}
+
+		if product, err = uc.productRepo.GetByID(ctx, productID); err != nil {
+			return nil, err
+		}
+		return product.Variant(variantID)
+	}
+
+	return variant, nil
+}
+
+// DeleteVariant deletes a variant of a product
+// The default variant goes with its product only
+func (uc *ProductUseCase) DeleteVariant(ctx context.Context, productID, variantID string) error {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	variant, err := product.Variant(variantID)
+	if err != nil {
+		return err
+	}
+
+	if variant.IsDefault {
+		return fmt.Errorf("%w: the default variant cannot be deleted", entity.ErrInvalidVariant)
+	}
+
+	return uc.productRepo.DeleteVariant(ctx, variant.ID)
+}
+
+// variantPrice returns a variant price override in the product currency, nil for none
+func variantPrice(product *entity.Product, price *entity.Money) (*entity.Money, error) {
+	if price == nil {
+		return nil, nil
+	}
+
+	override, err := price.WithDefaultCurrency(product.Price.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &override, nil
+}
+
+// productVariant returns the variant of a product with the given ID, the default variant when it is empty
+func productVariant(product *entity.Product, variantID string) (*entity.Variant, error) {
+	if variantID == "" {
+		if variant := product.DefaultVariant(); variant != nil {
+			return variant, nil
+		}
+		return nil, entity.ErrVariantNotFound
+	}
+
+	return product.Variant(variantID)
+}
+
+// validatePrices checks explicit per-currency prices against the base price
+// Each currency may appear once and must differ from the base price currency
+func validatePrices(base entity.Money, prices []entity.Money) ([]entity.Money, error) {
+	seen := map[string]bool{base.Currency: true}
+	for _, price := range prices {
+		if !entity.IsCurrencyCode(price.Currency) || seen[price.Currency] {
+			return nil, entity.ErrInvalidCurrency
+		}
+		if !price.IsPositive() {
+			return nil, entity.ErrInvalidAmount
+		}
+		seen[price.Currency] = true
+	}
+	return prices, nil
+}
+
+// validateCategories removes duplicate category IDs and checks that the categories exist
+func (uc *ProductUseCase) validateCategories(ctx context.Context, ids []string) ([]string, error) {
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	categories, err := uc.categoryRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(categories) != len(ids) {
+		return nil, fmt.Errorf("%w: unknown category ID", entity.ErrInvalidCategory)
+	}
+
+	return ids, nil
+}
+
+// validateStockPolicy checks the stock policy settings of a product
+func validateStockPolicy(product *entity.Product) error {
+	if !product.StockPolicy.IsValid() {
+		return entity.ErrInvalidStockPolicy
+	}
+	if product.BackorderLimit != nil && *product.BackorderLimit < 0 {
+		return entity.ErrInvalidStockPolicy
+	}
+	return nil
 }
 
 // GetProductsByIDs retrieves products by multiple IDs
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+// ReorderItemStatus describes what happened to an item when reordering
+type ReorderItemStatus string
+
+const (
+	ReorderItemAdded       ReorderItemStatus = "added"
+	ReorderItemClamped     ReorderItemStatus = "clamped"
+	ReorderItemUnavailable ReorderItemStatus = "unavailable"
+)
+
+// ReorderItem reports how one item of the previous order was copied into the cart
+type ReorderItem struct {
+	ProductID string            `json:"product_id"`
+	VariantID string            `json:"variant_id"`
+	Requested int               `json:"requested"`
+	Added     int               `json:"added"`
+	Status    ReorderItemStatus `json:"status"`
+	Reason    string            `json:"reason,omitempty"`
+}
+
+// ReorderResult is the cart after reordering and the outcome for each item
+type ReorderResult struct {
+	Cart  *entity.Cart   `json:"cart"`
+	Items []*ReorderItem `json:"items"`
+}
+
+// Reorder copies the items of a previous order into the user's cart at current prices
+// Items that are gone, archived, out of stock or not sold in the cart currency are skipped,
+// and quantities are reduced to what can still be ordered after the items already in the cart
+func (uc *OrderUseCase) Reorder(ctx context.Context, userID, orderID string) (*ReorderResult, error) {
+	order, err := uc.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		return nil, entity.ErrOrderNotFound
+	}
+
+	cart, err := uc.carts.GetOrCreateCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	inCart := make(map[string]int, len(cart.Items))
+	for _, item := range cart.Items {
+		inCart[item.VariantID] += item.Quantity
+	}
+
+	// An order may list a variant more than once, reorder it as a single line
+	var variantIDs, productIDs []string
+	variantProducts := make(map[string]string)
+	quantities := make(map[string]int)
+	for _, item := range order.Items {
+		if _, seen := quantities[item.VariantID]; !seen {
+			variantIDs = append(variantIDs, item.VariantID)
+			productIDs = append(productIDs, item.ProductID)
+			variantProducts[item.VariantID] = item.ProductID
+		}
+		quantities[item.VariantID] += item.Quantity
+	}
+
+	products, err := uc.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	productMap := make(map[string]*entity.Product)
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	result := &ReorderResult{Cart: cart}
+
+	for _, variantID := range variantIDs {
+		productID := variantProducts[variantID]
+		item := &ReorderItem{
+			ProductID: productID,
+			VariantID: variantID,
+			Requested: quantities[variantID],
+			Status:    ReorderItemUnavailable,
+		}
+		result.Items = append(result.Items, item)
+
+		product, exists := productMap[productID]
+		if !exists {
+			item.Reason = entity.ErrProductNotFound.Error()
+			continue
+		}
+
+		variant, err := product.Variant(variantID)
+		if err != nil {
+			item.Reason = err.Error()
+			continue
+		}
+
+		available := product.MaxOrderable(variant) - inCart[variantID]
+		if available <= 0 {
+			item.Reason = entity.ErrInsufficientStock.Error()
+			continue
+		}
+
+		quantity := min(item.Requested, available)
+
+		cart, err = uc.carts.AddItem(ctx, userID, &AddItemRequest{
+			ProductID: productID,
+			VariantID: variantID,
+			Quantity:  quantity,
+		})
+		if err != nil {
+			if errors.Is(err, entity.ErrInsufficientStock) || errors.Is(err, entity.ErrPriceNotAvailable) ||
+				errors.Is(err, entity.ErrProductArchived) {
+				item.Reason = err.Error()
+				continue
+			}
+			return nil, err
+		}
+
+		result.Cart = cart
+		item.Added = quantity
+		item.Status = ReorderItemAdded
+		if quantity < item.Requested {
+			item.Status = ReorderItemClamped
+			item.Reason = entity.ErrInsufficientStock.Error()
+		}
+	}
+
+	return result, nil
+}
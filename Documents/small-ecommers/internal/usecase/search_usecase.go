@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// Product search limits
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+	maxSearchQueryLength  = 200
+)
+
+// SearchUseCase handles product search business logic
+type SearchUseCase struct {
+	search      ProductSearch
+	productRepo repository.ProductRepository
+}
+
+// NewSearchUseCase creates a new SearchUseCase
+func NewSearchUseCase(search ProductSearch, productRepo repository.ProductRepository) *SearchUseCase {
+	return &SearchUseCase{
+		search:      search,
+		productRepo: productRepo,
+	}
+}
+
+// SearchProductsRequest represents a product search request
+type SearchProductsRequest struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// ProductMatch is a product found by a search
+type ProductMatch struct {
+	Product *entity.Product `json:"product"`
+	Rank    float64         `json:"rank"`
+	// Highlight and Snippet are HTML escaped, with the matched terms wrapped in <mark> tags
+	Highlight string `json:"highlight"`
+	Snippet   string `json:"snippet"`
+}
+
+// ProductSearchPage is a page of product search results
+type ProductSearchPage struct {
+	Matches []*ProductMatch `json:"matches"`
+	Total   int             `json:"total"`
+	// Fuzzy is set when nothing matched the query exactly and the matches have similar names
+	Fuzzy bool `json:"fuzzy"`
+}
+
+// SearchProducts finds the products matching a text query, best match first
+func (uc *SearchUseCase) SearchProducts(ctx context.Context, req *SearchProductsRequest) (*ProductSearchPage, error) {
+	query := &ProductSearchQuery{
+		Text:   strings.TrimSpace(req.Query),
+		Offset: req.Offset,
+	}
+
+	if query.Text == "" {
+		return nil, fmt.Errorf("%w: q is required", entity.ErrInvalidProductQuery)
+	}
+
+	if utf8.RuneCountInString(query.Text) > maxSearchQueryLength {
+		return nil, fmt.Errorf("%w: q must be at most %d characters", entity.ErrInvalidProductQuery, maxSearchQueryLength)
+	}
+
+	switch {
+	case req.Limit == 0:
+		query.Limit = defaultSearchPageSize
+	case req.Limit < 0 || req.Limit > maxSearchPageSize:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", entity.ErrInvalidProductQuery, maxSearchPageSize)
+	default:
+		query.Limit = req.Limit
+	}
+
+	if query.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset cannot be negative", entity.ErrInvalidProductQuery)
+	}
+
+	result, err := uc.search.Search(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+
+	ids := make([]string, len(result.Hits))
+	for i, hit := range result.Hits {
+		ids[i] = hit.ProductID
+	}
+
+	products, err := uc.productRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*entity.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	page := &ProductSearchPage{
+		Matches: make([]*ProductMatch, 0, len(result.Hits)),
+		Total:   result.Total,
+		Fuzzy:   result.Fuzzy,
+	}
+
+	// Hits keep the engine's order, products deleted since they were indexed are skipped
+	for _, hit := range result.Hits {
+		product, ok := byID[hit.ProductID]
+		if !ok {
+			continue
+		}
+		page.Matches = append(page.Matches, &ProductMatch{
+			Product:   product,
+			Rank:      hit.Rank,
+			Highlight: hit.Highlight,
+			Snippet:   hit.Snippet,
+		})
+	}
+
+	return page, nil
+}
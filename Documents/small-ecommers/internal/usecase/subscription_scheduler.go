@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// SubscriptionScheduler places and charges the orders of due subscriptions
+type SubscriptionScheduler struct {
+	subscriptionRepo repository.SubscriptionRepository
+	orders           *OrderUseCase
+	clock            Clock
+	batchSize        int
+}
+
+// NewSubscriptionScheduler creates a new SubscriptionScheduler
+// Each run handles at most batchSize subscriptions
+func NewSubscriptionScheduler(
+	subscriptionRepo repository.SubscriptionRepository,
+	orders *OrderUseCase,
+	clock Clock,
+	batchSize int,
+) *SubscriptionScheduler {
+	return &SubscriptionScheduler{
+		subscriptionRepo: subscriptionRepo,
+		orders:           orders,
+		clock:            clock,
+		batchSize:        batchSize,
+	}
+}
+
+// Run processes due subscriptions every interval until the context is cancelled
+func (s *SubscriptionScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if processed, err := s.RunDue(ctx); err != nil {
+			log.Printf("Subscription scheduler failed: %v", err)
+		} else if processed > 0 {
+			log.Printf("Subscription scheduler processed %d subscriptions", processed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue places the orders of all subscriptions that are due now
+// Returns the number of subscription runs that were processed
+func (s *SubscriptionScheduler) RunDue(ctx context.Context) (int, error) {
+	processed := 0
+
+	for {
+		now := s.clock.Now()
+
+		due, err := s.subscriptionRepo.ListDue(ctx, now, s.batchSize)
+		if err != nil {
+			return processed, err
+		}
+
+		claimed := 0
+		for _, subscription := range due {
+			ok, err := s.runSubscription(ctx, subscription, now)
+			if err != nil {
+				return processed, err
+			}
+			if ok {
+				claimed++
+			}
+		}
+		processed += claimed
+
+		// Stop once the backlog is drained, or when other workers took the whole batch
+		if len(due) < s.batchSize || claimed == 0 {
+			return processed, nil
+		}
+	}
+}
+
+// runSubscription claims the current run of a subscription and places its order
+// It reports false when another worker claimed the run first
+func (s *SubscriptionScheduler) runSubscription(ctx context.Context, subscription *entity.Subscription, now time.Time) (bool, error) {
+	scheduledAt := subscription.NextRunAt
+	subscription.Advance(now)
+
+	claimed, err := s.subscriptionRepo.Claim(ctx, subscription.ID, scheduledAt, subscription.NextRunAt)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	order, runErr := s.placeAndCharge(ctx, subscription)
+
+	// Reload so a pause or cancel made while the order was placed is kept
+	current, err := s.subscriptionRepo.GetByID(ctx, subscription.ID)
+	if err != nil {
+		return true, err
+	}
+
+	switch {
+	case runErr != nil:
+		log.Printf("Subscription %s run failed: %v", subscription.ID, runErr)
+		current.RecordFailure(runErr.Error(), now)
+	case order.Status == entity.OrderStatusPaid:
+		current.RecordSuccess(order.ID, now)
+	default:
+		// Held for fraud review, the order may still be rejected
+		log.Printf("Subscription %s order %s is %s", subscription.ID, order.ID, order.Status)
+		current.RecordPending(order.ID, now)
+	}
+
+	return true, s.subscriptionRepo.Update(ctx, current)
+}
+
+// placeAndCharge creates the order of a subscription run and charges the customer
+// An order whose payment fails is cancelled so its stock is released. The returned order is paid,
+// or on hold when fraud screening wants it reviewed first.
+func (s *SubscriptionScheduler) placeAndCharge(ctx context.Context, subscription *entity.Subscription) (*entity.Order, error) {
+	items := make([]CreateOrderItemRequest, len(subscription.Items))
+	for i, item := range subscription.Items {
+		items[i] = CreateOrderItemRequest{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		}
+	}
+
+	order, err := s.orders.CreateOrderFromItems(ctx, subscription.UserID, subscription.Currency, &CreateOrderRequest{Items: items})
+	if err != nil {
+		return nil, fmt.Errorf("failed to place order: %w", err)
+	}
+
+	paid, err := s.orders.PayOrder(ctx, subscription.UserID, order.ID)
+	if err != nil {
+		if _, cancelErr := s.orders.CancelOrder(ctx, subscription.UserID, order.ID); cancelErr != nil {
+			log.Printf("Failed to cancel unpaid subscription order %s: %v", order.ID, cancelErr)
+		}
+		return nil, fmt.Errorf("failed to pay order %s: %w", order.ID, err)
+	}
+
+	return paid, nil
+}
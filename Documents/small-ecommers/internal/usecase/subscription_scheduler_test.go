@@ -0,0 +1,491 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+const week = 7 * 24 * time.Hour
+
+// fakeClock is a Clock the tests move by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// memSubscriptions is an in-memory SubscriptionRepository
+// It hands out copies, so the scheduler only sees what it saved
+type memSubscriptions struct {
+	subscriptions map[string]*entity.Subscription
+	// claimedElsewhere makes Claim lose the race for these subscriptions
+	claimedElsewhere map[string]bool
+}
+
+func newMemSubscriptions(subscriptions ...*entity.Subscription) *memSubscriptions {
+	r := &memSubscriptions{
+		subscriptions:    make(map[string]*entity.Subscription),
+		claimedElsewhere: make(map[string]bool),
+	}
+	for _, subscription := range subscriptions {
+		r.subscriptions[subscription.ID] = copySubscription(subscription)
+	}
+	return r
+}
+
+func copySubscription(subscription *entity.Subscription) *entity.Subscription {
+	c := *subscription
+	c.Items = append([]*entity.SubscriptionItem(nil), subscription.Items...)
+	return &c
+}
+
+func (r *memSubscriptions) Create(ctx context.Context, subscription *entity.Subscription) error {
+	r.subscriptions[subscription.ID] = copySubscription(subscription)
+	return nil
+}
+
+func (r *memSubscriptions) GetByID(ctx context.Context, id string) (*entity.Subscription, error) {
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return nil, entity.ErrSubscriptionNotFound
+	}
+	return copySubscription(subscription), nil
+}
+
+func (r *memSubscriptions) GetByUserID(ctx context.Context, userID string) ([]*entity.Subscription, error) {
+	var subscriptions []*entity.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.UserID == userID {
+			subscriptions = append(subscriptions, copySubscription(subscription))
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *memSubscriptions) ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.Subscription, error) {
+	var due []*entity.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.IsDue(now) && len(due) < limit {
+			due = append(due, copySubscription(subscription))
+		}
+	}
+	return due, nil
+}
+
+func (r *memSubscriptions) Update(ctx context.Context, subscription *entity.Subscription) error {
+	if _, ok := r.subscriptions[subscription.ID]; !ok {
+		return entity.ErrSubscriptionNotFound
+	}
+	r.subscriptions[subscription.ID] = copySubscription(subscription)
+	return nil
+}
+
+func (r *memSubscriptions) Claim(ctx context.Context, id string, scheduledAt, nextRunAt time.Time) (bool, error) {
+	subscription, ok := r.subscriptions[id]
+	if !ok || r.claimedElsewhere[id] {
+		return false, nil
+	}
+	if subscription.Status != entity.SubscriptionStatusActive || !subscription.NextRunAt.Equal(scheduledAt) {
+		return false, nil
+	}
+	subscription.NextRunAt = nextRunAt
+	return true, nil
+}
+
+// memProducts serves products from memory and records released stock
+type memProducts struct {
+	repository.ProductRepository
+	products map[string]*entity.Product
+}
+
+func (r *memProducts) GetByID(ctx context.Context, id string) (*entity.Product, error) {
+	product, ok := r.products[id]
+	if !ok {
+		return nil, entity.ErrProductNotFound
+	}
+	return product, nil
+}
+
+func (r *memProducts) GetByIDs(ctx context.Context, ids []string) ([]*entity.Product, error) {
+	var products []*entity.Product
+	for _, id := range ids {
+		if product, ok := r.products[id]; ok {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (r *memProducts) ReleaseStock(ctx context.Context, variantID string, allocated, backordered int) ([]*entity.Backorder, error) {
+	for _, product := range r.products {
+		if variant, err := product.Variant(variantID); err == nil {
+			variant.Stock += allocated
+			return nil, nil
+		}
+	}
+	return nil, entity.ErrVariantNotFound
+}
+
+// memOrders keeps orders in memory
+type memOrders struct {
+	repository.OrderRepository
+	orders map[string]*entity.Order
+}
+
+func (r *memOrders) Create(ctx context.Context, order *entity.Order) error {
+	r.orders[order.ID] = order
+	return nil
+}
+
+func (r *memOrders) GetByID(ctx context.Context, id string) (*entity.Order, error) {
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, entity.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (r *memOrders) Update(ctx context.Context, order *entity.Order) error {
+	r.orders[order.ID] = order
+	return nil
+}
+
+// memUsers knows every user
+type memUsers struct {
+	repository.UserRepository
+}
+
+func (r *memUsers) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	return entity.NewUser(id, "Customer", id+"@example.com", "secret"), nil
+}
+
+// memFraud discards screenings
+type memFraud struct {
+	repository.FraudRepository
+}
+
+func (r *memFraud) Create(ctx context.Context, screening *entity.FraudScreening) error {
+	return nil
+}
+
+// memLedger has no gift card or store credit entries
+type memLedger struct {
+	repository.LedgerRepository
+}
+
+func (r *memLedger) GetByOrderID(ctx context.Context, orderID string) ([]*entity.LedgerEntry, error) {
+	return nil, nil
+}
+
+// memLoyalty applies loyalty changes to an empty history
+type memLoyalty struct {
+	repository.LoyaltyRepository
+}
+
+func (r *memLoyalty) Apply(ctx context.Context, userID string, fn func(history []*entity.LoyaltyEntry) ([]*entity.LoyaltyEntry, error)) ([]*entity.LoyaltyEntry, error) {
+	return fn(nil)
+}
+
+// fakeGateway declines the next declines charges and records the charges and refunds it makes
+type fakeGateway struct {
+	declines int
+	charges  []*ChargeRequest
+	refunds  []*ChargeRefundRequest
+	// onCharge runs before each charge
+	onCharge func()
+}
+
+func (g *fakeGateway) Charge(ctx context.Context, req *ChargeRequest) (*ChargeResult, error) {
+	if g.onCharge != nil {
+		g.onCharge()
+	}
+	if g.declines > 0 {
+		g.declines--
+		return nil, fmt.Errorf("%w: insufficient funds", entity.ErrPaymentDeclined)
+	}
+	g.charges = append(g.charges, req)
+	return &ChargeResult{Reference: "charge-" + req.OrderID}, nil
+}
+
+func (g *fakeGateway) Refund(ctx context.Context, req *ChargeRefundRequest) error {
+	g.refunds = append(g.refunds, req)
+	return nil
+}
+
+// schedulerFixture wires a scheduler to in-memory repositories
+type schedulerFixture struct {
+	clock         *fakeClock
+	subscriptions *memSubscriptions
+	products      *memProducts
+	orders        *memOrders
+	gateway       *fakeGateway
+	scheduler     *SubscriptionScheduler
+	large         *entity.Variant
+}
+
+func newSchedulerFixture(start time.Time, subscriptions ...*entity.Subscription) *schedulerFixture {
+	product := entity.NewProduct("coffee", "Coffee", nil, entity.NewMoney(1200, "USD"), 100, "COFFEE")
+	large := entity.NewVariant("coffee-1kg", product.ID, "COFFEE-1KG", entity.OptionValues{"size": "1kg"}, nil, 10)
+	large.Price = &entity.Money{Amount: 3000, Currency: "USD"}
+	product.Variants = append(product.Variants, large)
+
+	f := &schedulerFixture{
+		clock:         &fakeClock{now: start},
+		subscriptions: newMemSubscriptions(subscriptions...),
+		products:      &memProducts{products: map[string]*entity.Product{product.ID: product}},
+		orders:        &memOrders{orders: make(map[string]*entity.Order)},
+		gateway:       &fakeGateway{},
+		large:         large,
+	}
+
+	orders := NewOrderUseCase(
+		f.orders,
+		nil,
+		f.products,
+		nil,
+		NewPriceResolver(nil, "USD"),
+		NewPricingPipeline(),
+		nil,
+		NewCreditUseCase(nil, &memLedger{}),
+		NewLoyaltyUseCase(&memLoyalty{}, LoyaltyRules{}, f.clock),
+		NewFraudScreener(&memFraud{}, &memUsers{}, f.clock, FraudThresholds{}),
+		f.gateway,
+		nil,
+	)
+
+	f.scheduler = NewSubscriptionScheduler(f.subscriptions, orders, f.clock, 10)
+	return f
+}
+
+// weeklyLarge is a weekly subscription to the 1kg variant of the fixture's product
+func weeklyLarge(id string, start time.Time) *entity.Subscription {
+	items := []*entity.SubscriptionItem{{ID: id + "-item", ProductID: "coffee", VariantID: "coffee-1kg", Quantity: 2}}
+	return entity.NewSubscription(id, "user-1", "USD", 1, items, start, start)
+}
+
+func (f *schedulerFixture) runDue(t *testing.T) int {
+	t.Helper()
+	processed, err := f.scheduler.RunDue(context.Background())
+	if err != nil {
+		t.Fatalf("RunDue: %v", err)
+	}
+	return processed
+}
+
+func (f *schedulerFixture) subscription(t *testing.T, id string) *entity.Subscription {
+	t.Helper()
+	subscription, err := f.subscriptions.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return subscription
+}
+
+func TestSchedulerChargesTheSubscribedVariant(t *testing.T) {
+	start := time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC)
+	f := newSchedulerFixture(start, weeklyLarge("s1", start))
+
+	if processed := f.runDue(t); processed != 1 {
+		t.Fatalf("processed %d runs, want 1", processed)
+	}
+
+	subscription := f.subscription(t, "s1")
+	if subscription.LastOrderID == nil {
+		t.Fatal("no order recorded for the run")
+	}
+	order := f.orders.orders[*subscription.LastOrderID]
+	if order.Status != entity.OrderStatusPaid {
+		t.Errorf("order status %s, want paid", order.Status)
+	}
+	if item := order.Items[0]; item.VariantID != "coffee-1kg" || item.Price.Amount != 3000 {
+		t.Errorf("order delivers variant %s at %d, want coffee-1kg at 3000", item.VariantID, item.Price.Amount)
+	}
+	if f.large.Stock != 8 {
+		t.Errorf("1kg stock %d, want 8", f.large.Stock)
+	}
+	if want := start.Add(week); !subscription.NextRunAt.Equal(want) {
+		t.Errorf("next run %v, want %v", subscription.NextRunAt, want)
+	}
+
+	// Nothing is due again until the next run
+	f.clock.now = start.Add(week - time.Minute)
+	if processed := f.runDue(t); processed != 0 {
+		t.Errorf("processed %d runs before the next run, want 0", processed)
+	}
+}
+
+func TestSchedulerRetriesDeclinedPaymentsThenPauses(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	f := newSchedulerFixture(start, weeklyLarge("s1", start))
+	f.gateway.declines = entity.MaxSubscriptionFailures
+
+	for run := 1; run <= entity.MaxSubscriptionFailures; run++ {
+		if processed := f.runDue(t); processed != 1 {
+			t.Fatalf("run %d: processed %d runs, want 1", run, processed)
+		}
+
+		subscription := f.subscription(t, "s1")
+		if subscription.FailureCount != run {
+			t.Errorf("run %d: failure count %d", run, subscription.FailureCount)
+		}
+		if subscription.LastError == nil || !strings.Contains(*subscription.LastError, entity.ErrPaymentDeclined.Error()) {
+			t.Errorf("run %d: last error %v, want a declined payment", run, subscription.LastError)
+		}
+		// The declined order is cancelled and its stock released
+		if f.large.Stock != 10 {
+			t.Errorf("run %d: 1kg stock %d, want 10", run, f.large.Stock)
+		}
+
+		// The failed run is retried at the next scheduled run
+		f.clock.now = f.clock.now.Add(week)
+	}
+
+	for _, order := range f.orders.orders {
+		if order.Status != entity.OrderStatusCancelled {
+			t.Errorf("order %s status %s, want cancelled", order.ID, order.Status)
+		}
+	}
+
+	subscription := f.subscription(t, "s1")
+	if subscription.Status != entity.SubscriptionStatusPaused {
+		t.Fatalf("status %s after %d declined payments, want paused", subscription.Status, entity.MaxSubscriptionFailures)
+	}
+
+	if processed := f.runDue(t); processed != 0 {
+		t.Errorf("paused subscription ran %d times", processed)
+	}
+	if len(f.orders.orders) != entity.MaxSubscriptionFailures {
+		t.Errorf("%d orders placed, want %d", len(f.orders.orders), entity.MaxSubscriptionFailures)
+	}
+}
+
+func TestSchedulerSuccessResetsFailures(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	f := newSchedulerFixture(start, weeklyLarge("s1", start))
+	f.gateway.declines = 1
+
+	f.runDue(t)
+	if subscription := f.subscription(t, "s1"); subscription.FailureCount != 1 {
+		t.Fatalf("failure count %d after a declined payment, want 1", subscription.FailureCount)
+	}
+
+	f.clock.now = start.Add(week)
+	f.runDue(t)
+
+	subscription := f.subscription(t, "s1")
+	if subscription.FailureCount != 0 || subscription.LastError != nil {
+		t.Errorf("failure count %d, last error %v after a paid run", subscription.FailureCount, subscription.LastError)
+	}
+	if len(f.gateway.charges) != 1 {
+		t.Errorf("%d charges collected, want 1", len(f.gateway.charges))
+	}
+}
+
+// reviewEverything is a fraud rule that sends every order to review
+type reviewEverything struct{}
+
+func (reviewEverything) Name() string {
+	return "review_everything"
+}
+
+func (reviewEverything) Evaluate(ctx context.Context, fc *FraudContext) (*entity.FraudRuleHit, error) {
+	return &entity.FraudRuleHit{Rule: "review_everything", Score: 1, Reason: "always reviewed"}, nil
+}
+
+func TestSchedulerHeldOrderIsNotASuccess(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	f := newSchedulerFixture(start, weeklyLarge("s1", start))
+	f.gateway.declines = 1
+
+	f.runDue(t)
+
+	f.scheduler.orders.fraud = NewFraudScreener(&memFraud{}, &memUsers{}, f.clock, FraudThresholds{ReviewScore: 1}, reviewEverything{})
+	f.clock.now = start.Add(week)
+	f.runDue(t)
+
+	subscription := f.subscription(t, "s1")
+	if subscription.FailureCount != 1 || subscription.LastError == nil {
+		t.Errorf("failure count %d, last error %v after a held run, want the earlier failure kept", subscription.FailureCount, subscription.LastError)
+	}
+	if subscription.LastOrderID == nil {
+		t.Fatal("held order not recorded as the last order")
+	}
+
+	order, err := f.orders.GetByID(context.Background(), *subscription.LastOrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != entity.OrderStatusOnHold {
+		t.Errorf("last order is %s, want on_hold", order.Status)
+	}
+	if len(f.gateway.charges) != 0 {
+		t.Errorf("%d charges collected, want 0", len(f.gateway.charges))
+	}
+}
+
+func TestSchedulerSkipsMissedRuns(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	f := newSchedulerFixture(start, weeklyLarge("s1", start))
+
+	// The scheduler comes back after five missed weeks and a day
+	now := start.Add(5*week + 24*time.Hour)
+	f.clock.now = now
+
+	if processed := f.runDue(t); processed != 1 {
+		t.Fatalf("processed %d runs, want 1", processed)
+	}
+	if len(f.gateway.charges) != 1 {
+		t.Errorf("%d charges collected, want 1 for all the missed runs", len(f.gateway.charges))
+	}
+
+	// The next run stays on the weekly schedule
+	if subscription := f.subscription(t, "s1"); !subscription.NextRunAt.Equal(start.Add(6 * week)) {
+		t.Errorf("next run %v, want %v", subscription.NextRunAt, start.Add(6*week))
+	}
+}
+
+func TestSchedulerSkipsRunsClaimedElsewhere(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	f := newSchedulerFixture(start, weeklyLarge("s1", start), weeklyLarge("s2", start))
+	f.subscriptions.claimedElsewhere["s2"] = true
+
+	if processed := f.runDue(t); processed != 1 {
+		t.Fatalf("processed %d runs, want 1", processed)
+	}
+	if len(f.orders.orders) != 1 {
+		t.Errorf("%d orders placed, want 1", len(f.orders.orders))
+	}
+	if subscription := f.subscription(t, "s2"); subscription.LastRunAt != nil {
+		t.Error("a run claimed by another worker was recorded")
+	}
+}
+
+func TestSchedulerKeepsPauseMadeDuringRun(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	f := newSchedulerFixture(start, weeklyLarge("s1", start))
+
+	// The customer pauses the subscription while its order is being charged
+	f.gateway.onCharge = func() {
+		if err := f.subscriptions.subscriptions["s1"].Pause(start); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f.runDue(t)
+
+	subscription := f.subscription(t, "s1")
+	if subscription.Status != entity.SubscriptionStatusPaused {
+		t.Errorf("status %s, want the pause kept", subscription.Status)
+	}
+	if subscription.LastOrderID == nil {
+		t.Error("the run that was already charged is not recorded")
+	}
+}
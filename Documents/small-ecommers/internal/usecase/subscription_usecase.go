@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"small-ecommers/internal/domain/entity"
+	"small-ecommers/internal/domain/repository"
+)
+
+// MaxSubscriptionIntervalWeeks is the longest allowed time between two deliveries
+const MaxSubscriptionIntervalWeeks = 52
+
+// SubscriptionUseCase defines the business logic for subscription operations
+type SubscriptionUseCase struct {
+	subscriptionRepo repository.SubscriptionRepository
+	productRepo      repository.ProductRepository
+	prices           *PriceResolver
+	clock            Clock
+}
+
+// NewSubscriptionUseCase creates a new SubscriptionUseCase
+func NewSubscriptionUseCase(
+	subscriptionRepo repository.SubscriptionRepository,
+	productRepo repository.ProductRepository,
+	prices *PriceResolver,
+	clock Clock,
+) *SubscriptionUseCase {
+	return &SubscriptionUseCase{
+		subscriptionRepo: subscriptionRepo,
+		productRepo:      productRepo,
+		prices:           prices,
+		clock:            clock,
+	}
+}
+
+// CreateSubscriptionRequest represents the request to create a subscription
+type CreateSubscriptionRequest struct {
+	Items         []CreateOrderItemRequest `json:"items"`
+	IntervalWeeks int                      `json:"interval_weeks"`
+	// Currency defaults to the base currency
+	Currency string `json:"currency,omitempty"`
+	// StartAt is the first delivery, defaults to now
+	StartAt *time.Time `json:"start_at,omitempty"`
+}
+
+// CreateSubscription creates a new subscription
+func (uc *SubscriptionUseCase) CreateSubscription(ctx context.Context, userID string, req *CreateSubscriptionRequest) (*entity.Subscription, error) {
+	now := uc.clock.Now()
+
+	if req.IntervalWeeks < 1 || req.IntervalWeeks > MaxSubscriptionIntervalWeeks {
+		return nil, fmt.Errorf("interval must be between 1 and %d weeks", MaxSubscriptionIntervalWeeks)
+	}
+
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("subscription has no items")
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = uc.prices.BaseCurrency()
+	}
+	if !entity.IsCurrencyCode(currency) {
+		return nil, entity.ErrInvalidCurrency
+	}
+
+	startAt := now
+	if req.StartAt != nil && req.StartAt.After(now) {
+		startAt = req.StartAt.UTC()
+	}
+
+	items := make([]*entity.SubscriptionItem, len(req.Items))
+	seen := make(map[string]bool, len(req.Items))
+
+	for i, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity for product: %s", item.ProductID)
+		}
+
+		product, err := uc.productRepo.GetByID(ctx, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product.IsArchived() {
+			return nil, entity.ErrProductArchived
+		}
+
+		variant, err := productVariant(product, item.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		if seen[variant.ID] {
+			return nil, fmt.Errorf("duplicate variant: %s", variant.ID)
+		}
+		seen[variant.ID] = true
+
+		// Refuse variants that could not be priced at delivery time
+		if _, err := uc.prices.Resolve(ctx, product, variant, currency); err != nil {
+			return nil, err
+		}
+
+		items[i] = &entity.SubscriptionItem{
+			ID:        uuid.New().String(),
+			ProductID: product.ID,
+			VariantID: variant.ID,
+			Quantity:  item.Quantity,
+		}
+	}
+
+	subscription := entity.NewSubscription(uuid.New().String(), userID, currency, req.IntervalWeeks, items, startAt, now)
+
+	if err := uc.subscriptionRepo.Create(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// GetSubscription retrieves a subscription owned by the user
+func (uc *SubscriptionUseCase) GetSubscription(ctx context.Context, id, userID string) (*entity.Subscription, error) {
+	subscription, err := uc.subscriptionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.UserID != userID {
+		return nil, entity.ErrSubscriptionNotFound
+	}
+
+	return subscription, nil
+}
+
+// GetUserSubscriptions retrieves all subscriptions for a user
+func (uc *SubscriptionUseCase) GetUserSubscriptions(ctx context.Context, userID string) ([]*entity.Subscription, error) {
+	return uc.subscriptionRepo.GetByUserID(ctx, userID)
+}
+
+// PauseSubscription pauses a subscription owned by the user
+func (uc *SubscriptionUseCase) PauseSubscription(ctx context.Context, id, userID string) (*entity.Subscription, error) {
+	return uc.transition(ctx, id, userID, (*entity.Subscription).Pause)
+}
+
+// ResumeSubscription resumes a paused subscription owned by the user
+func (uc *SubscriptionUseCase) ResumeSubscription(ctx context.Context, id, userID string) (*entity.Subscription, error) {
+	return uc.transition(ctx, id, userID, (*entity.Subscription).Resume)
+}
+
+// CancelSubscription cancels a subscription owned by the user
+func (uc *SubscriptionUseCase) CancelSubscription(ctx context.Context, id, userID string) (*entity.Subscription, error) {
+	return uc.transition(ctx, id, userID, (*entity.Subscription).Cancel)
+}
+
+// transition applies a status change to a subscription and saves it
+func (uc *SubscriptionUseCase) transition(ctx context.Context, id, userID string, apply func(*entity.Subscription, time.Time) error) (*entity.Subscription, error) {
+	subscription, err := uc.GetSubscription(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := apply(subscription, uc.clock.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := uc.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
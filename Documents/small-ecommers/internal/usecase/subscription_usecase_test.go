@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"small-ecommers/internal/domain/entity"
+)
+
+func newSubscriptionFixture(start time.Time) (*SubscriptionUseCase, *schedulerFixture) {
+	f := newSchedulerFixture(start)
+	return NewSubscriptionUseCase(f.subscriptions, f.products, NewPriceResolver(nil, "USD"), f.clock), f
+}
+
+func TestCreateSubscriptionStoresVariants(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	uc, _ := newSubscriptionFixture(start)
+
+	subscription, err := uc.CreateSubscription(context.Background(), "user-1", &CreateSubscriptionRequest{
+		IntervalWeeks: 2,
+		Items: []CreateOrderItemRequest{
+			{ProductID: "coffee", Quantity: 1},
+			{ProductID: "coffee", VariantID: "coffee-1kg", Quantity: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := subscription.Items[0].VariantID; got != "coffee" {
+		t.Errorf("item without a variant stores %q, want the default variant", got)
+	}
+	if got := subscription.Items[1].VariantID; got != "coffee-1kg" {
+		t.Errorf("item stores variant %q, want coffee-1kg", got)
+	}
+}
+
+func TestCreateSubscriptionRejectsInvalidVariants(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)
+	uc, f := newSubscriptionFixture(start)
+
+	tests := []struct {
+		name  string
+		items []CreateOrderItemRequest
+	}{
+		{"unknown variant", []CreateOrderItemRequest{{ProductID: "coffee", VariantID: "tea-1kg", Quantity: 1}}},
+		{"duplicate variant", []CreateOrderItemRequest{
+			{ProductID: "coffee", VariantID: "coffee-1kg", Quantity: 1},
+			{ProductID: "coffee", VariantID: "coffee-1kg", Quantity: 2},
+		}},
+		{"default variant twice", []CreateOrderItemRequest{
+			{ProductID: "coffee", Quantity: 1},
+			{ProductID: "coffee", VariantID: "coffee", Quantity: 1},
+		}},
+	}
+
+	for _, tt := range tests {
+		_, err := uc.CreateSubscription(context.Background(), "user-1", &CreateSubscriptionRequest{IntervalWeeks: 1, Items: tt.items})
+		if err == nil {
+			t.Errorf("%s: subscription created", tt.name)
+		}
+	}
+
+	_, err := uc.CreateSubscription(context.Background(), "user-1", &CreateSubscriptionRequest{
+		IntervalWeeks: 1,
+		Items:         []CreateOrderItemRequest{{ProductID: "coffee", VariantID: "tea-1kg", Quantity: 1}},
+	})
+	if !errors.Is(err, entity.ErrVariantNotFound) {
+		t.Errorf("unknown variant: got %v, want ErrVariantNotFound", err)
+	}
+
+	if len(f.subscriptions.subscriptions) != 0 {
+		t.Errorf("%d subscriptions saved, want 0", len(f.subscriptions.subscriptions))
+	}
+}
@@ -0,0 +1,16 @@
+package usecase
+
+import "small-ecommers/internal/domain/entity"
+
+// AnyVersion lets an update apply to whatever version is current, as requested with "If-Match: *"
+const AnyVersion = 0
+
+// checkVersion fails with ErrVersionConflict when an update made to version expected
+// would overwrite the newer version current
+// The repositories repeat the check when saving, which catches updates racing in between
+func checkVersion(current, expected int) error {
+	if expected != AnyVersion && current != expected {
+		return entity.ErrVersionConflict
+	}
+	return nil
+}
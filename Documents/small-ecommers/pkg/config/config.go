@@ -2,14 +2,26 @@ package config
 
 import (
 	"fmt"
+	"math/big"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Kafka    KafkaConfig
+	Server    ServerConfig
+	Auth      AuthConfig
+	Database  DatabaseConfig
+	Kafka     KafkaConfig
+	Pricing   PricingConfig
+	Invoice   InvoiceConfig
+	Media     MediaConfig
+	Loyalty   LoyaltyConfig
+	Fraud     FraudConfig
+	Email     EmailConfig
+	Scheduler SchedulerConfig
 }
 
 // ServerConfig holds the server configuration
@@ -17,6 +29,11 @@ type ServerConfig struct {
 	Port string
 }
 
+// AuthConfig holds the authorization configuration
+type AuthConfig struct {
+	AdminUserIDs []string
+}
+
 // DatabaseConfig holds the database configuration
 type DatabaseConfig struct {
 	Host     string
@@ -32,12 +49,87 @@ type KafkaConfig struct {
 	Brokers []string
 }
 
+// PricingConfig holds the order pricing configuration
+// Amounts are decimal strings in Currency, rates and percentages are exact rationals
+type PricingConfig struct {
+	Currency                string
+	TaxRate                 *big.Rat
+	ShippingFee             string
+	FreeShippingThreshold   string
+	BulkDiscountMinQuantity int
+	BulkDiscountPercent     *big.Rat
+	OrderDiscountThreshold  string
+	OrderDiscountPercent    *big.Rat
+}
+
+// InvoiceConfig holds the invoice configuration
+type InvoiceConfig struct {
+	StorageDir    string
+	SellerName    string
+	SellerAddress string
+}
+
+// MediaConfig holds the product image configuration
+// Images are stored below StorageDir and served below BaseURL
+type MediaConfig struct {
+	StorageDir     string
+	BaseURL        string
+	ThumbnailSize  int
+	MaxUploadBytes int
+	MaxPixels      int
+	CacheMaxAge    time.Duration
+}
+
+// LoyaltyConfig holds the loyalty points configuration
+// EarnRate is in points per unit of the pricing currency, PointValue a decimal string in it
+type LoyaltyConfig struct {
+	EarnRate       *big.Rat
+	PointValue     string
+	PointsValidity time.Duration
+}
+
+// FraudConfig holds the fraud screening configuration
+// Orders scoring ReviewScore are held for review and orders scoring RejectScore are cancelled.
+// NewAccountMinTotal is a decimal string in the pricing currency.
+type FraudConfig struct {
+	ReviewScore        int
+	RejectScore        int
+	VelocityWindow     time.Duration
+	MaxOrdersPerUser   int
+	MaxOrdersPerIP     int
+	OutlierMinOrders   int
+	OutlierMultiplier  *big.Rat
+	NewAccountAge      time.Duration
+	NewAccountMinTotal string
+}
+
+// EmailConfig holds the outgoing email configuration
+// Emails are captured in memory and logged instead of sent when SMTPHost is empty
+type EmailConfig struct {
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+}
+
+// SchedulerConfig holds the background job configuration
+type SchedulerConfig struct {
+	SubscriptionInterval  time.Duration
+	SubscriptionBatchSize int
+	PriceInterval         time.Duration
+	PriceBatchSize        int
+}
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
 			Port: getEnv("SERVER_PORT", "3000"),
 		},
+		Auth: AuthConfig{
+			AdminUserIDs: getEnvList("ADMIN_USER_IDS"),
+		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     getEnv("DB_PORT", "5432"),
@@ -49,6 +141,58 @@ func LoadConfig() *Config {
 		Kafka: KafkaConfig{
 			Brokers: []string{getEnv("KAFKA_BROKERS", "localhost:9092")},
 		},
+		Pricing: PricingConfig{
+			Currency:                getEnv("PRICING_CURRENCY", "USD"),
+			TaxRate:                 getEnvRat("PRICING_TAX_RATE", "0"),
+			ShippingFee:             getEnv("PRICING_SHIPPING_FEE", "0"),
+			FreeShippingThreshold:   getEnv("PRICING_FREE_SHIPPING_THRESHOLD", "0"),
+			BulkDiscountMinQuantity: getEnvInt("PRICING_BULK_DISCOUNT_MIN_QUANTITY", 0),
+			BulkDiscountPercent:     getEnvRat("PRICING_BULK_DISCOUNT_PERCENT", "0"),
+			OrderDiscountThreshold:  getEnv("PRICING_ORDER_DISCOUNT_THRESHOLD", "0"),
+			OrderDiscountPercent:    getEnvRat("PRICING_ORDER_DISCOUNT_PERCENT", "0"),
+		},
+		Invoice: InvoiceConfig{
+			StorageDir:    getEnv("INVOICE_STORAGE_DIR", "./data"),
+			SellerName:    getEnv("INVOICE_SELLER_NAME", "Small E-Commerce"),
+			SellerAddress: getEnv("INVOICE_SELLER_ADDRESS", ""),
+		},
+		Media: MediaConfig{
+			StorageDir:     getEnv("MEDIA_STORAGE_DIR", "./data/media"),
+			BaseURL:        getEnv("MEDIA_BASE_URL", "/media"),
+			ThumbnailSize:  getEnvInt("MEDIA_THUMBNAIL_SIZE", 320),
+			MaxUploadBytes: getEnvInt("MEDIA_MAX_UPLOAD_BYTES", 10<<20),
+			MaxPixels:      getEnvInt("MEDIA_MAX_PIXELS", 40_000_000),
+			CacheMaxAge:    getEnvDuration("MEDIA_CACHE_MAX_AGE", 365*24*time.Hour),
+		},
+		Loyalty: LoyaltyConfig{
+			EarnRate:       getEnvRat("LOYALTY_EARN_RATE", "1"),
+			PointValue:     getEnv("LOYALTY_POINT_VALUE", "0.01"),
+			PointsValidity: getEnvDuration("LOYALTY_POINTS_VALIDITY", 365*24*time.Hour),
+		},
+		Fraud: FraudConfig{
+			ReviewScore:        getEnvInt("FRAUD_REVIEW_SCORE", 50),
+			RejectScore:        getEnvInt("FRAUD_REJECT_SCORE", 100),
+			VelocityWindow:     getEnvDuration("FRAUD_VELOCITY_WINDOW", time.Hour),
+			MaxOrdersPerUser:   getEnvInt("FRAUD_MAX_ORDERS_PER_USER", 5),
+			MaxOrdersPerIP:     getEnvInt("FRAUD_MAX_ORDERS_PER_IP", 10),
+			OutlierMinOrders:   getEnvInt("FRAUD_OUTLIER_MIN_ORDERS", 3),
+			OutlierMultiplier:  getEnvRat("FRAUD_OUTLIER_MULTIPLIER", "5"),
+			NewAccountAge:      getEnvDuration("FRAUD_NEW_ACCOUNT_AGE", 24*time.Hour),
+			NewAccountMinTotal: getEnv("FRAUD_NEW_ACCOUNT_MIN_TOTAL", "500"),
+		},
+		Email: EmailConfig{
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			From:         getEnv("EMAIL_FROM", "no-reply@localhost"),
+		},
+		Scheduler: SchedulerConfig{
+			SubscriptionInterval:  getEnvDuration("SUBSCRIPTION_SCHEDULER_INTERVAL", time.Minute),
+			SubscriptionBatchSize: getEnvInt("SUBSCRIPTION_SCHEDULER_BATCH_SIZE", 100),
+			PriceInterval:         getEnvDuration("PRICE_SCHEDULER_INTERVAL", time.Minute),
+			PriceBatchSize:        getEnvInt("PRICE_SCHEDULER_BATCH_SIZE", 100),
+		},
 	}
 }
 
@@ -72,3 +216,45 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList returns the comma separated environment variable as a list
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvRat returns the environment variable parsed as an exact decimal or a default value
+func getEnvRat(key, defaultValue string) *big.Rat {
+	if value := os.Getenv(key); value != "" {
+		if parsed, ok := new(big.Rat).SetString(value); ok {
+			return parsed
+		}
+	}
+	parsed, _ := new(big.Rat).SetString(defaultValue)
+	return parsed
+}
+
+// getEnvInt returns the environment variable parsed as an int or a default value
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDuration returns the environment variable parsed as a duration or a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
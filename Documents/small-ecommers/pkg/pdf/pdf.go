@@ -0,0 +1,167 @@
+// Package pdf is a minimal PDF writer for simple text documents
+// It only uses the standard Helvetica fonts, so no font files are embedded
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Page sizes in points
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Font selects one of the standard fonts
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+// Document is a PDF document made of pages
+type Document struct {
+	width  float64
+	height float64
+	pages  []*Page
+}
+
+// Page is a single page of a document
+// Coordinates are in points with the origin at the bottom left corner
+type Page struct {
+	content bytes.Buffer
+}
+
+// New creates a new empty document with the given page size
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// AddPage appends a new page to the document
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Text draws text with its baseline starting at x, y
+// Characters outside of Latin-1 are replaced with '?'
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		font+1, num(size), num(x), num(y), escape(text))
+}
+
+// TextRight draws text so that it ends at x
+func (p *Page) TextRight(x, y float64, font Font, size float64, text string) {
+	p.Text(x-TextWidth(text, font, size), y, font, size, text)
+}
+
+// Line draws a straight line between two points
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n",
+		num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// WriteTo writes the encoded document to w
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are the catalog, page tree and fonts, pages follow in pairs
+	kids := make([]byte, 0, len(d.pages)*8)
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R ", 5+i*2)...)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		bytes.TrimSpace(kids), len(d.pages), num(d.width), num(d.height)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", 6+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.Bytes()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.WriteTo(w)
+}
+
+// TextWidth returns the width of text in points
+func TextWidth(text string, font Font, size float64) float64 {
+	widths := helveticaWidths
+	if font == HelveticaBold {
+		widths = helveticaBoldWidths
+	}
+
+	units := 0
+	for _, r := range text {
+		if r >= 32 && r < 127 {
+			units += widths[r-32]
+		} else {
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// escape encodes text as the body of a PDF string literal
+func escape(text string) string {
+	var buf bytes.Buffer
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r >= 32 && r < 127:
+			buf.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&buf, "\\%03o", r)
+		default:
+			buf.WriteByte('?')
+		}
+	}
+	return buf.String()
+}
+
+// num formats a coordinate or size compactly
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Glyph widths of the printable ASCII characters, in 1/1000 of the font size
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
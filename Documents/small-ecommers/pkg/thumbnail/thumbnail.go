@@ -0,0 +1,124 @@
+// Package thumbnail scales images down with an area-averaging filter
+// It only uses the standard library, every source pixel contributes to the result in proportion
+// to how much of an output pixel it covers
+package thumbnail
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Fit scales an image down to fit within maxWidth by maxHeight, keeping its aspect ratio
+// Images that already fit are copied unscaled, images are never enlarged
+func Fit(src image.Image, maxWidth, maxHeight int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := Size(bounds.Dx(), bounds.Dy(), maxWidth, maxHeight)
+
+	// Work on premultiplied RGBA so transparent pixels do not bleed their colour
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	if width == bounds.Dx() && height == bounds.Dy() {
+		return rgba
+	}
+
+	return scale(rgba, width, height)
+}
+
+// Size returns the dimensions of a width by height image scaled down to fit within
+// maxWidth by maxHeight, keeping its aspect ratio and at least one pixel on each side
+func Size(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+
+	// Compare the ratios without floating point: width/height > maxWidth/maxHeight
+	if width*maxHeight > maxWidth*height {
+		return maxWidth, max(1, height*maxWidth/width)
+	}
+	return max(1, width*maxHeight/height), maxHeight
+}
+
+// contribution is the share of one source pixel in one output pixel
+type contribution struct {
+	index  int
+	weight float64
+}
+
+// weights returns for each of the dst output pixels the source pixels it covers and their weights
+// The weights of each output pixel add up to one
+func weights(src, dst int) [][]contribution {
+	ratio := float64(src) / float64(dst)
+	result := make([][]contribution, dst)
+
+	for i := range result {
+		start := float64(i) * ratio
+		end := start + ratio
+
+		for j := int(start); j < src && float64(j) < end; j++ {
+			covered := min(end, float64(j+1)) - max(start, float64(j))
+			if covered > 0 {
+				result[i] = append(result[i], contribution{index: j, weight: covered / ratio})
+			}
+		}
+	}
+
+	return result
+}
+
+// scale resamples an image to width by height, horizontally then vertically
+func scale(src *image.RGBA, width, height int) *image.RGBA {
+	srcWidth, srcHeight := src.Rect.Dx(), src.Rect.Dy()
+	columns := weights(srcWidth, width)
+	rows := weights(srcHeight, height)
+
+	// Horizontal pass into a buffer of width by srcHeight channels
+	buffer := make([]float64, width*srcHeight*4)
+	for y := 0; y < srcHeight; y++ {
+		line := src.Pix[y*src.Stride:]
+		for x, column := range columns {
+			out := buffer[(y*width+x)*4:]
+			for _, c := range column {
+				in := line[c.index*4:]
+				out[0] += float64(in[0]) * c.weight
+				out[1] += float64(in[1]) * c.weight
+				out[2] += float64(in[2]) * c.weight
+				out[3] += float64(in[3]) * c.weight
+			}
+		}
+	}
+
+	// Vertical pass into the result
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y, row := range rows {
+		for x := 0; x < width; x++ {
+			var sum [4]float64
+			for _, c := range row {
+				in := buffer[(c.index*width+x)*4:]
+				sum[0] += in[0] * c.weight
+				sum[1] += in[1] * c.weight
+				sum[2] += in[2] * c.weight
+				sum[3] += in[3] * c.weight
+			}
+
+			out := dst.Pix[y*dst.Stride+x*4:]
+			for i, value := range sum {
+				out[i] = clamp(value)
+			}
+		}
+	}
+
+	return dst
+}
+
+// clamp rounds a channel value to the nearest byte
+func clamp(value float64) uint8 {
+	switch {
+	case value <= 0:
+		return 0
+	case value >= 255:
+		return 255
+	default:
+		return uint8(value + 0.5)
+	}
+}